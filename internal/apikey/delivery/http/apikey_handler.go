@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"errors"
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/beego/i18n"
+	"github.com/online-store/internal/apikey"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/response"
+	"github.com/online-store/pkg/validator"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+type ApiKeyHandler struct {
+	beego.Controller
+	apikey.UseCase
+	i18n.Locale
+	response.APIResponseInterface
+	time.Duration
+}
+
+func NewApiKeyHandler(useCase apikey.UseCase, executionTimeout time.Duration, apiResponse response.APIResponseInterface) {
+	handler := &ApiKeyHandler{
+		UseCase:              useCase,
+		APIResponseInterface: apiResponse,
+		Duration:             executionTimeout,
+	}
+
+	beego.Router("/admin/v1/api-key", handler, "post:CreateApiKey")
+	beego.Router("/admin/v1/api-key/:id", handler, "delete:RevokeApiKey")
+}
+
+func (h *ApiKeyHandler) Prepare() {
+	// check user access when needed
+	h.Lang = pkg.GetLangVersion(h.Ctx)
+	requestTime := time.Now().UnixNano() / int64(time.Millisecond)
+	h.Ctx.Input.SetData("request_time", requestTime)
+}
+
+func (h *ApiKeyHandler) CreateApiKey() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	var request domain.CreateApiKeyRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	res, err := h.UseCase.CreateApiKey(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrForeignKeyConstraint) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ForeignKeyConstraintErrorCode, domain.ErrorCodeText(domain.ForeignKeyConstraintErrorCode, h.Locale.Lang, "Data customer"), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *ApiKeyHandler) RevokeApiKey() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	if err := h.UseCase.RevokeApiKey(h.Ctx, h.Ctx.Input.Param(":id")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), nil, nil)
+}
@@ -0,0 +1,12 @@
+package apikey
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+)
+
+type Repository interface {
+	InsertApiKey(ctx context.Context, data domain.ApiKey) (*domain.ApiKey, error)
+	GetApiKeyByHash(ctx context.Context, keyHash string) (domain.ApiKey, error)
+	RevokeApiKey(ctx context.Context, id int) (int64, error)
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/apikey"
+	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ApiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewApiKeyRepository(db *gorm.DB) apikey.Repository {
+	return &ApiKeyRepository{db: db}
+}
+
+func (r *ApiKeyRepository) InsertApiKey(ctx context.Context, data domain.ApiKey) (*domain.ApiKey, error) {
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("RevokedAt", "UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *ApiKeyRepository) GetApiKeyByHash(ctx context.Context, keyHash string) (domain.ApiKey, error) {
+	var data domain.ApiKey
+
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("key_hash = ? AND deleted_at IS NULL", keyHash).First(&data)
+	return data, result.Error
+}
+
+func (r *ApiKeyRepository) RevokeApiKey(ctx context.Context, id int) (int64, error) {
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("api_key").Where("id = ? AND revoked_at IS NULL AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at": time.Now(),
+			"updated_at": time.Now(),
+			"updated_by": "System",
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,12 @@
+package apikey
+
+import (
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+)
+
+type UseCase interface {
+	CreateApiKey(beegoCtx *beegoContext.Context, request domain.CreateApiKeyRequest) (*domain.CreateApiKeyResponse, error)
+	RevokeApiKey(beegoCtx *beegoContext.Context, idReq string) error
+	AuthenticateApiKey(beegoCtx *beegoContext.Context, key string, scope string) (*domain.ApiKey, error)
+}
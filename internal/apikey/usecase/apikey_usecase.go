@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"errors"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/apikey"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ApiKeyUseCase struct {
+	apiKeyRepo apikey.Repository
+	zapLogger  zaplogger.Logger
+}
+
+func NewApiKeyUseCase(apiKeyRepo apikey.Repository, zapLogger zaplogger.Logger) apikey.UseCase {
+	return &ApiKeyUseCase{
+		apiKeyRepo: apiKeyRepo,
+		zapLogger:  zapLogger,
+	}
+}
+
+func (u *ApiKeyUseCase) CreateApiKey(beegoCtx *beegoContext.Context, request domain.CreateApiKeyRequest) (*domain.CreateApiKeyResponse, error) {
+	key, err := pkg.GenerateApiKey(domain.ApiKeyPrefix)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	data, err := u.apiKeyRepo.InsertApiKey(beegoCtx.Request.Context(), domain.ApiKey{
+		Name:       request.Name,
+		KeyPrefix:  key[:len(domain.ApiKeyPrefix)+8],
+		KeyHash:    pkg.HashApiKey(key),
+		Scopes:     strings.Join(request.Scopes, ","),
+		CustomerID: request.CustomerID,
+		ExpiredAt:  request.ExpiredAt,
+		CreatedAt:  time.Now(),
+		CreatedBy:  "System",
+	})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		pgerr, ok := err.(*pgconn.PgError)
+		if !ok {
+			return nil, err
+		}
+		switch pgerr.Code {
+		case domain.PgCodeForeignKeyConstraint:
+			return nil, domain.ErrForeignKeyConstraint
+		case domain.PgCodeUniqueConstraint:
+			return nil, domain.ErrUniqueConstraint
+		default:
+			return nil, err
+		}
+	}
+
+	return &domain.CreateApiKeyResponse{
+		ApiKey: *data,
+		Key:    key,
+	}, nil
+}
+
+func (u *ApiKeyUseCase) RevokeApiKey(beegoCtx *beegoContext.Context, idReq string) error {
+	id, err := strconv.Atoi(idReq)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	affected, err := u.apiKeyRepo.RevokeApiKey(beegoCtx.Request.Context(), id)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	if affected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (u *ApiKeyUseCase) AuthenticateApiKey(beegoCtx *beegoContext.Context, key string, scope string) (*domain.ApiKey, error) {
+	data, err := u.apiKeyRepo.GetApiKeyByHash(beegoCtx.Request.Context(), pkg.HashApiKey(key))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrApiKeyNotRegistered
+		}
+		return nil, err
+	}
+
+	if data.RevokedAt != nil || (data.ExpiredAt != nil && data.ExpiredAt.Before(time.Now())) {
+		return nil, domain.ErrApiKeyInvalid
+	}
+
+	if !data.HasScope(scope) {
+		return nil, domain.ErrApiKeyForbidden
+	}
+
+	return &data, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/online-store/internal/cart"
 	"github.com/online-store/internal/domain"
 	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/database"
 	paging "github.com/online-store/pkg/paging"
 	"github.com/online-store/pkg/response"
 	"github.com/online-store/pkg/validator"
@@ -34,6 +35,8 @@ func NewProductHandler(useCase cart.UseCase, executionTimeout time.Duration, api
 	beego.Router("/customer/v1/cart", handler, "post:CreateCart")
 	beego.Router("/customer/v1/cart", handler, "get:GetListCart")
 	beego.Router("/customer/v1/cart/:id", handler, "delete:DeleteCart")
+	beego.Router("/customer/v1/cart/promotions", handler, "get:GetCartPromotions")
+	beego.Router("/customer/v1/cart/shipping-quotes", handler, "post:GetShippingQuotes")
 }
 
 func (h *CartHandler) Prepare() {
@@ -62,6 +65,10 @@ func (h *CartHandler) CreateCart() {
 	}
 
 	if err := h.UseCase.InsertCartItem(h.Ctx, request); err != nil {
+		if errors.Is(err, domain.ErrVariantRequired) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.VariantRequiredErrorCode, domain.ErrorCodeText(domain.VariantRequiredErrorCode, h.Locale.Lang), nil)
+			return
+		}
 		if errors.Is(err, domain.ErrForeignKeyConstraint) {
 			customMsg := fmt.Sprintf("Data product")
 			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ForeignKeyConstraintErrorCode, domain.ErrorCodeText(domain.ForeignKeyConstraintErrorCode, h.Locale.Lang, customMsg), nil)
@@ -93,12 +100,37 @@ func (h *CartHandler) GetListCart() {
 		return
 	}
 
+	cursorMode, err := paging.CursorValidation(h.Ctx.Input.Query("pagination"), h.Ctx.Input.Query("cursor"))
+	if err != nil {
+		h.ResponseError(
+			h.Ctx,
+			http.StatusBadRequest,
+			domain.InvalidUrlQueryParamErrorCode,
+			domain.ErrorCodeText(domain.InvalidUrlQueryParamErrorCode, h.Locale.Lang),
+			domain.ErrInvalidUrlQueryParam,
+		)
+		return
+	}
+
 	request.Limit = limit
 	request.Page = page
+	request.CursorMode = cursorMode
+	request.Cursor = h.Ctx.Input.Query("cursor")
 	request.CustomerID = h.Ctx.Input.GetData("userID").(int)
+	request.Currency = pkg.GetCurrency(h.Ctx)
 
 	res, err := h.UseCase.GetListCartItem(h.Ctx, request)
 	if err != nil {
+		if errors.Is(err, database.ErrInvalidCursor) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlQueryParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlQueryParamErrorCode, h.Locale.Lang), domain.ErrInvalidUrlQueryParam)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUnsupportedCurrency) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.UnsupportedCurrencyErrorCode, domain.ErrorCodeText(domain.UnsupportedCurrencyErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
 		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), nil)
 		return
 	}
@@ -122,3 +154,66 @@ func (h *CartHandler) DeleteCart() {
 
 	h.Ok(h.Ctx, h.Tr("message.success"), nil, nil)
 }
+
+func (h *CartHandler) GetCartPromotions() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	res, err := h.UseCase.GetCartPromotions(h.Ctx, h.Ctx.Input.GetData("userID").(int), pkg.GetCurrency(h.Ctx))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUnsupportedCurrency) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.UnsupportedCurrencyErrorCode, domain.ErrorCodeText(domain.UnsupportedCurrencyErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *CartHandler) GetShippingQuotes() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	var request domain.ShippingQuoteRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	request.CustomerID = h.Ctx.Input.GetData("userID").(int)
+	request.Currency = pkg.GetCurrency(h.Ctx)
+
+	res, err := h.UseCase.GetShippingQuotes(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUnsupportedCurrency) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.UnsupportedCurrencyErrorCode, domain.ErrorCodeText(domain.UnsupportedCurrencyErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"github.com/online-store/internal/domain"
 	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
 )
 
 type Repository interface {
+	DB() *gorm.DB
 	InsertCartItem(ctx context.Context, data []domain.Cart) error
+	HasVariants(ctx context.Context, productID int) (bool, error)
 	FetchWithFilterAndPaginationAndOrderBy(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+	FetchWithFilterAndCursor(ctx context.Context, cursor string, pageSize int, query string, keys []database.CursorKey, model interface{}, args ...interface{}) (*database.Paginator, error)
 	DeleteCartItem(ctx context.Context, cartID, customerID int) error
+	GetCartProducts(ctx context.Context, query string, args ...interface{}) ([]domain.CartProduct, error)
 }
@@ -20,6 +20,19 @@ func NewCartRepository(db *gorm.DB) cart.Repository {
 	return &CartRepository{db: db}
 }
 
+func (r *CartRepository) DB() *gorm.DB {
+	return r.db
+}
+
+// HasVariants reports whether the product is sold by variant.
+func (r *CartRepository) HasVariants(ctx context.Context, productID int) (bool, error) {
+	var count int64
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("product_variant").Where("product_id = ? AND deleted_at IS NULL", productID).Count(&count).Error
+	return count > 0, err
+}
+
 func (r *CartRepository) InsertCartItem(ctx context.Context, data []domain.Cart) error {
 	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("CartID", "UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").CreateInBatches(&data, 20).Error
 	if err != nil {
@@ -43,6 +56,21 @@ func (r *CartRepository) FetchWithFilterAndPaginationAndOrderBy(ctx context.Cont
 	return paginate, nil
 }
 
+func (r *CartRepository) FetchWithFilterAndCursor(ctx context.Context, cursor string, pageSize int, query string, keys []database.CursorKey, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	linq.From(args).Where(func(item interface{}) bool {
+		if reflect.TypeOf(item).Kind() == reflect.Slice {
+			return len(item.([]string)) != 0
+		}
+		return item != ""
+	}).ToSlice(&args)
+	paginate := database.NewPaginator(r.db, 0, pageSize, model).Raw(query, args, "", nil).Cursor(cursor, keys...)
+
+	if err := paginate.Find(ctx).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
+
 func (r *CartRepository) DeleteCartItem(ctx context.Context, cartID, customerID int) error {
 	return r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
 		Table("cart").Where("cart_id = ? AND customer_id = ?", cartID, customerID).
@@ -51,3 +79,10 @@ func (r *CartRepository) DeleteCartItem(ctx context.Context, cartID, customerID
 			"deleted_by": "System",
 		}).Error
 }
+
+func (r *CartRepository) GetCartProducts(ctx context.Context, query string, args ...interface{}) ([]domain.CartProduct, error) {
+	var data []domain.CartProduct
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(query, args...).Scan(&data).Error
+	return data, err
+}
@@ -1,39 +1,83 @@
 package usecase
 
 import (
-	"context"
 	"fmt"
 	beegoContext "github.com/beego/beego/v2/server/web/context"
 	"github.com/jackc/pgconn"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/online-store/internal/cart"
+	"github.com/online-store/internal/currency"
 	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/promotion"
+	"github.com/online-store/internal/recommendation"
+	"github.com/online-store/internal/shipping"
 	"github.com/online-store/pkg/cache"
 	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
 	"github.com/online-store/pkg/zaplogger"
 	"strconv"
 	"time"
 )
 
+// cartProductQuery selects the cart lines of a customer with their product.
+const cartProductQuery = `SELECT 
+					c.cart_id ,
+					c.product_id,
+					p.name as product_name,
+					p.description as product_description,
+					c.variant_id,
+					v.sku,
+					p.category_id,
+					ca."name" as category_name,
+					COALESCE(v.price, p.price) as product_price,
+					c.quantity as quantity,
+					c.created_at
+					from cart c 
+					join product p ON p.id = c.product_id 
+					join category ca on ca.id = p.category_id 
+					left join product_variant v on v.id = c.variant_id 
+				WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL AND ca.deleted_at IS NULL AND c.customer_id = ?`
+
 type CartUseCase struct {
-	cartRepo  cart.Repository
-	zapLogger zaplogger.Logger
-	cacheRepo cache.RedisRepository
+	cartRepo         cart.Repository
+	promotionUC      promotion.UseCase
+	currencyUC       currency.UseCase
+	shippingUC       shipping.UseCase
+	recommendationUC recommendation.UseCase
+	zapLogger        zaplogger.Logger
+	cacheRepo        cache.RedisRepository
 }
 
-func NewCustomerUseCase(cartRepo cart.Repository, zapLogger zaplogger.Logger, cacheRepo cache.RedisRepository) cart.UseCase {
+func NewCustomerUseCase(cartRepo cart.Repository, promotionUC promotion.UseCase, currencyUC currency.UseCase, shippingUC shipping.UseCase, recommendationUC recommendation.UseCase, zapLogger zaplogger.Logger, cacheRepo cache.RedisRepository) cart.UseCase {
 	return &CartUseCase{
-		cartRepo:  cartRepo,
-		zapLogger: zapLogger,
-		cacheRepo: cacheRepo,
+		cartRepo:         cartRepo,
+		promotionUC:      promotionUC,
+		currencyUC:       currencyUC,
+		shippingUC:       shippingUC,
+		recommendationUC: recommendationUC,
+		zapLogger:        zapLogger,
+		cacheRepo:        cacheRepo,
 	}
 }
 
 func (u *CartUseCase) InsertCartItem(beegoCtx *beegoContext.Context, request domain.CreateCartRequest) error {
 	var data []domain.Cart
 	for _, v := range request.CartItem {
+		//a product sold by variant is added by variant, as it is ordered
+		if v.VariantID == nil {
+			hasVariants, err := u.cartRepo.HasVariants(beegoCtx.Request.Context(), v.ProductID)
+			if err != nil {
+				beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+				return err
+			}
+			if hasVariants {
+				return domain.ErrVariantRequired
+			}
+		}
+
 		data = append(data, domain.Cart{
 			ProductID:  v.ProductID,
+			VariantID:  v.VariantID,
 			Quantity:   v.Quantity,
 			CustomerID: request.CustomerID,
 			CreatedAt:  time.Now(),
@@ -59,7 +103,7 @@ func (u *CartUseCase) InsertCartItem(beegoCtx *beegoContext.Context, request dom
 
 	//delete existing cache
 	err = u.cacheRepo.Deletes(beegoCtx.Request.Context(), []string{
-		domain.CartKeyCache,
+		domain.CartCustomerKeyCache(request.CustomerID),
 	})
 
 	if err != nil {
@@ -69,40 +113,91 @@ func (u *CartUseCase) InsertCartItem(beegoCtx *beegoContext.Context, request dom
 	return nil
 }
 
-func (u *CartUseCase) GetListCartItem(beegoCtx *beegoContext.Context, request domain.GetListCartRequest) (*database.Paginator, error) {
+// GetListCartItem returns a page of the cart with the products recommended for the
+// whole cart.
+func (u *CartUseCase) GetListCartItem(beegoCtx *beegoContext.Context, request domain.GetListCartRequest) (*domain.CartList, error) {
+	//the cart is cached in the base currency and converted for the response
+	rate, err := u.currencyUC.GetExchangeRate(beegoCtx.Request.Context(), request.Currency)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	data, err := u.getCartPage(beegoCtx, request, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := u.cartRepo.GetCartProducts(beegoCtx.Request.Context(), cartProductQuery, request.CustomerID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	productIDs := make([]int, 0, len(entities))
+	seen := make(map[int]bool, len(entities))
+	for _, v := range entities {
+		if !seen[v.ProductID] {
+			seen[v.ProductID] = true
+			productIDs = append(productIDs, v.ProductID)
+		}
+	}
+
+	related, err := u.recommendationUC.GetRelatedProducts(beegoCtx.Request.Context(), productIDs)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	for i, v := range related {
+		if related[i].Price, err = rate.Convert(v.Price); err != nil {
+			return nil, err
+		}
+	}
+
+	return &domain.CartList{Paginator: data, Recommendations: append([]domain.RelatedProduct{}, related...)}, nil
+}
+
+// getCartPage returns a page of the cart, from the cache when it is there.
+func (u *CartUseCase) getCartPage(beegoCtx *beegoContext.Context, request domain.GetListCartRequest, rate domain.ExchangeRate) (*database.Paginator, error) {
 	var entities []domain.CartProduct
 
-	cacheKey := fmt.Sprintf("%s:%s", domain.CartKeyCache, fmt.Sprintf("%s|%d|%d", "ALL", request.Page, request.Limit))
+	cacheKey := domain.CartCustomerKeyCache(request.CustomerID) + fmt.Sprintf("%d|%d|%t|%s", request.Page, request.Limit, request.CursorMode, request.Cursor)
 
 	//check cache
 	redisResult, err := u.cacheRepo.Fetch(beegoCtx.Request.Context(), cacheKey)
 	if err != nil {
-		query := `SELECT 
-					c.cart_id ,
-					p.name as product_name,
-					p.description as product_description,
-					ca."name" as category_name,
-					p.price as product_price,
-					c.quantity as quantity
-					from cart c 
-					join product p ON p.id = c.product_id 
-					join category ca on ca.id = p.category_id 
-				WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL AND ca.deleted_at IS NULL AND c.customer_id = ?`
+		query := cartProductQuery
 		countQuery := `SELECT COUNT(*) from cart c 
 					join product p ON p.id = c.product_id 
 					join category ca on ca.id = p.category_id 
 				WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL AND ca.deleted_at IS NULL AND c.customer_id = ?`
 
-		data, err := u.cartRepo.FetchWithFilterAndPaginationAndOrderBy(
-			context.Background(),
-			request.Page,
-			request.Limit,
-			query,
-			countQuery,
-			"ORDER BY c.created_at DESC",
-			&entities,
-			request.CustomerID,
-		)
+		var data *database.Paginator
+		if request.CursorMode {
+			data, err = u.cartRepo.FetchWithFilterAndCursor(
+				beegoCtx.Request.Context(),
+				request.Cursor,
+				request.Limit,
+				query,
+				[]database.CursorKey{
+					{Column: "created_at", Field: "CreatedAt", Desc: true},
+					{Column: "cart_id", Field: "CartID", Desc: true},
+				},
+				&entities,
+				request.CustomerID,
+			)
+		} else {
+			data, err = u.cartRepo.FetchWithFilterAndPaginationAndOrderBy(
+				beegoCtx.Request.Context(),
+				request.Page,
+				request.Limit,
+				query,
+				countQuery,
+				"ORDER BY c.created_at DESC",
+				&entities,
+				request.CustomerID,
+			)
+		}
 
 		if err != nil {
 			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
@@ -114,14 +209,21 @@ func (u *CartUseCase) GetListCartItem(beegoCtx *beegoContext.Context, request do
 			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
 		}
 
+		if err := convertCartProducts(entities, rate); err != nil {
+			return nil, err
+		}
 		return data, nil
 	}
 
 	var paginator = new(database.Paginator)
+	paginator.Records = &entities
 	if err := jsoniter.UnmarshalFromString(*redisResult, paginator); err != nil {
 		return nil, err
 	}
 
+	if err := convertCartProducts(entities, rate); err != nil {
+		return nil, err
+	}
 	return paginator, nil
 }
 
@@ -140,7 +242,7 @@ func (u *CartUseCase) DeleteCartItem(beegoCtx *beegoContext.Context, cartIDReq s
 
 	//delete existing cache
 	err = u.cacheRepo.Deletes(beegoCtx.Request.Context(), []string{
-		domain.CartKeyCache,
+		domain.CartCustomerKeyCache(customerIDReq),
 	})
 
 	if err != nil {
@@ -149,3 +251,109 @@ func (u *CartUseCase) DeleteCartItem(beegoCtx *beegoContext.Context, cartIDReq s
 
 	return nil
 }
+
+// GetCartPromotions applies the running promotions to the whole cart of the customer.
+func (u *CartUseCase) GetCartPromotions(beegoCtx *beegoContext.Context, customerID int, currency string) (*domain.PromotionResult, error) {
+	rate, err := u.currencyUC.GetExchangeRate(beegoCtx.Request.Context(), currency)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	entities, err := u.cartRepo.GetCartProducts(beegoCtx.Request.Context(), cartProductQuery+" ORDER BY c.created_at DESC", customerID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	lines := make([]domain.PromotionLine, 0, len(entities))
+	for _, v := range entities {
+		lines = append(lines, domain.PromotionLine{
+			ProductID:  v.ProductID,
+			VariantID:  v.VariantID,
+			CategoryID: v.CategoryID,
+			UnitPrice:  v.ProductPrice,
+			Quantity:   v.Quantity,
+		})
+	}
+
+	//promotions are evaluated in the base currency, the result is converted
+	data, err := u.promotionUC.Evaluate(beegoCtx.Request.Context(), u.cartRepo.DB(), lines)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	if data.Subtotal, err = rate.Convert(data.Subtotal); err != nil {
+		return nil, err
+	}
+	if data.Discount, err = rate.Convert(data.Discount); err != nil {
+		return nil, err
+	}
+	if data.Total, err = rate.Convert(data.Total); err != nil {
+		return nil, err
+	}
+	for i, v := range data.Applied {
+		if data.Applied[i].Discount, err = rate.Convert(v.Discount); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// GetShippingQuotes prices the shipping of the whole cart of the customer to the
+// address, with every method available there.
+func (u *CartUseCase) GetShippingQuotes(beegoCtx *beegoContext.Context, request domain.ShippingQuoteRequest) ([]domain.ShippingQuote, error) {
+	rate, err := u.currencyUC.GetExchangeRate(beegoCtx.Request.Context(), request.Currency)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	entities, err := u.cartRepo.GetCartProducts(beegoCtx.Request.Context(), cartProductQuery+" ORDER BY c.created_at DESC", request.CustomerID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	subtotal := money.Zero(money.DefaultCurrency())
+	lines := make([]domain.ShippingLine, 0, len(entities))
+	for _, v := range entities {
+		price, err := v.ProductPrice.Mul(int64(v.Quantity))
+		if err != nil {
+			return nil, err
+		}
+		if subtotal, err = subtotal.Add(price); err != nil {
+			return nil, err
+		}
+		lines = append(lines, domain.ShippingLine{ProductID: v.ProductID, Quantity: v.Quantity})
+	}
+
+	//shipping is quoted in the base currency, the costs are converted
+	data, err := u.shippingUC.Quote(beegoCtx.Request.Context(), u.cartRepo.DB(), request.Address, lines, subtotal)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	for i, v := range data {
+		if data[i].Cost, err = rate.Convert(v.Cost); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// convertCartProducts converts the prices of the cart lines from the base currency
+// to the currency of rate.
+func convertCartProducts(entities []domain.CartProduct, rate domain.ExchangeRate) error {
+	for i := range entities {
+		var err error
+		if entities[i].ProductPrice, err = rate.Convert(entities[i].ProductPrice); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/online-store/internal/coupon"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/money"
+	paging "github.com/online-store/pkg/paging"
+	"github.com/online-store/pkg/response"
+	"github.com/online-store/pkg/validator"
+	"gorm.io/gorm"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/beego/i18n"
+)
+
+type CouponHandler struct {
+	beego.Controller
+	coupon.UseCase
+	i18n.Locale
+	response.APIResponseInterface
+	time.Duration
+}
+
+func NewCouponHandler(useCase coupon.UseCase, executionTimeout time.Duration, apiResponse response.APIResponseInterface) {
+	handler := &CouponHandler{
+		UseCase:              useCase,
+		APIResponseInterface: apiResponse,
+		Duration:             executionTimeout,
+	}
+
+	beego.Router("/admin/v1/coupons", handler, "post:CreateCoupon;get:GetCoupons")
+	beego.Router("/admin/v1/coupons/:id", handler, "put:UpdateCoupon")
+	beego.Router("/customer/v1/coupons/validate", handler, "post:ValidateCoupon")
+}
+
+func (h *CouponHandler) Prepare() {
+	// check user access when needed
+	h.Lang = pkg.GetLangVersion(h.Ctx)
+	requestTime := time.Now().UnixNano() / int64(time.Millisecond)
+	h.Ctx.Input.SetData("request_time", requestTime)
+}
+
+func (h *CouponHandler) CreateCoupon() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	var request domain.CreateCouponRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	res, err := h.UseCase.CreateCoupon(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidDiscountValue) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidDiscountValueErrorCode, domain.ErrorCodeText(domain.InvalidDiscountValueErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUniqueConstraint) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.DataAlreadyExist, domain.ErrorCodeText(domain.DataAlreadyExist, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrForeignKeyConstraint) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ForeignKeyConstraintErrorCode, domain.ErrorCodeText(domain.ForeignKeyConstraintErrorCode, h.Locale.Lang, "Data product or category"), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.insertedSuccess"), res, nil)
+}
+
+func (h *CouponHandler) GetCoupons() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	limit, page, err := paging.PageAndPageSizeValidation(h.Ctx.Input.Query("limit"), h.Ctx.Input.Query("page"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlQueryParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlQueryParamErrorCode, h.Locale.Lang), domain.ErrInvalidUrlQueryParam)
+		return
+	}
+
+	res, err := h.UseCase.GetCoupons(h.Ctx, domain.GetCouponListRequest{
+		Page:  page,
+		Limit: limit,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *CouponHandler) UpdateCoupon() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	couponID, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	var request domain.UpdateCouponRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	request.ID = couponID
+
+	res, err := h.UseCase.UpdateCoupon(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.updatedSuccess"), res, nil)
+}
+
+func (h *CouponHandler) ValidateCoupon() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	var request domain.ValidateCouponRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	request.CustomerID = h.Ctx.Input.GetData("userID").(int)
+
+	res, err := h.UseCase.ValidateCoupon(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrCouponInvalid) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.CouponInvalidErrorCode, domain.ErrorCodeText(domain.CouponInvalidErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrCouponMinSpend) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.CouponMinSpendErrorCode, domain.ErrorCodeText(domain.CouponMinSpendErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrCouponNotApplicable) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.CouponNotApplicableErrorCode, domain.ErrorCodeText(domain.CouponNotApplicableErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrCouponUsageLimit) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.CouponUsageLimitErrorCode, domain.ErrorCodeText(domain.CouponUsageLimitErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, money.ErrCurrencyMismatch) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
@@ -0,0 +1,25 @@
+package coupon
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	InsertCoupon(ctx context.Context, tx *gorm.DB, data domain.Coupon) (*domain.Coupon, error)
+	InsertTargets(ctx context.Context, tx *gorm.DB, data []domain.CouponTarget) error
+	FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+	GetCouponByID(ctx context.Context, couponID int) (domain.Coupon, error)
+	GetCouponByCode(ctx context.Context, tx *gorm.DB, code string) (domain.Coupon, error)
+	GetTargets(ctx context.Context, tx *gorm.DB, couponIDs []int) ([]domain.CouponTarget, error)
+	UpdateCoupon(ctx context.Context, couponID int, data map[string]interface{}) (int64, error)
+	GetProductCategories(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.CouponLine, error)
+	ReserveUsage(ctx context.Context, tx *gorm.DB, couponID int) (domain.Coupon, int64, error)
+	CountCustomerRedemptions(ctx context.Context, tx *gorm.DB, couponID, customerID int) (int64, error)
+	InsertRedemption(ctx context.Context, tx *gorm.DB, data domain.CouponRedemption) error
+	DeleteRedemptionsByOrderID(ctx context.Context, tx *gorm.DB, orderID int) ([]int, error)
+	ReleaseUsage(ctx context.Context, tx *gorm.DB, couponID int) error
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/coupon"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type CouponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) coupon.Repository {
+	return &CouponRepository{db}
+}
+
+func (r *CouponRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *CouponRepository) InsertCoupon(ctx context.Context, tx *gorm.DB, data domain.Coupon) (*domain.Coupon, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UsedCount", "UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *CouponRepository) InsertTargets(ctx context.Context, tx *gorm.DB, data []domain.CouponTarget) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).CreateInBatches(&data, 100).Error
+}
+
+func (r *CouponRepository) FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	paginate := database.NewPaginator(r.db, page, pageSize, model).Raw(query, args, countQuery, args)
+
+	if err := paginate.FindWithOrderBy(ctx, orderBy).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
+
+func (r *CouponRepository) GetCouponByID(ctx context.Context, couponID int) (domain.Coupon, error) {
+	var data domain.Coupon
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", couponID).First(&data).Error
+	return data, err
+}
+
+// GetCouponByCode finds a coupon by its code, whatever the case it is typed in.
+func (r *CouponRepository) GetCouponByCode(ctx context.Context, tx *gorm.DB, code string) (domain.Coupon, error) {
+	var data domain.Coupon
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("code = upper(?) AND deleted_at IS NULL", code).First(&data).Error
+	return data, err
+}
+
+func (r *CouponRepository) GetTargets(ctx context.Context, tx *gorm.DB, couponIDs []int) ([]domain.CouponTarget, error) {
+	var data []domain.CouponTarget
+	if len(couponIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("coupon_id IN ?", couponIDs).Find(&data).Error
+	return data, err
+}
+
+func (r *CouponRepository) UpdateCoupon(ctx context.Context, couponID int, data map[string]interface{}) (int64, error) {
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("coupon").Where("id = ? AND deleted_at IS NULL", couponID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
+
+func (r *CouponRepository) GetProductCategories(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.CouponLine, error) {
+	var data []domain.CouponLine
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT id AS product_id, category_id FROM product WHERE id IN ?`, productIDs).Scan(&data).Error
+	return data, err
+}
+
+// ReserveUsage counts one more use of the coupon unless its usage limit is reached.
+// The coupon row stays locked until the end of the transaction, so concurrent
+// checkouts with the same coupon are counted one after the other.
+func (r *CouponRepository) ReserveUsage(ctx context.Context, tx *gorm.DB, couponID int) (domain.Coupon, int64, error) {
+	var data domain.Coupon
+
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`UPDATE coupon 
+				SET used_count = used_count + 1 
+				WHERE id = ? AND deleted_at IS NULL AND (usage_limit IS NULL OR used_count < usage_limit) 
+				RETURNING *`, couponID).Scan(&data)
+	return data, result.RowsAffected, result.Error
+}
+
+func (r *CouponRepository) CountCustomerRedemptions(ctx context.Context, tx *gorm.DB, couponID, customerID int) (int64, error) {
+	var count int64
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Model(&domain.CouponRedemption{}).Where("coupon_id = ? AND customer_id = ?", couponID, customerID).Count(&count).Error
+	return count, err
+}
+
+func (r *CouponRepository) InsertRedemption(ctx context.Context, tx *gorm.DB, data domain.CouponRedemption) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("ID").Create(&data).Error
+}
+
+// DeleteRedemptionsByOrderID deletes the redemptions of the order and returns the
+// coupons they used.
+func (r *CouponRepository) DeleteRedemptionsByOrderID(ctx context.Context, tx *gorm.DB, orderID int) ([]int, error) {
+	var couponIDs []int
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`DELETE FROM coupon_redemption 
+				WHERE order_id = ? 
+				RETURNING coupon_id`, orderID).Scan(&couponIDs).Error
+	return couponIDs, err
+}
+
+// ReleaseUsage counts one use of the coupon less.
+func (r *CouponRepository) ReleaseUsage(ctx context.Context, tx *gorm.DB, couponID int) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Exec(`UPDATE coupon 
+				SET used_count = used_count - 1 
+				WHERE id = ? AND used_count > 0`, couponID).Error
+}
@@ -0,0 +1,20 @@
+package coupon
+
+import (
+	"context"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type UseCase interface {
+	CreateCoupon(beegoCtx *beegoContext.Context, req domain.CreateCouponRequest) (*domain.Coupon, error)
+	GetCoupons(beegoCtx *beegoContext.Context, req domain.GetCouponListRequest) (*database.Paginator, error)
+	UpdateCoupon(beegoCtx *beegoContext.Context, req domain.UpdateCouponRequest) (*domain.Coupon, error)
+	ValidateCoupon(beegoCtx *beegoContext.Context, req domain.ValidateCouponRequest) (*domain.CouponDiscount, error)
+	PriceOrder(ctx context.Context, tx *gorm.DB, code string, order []domain.OrderRequest) (*domain.CouponDiscount, error)
+	Redeem(ctx context.Context, tx *gorm.DB, discount domain.CouponDiscount, customerID, orderID int) error
+	Release(ctx context.Context, tx *gorm.DB, orderID int) error
+}
@@ -0,0 +1,358 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/coupon"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/promotion"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type CouponUseCase struct {
+	couponRepo  coupon.Repository
+	promotionUC promotion.UseCase
+	zapLogger   zaplogger.Logger
+}
+
+func NewCouponUseCase(couponRepo coupon.Repository, promotionUC promotion.UseCase, zapLogger zaplogger.Logger) coupon.UseCase {
+	return &CouponUseCase{
+		couponRepo:  couponRepo,
+		promotionUC: promotionUC,
+		zapLogger:   zapLogger,
+	}
+}
+
+// CreateCoupon stores the coupon with its code in upper case, so codes are matched
+// whatever the case the customer types them in.
+func (u *CouponUseCase) CreateCoupon(beegoCtx *beegoContext.Context, req domain.CreateCouponRequest) (*domain.Coupon, error) {
+	if !domain.ValidDiscount(req.DiscountType, req.DiscountValue, req.DiscountAmount) {
+		return nil, domain.ErrInvalidDiscountValue
+	}
+
+	var (
+		data *domain.Coupon
+		err  error
+	)
+
+	//start transaction
+	errs := u.couponRepo.DB().Transaction(func(tx *gorm.DB) error {
+		data, err = u.couponRepo.InsertCoupon(beegoCtx.Request.Context(), tx, domain.Coupon{
+			Code:             strings.ToUpper(req.Code),
+			Description:      req.Description,
+			DiscountType:     req.DiscountType,
+			DiscountValue:    req.DiscountValue,
+			DiscountAmount:   req.DiscountAmount,
+			MaxDiscount:      req.MaxDiscount,
+			MinSpend:         req.MinSpend,
+			UsageLimit:       req.UsageLimit,
+			UsagePerCustomer: req.UsagePerCustomer,
+			StartsAt:         req.StartsAt,
+			EndsAt:           req.EndsAt,
+			IsActive:         true,
+			CreatedAt:        time.Now(),
+			CreatedBy:        "System",
+		})
+		if err != nil {
+			return err
+		}
+
+		var targets []domain.CouponTarget
+		for i := range req.ProductIDs {
+			targets = append(targets, domain.CouponTarget{CouponID: data.ID, ProductID: &req.ProductIDs[i]})
+		}
+		for i := range req.CategoryIDs {
+			targets = append(targets, domain.CouponTarget{CouponID: data.ID, CategoryID: &req.CategoryIDs[i]})
+		}
+		data.ProductIDs, data.CategoryIDs = req.ProductIDs, req.CategoryIDs
+
+		return u.couponRepo.InsertTargets(beegoCtx.Request.Context(), tx, targets)
+	})
+
+	if errs != nil {
+		if pgErr, ok := errs.(*pgconn.PgError); ok {
+			switch pgErr.Code {
+			case domain.PgCodeUniqueConstraint:
+				return nil, domain.ErrUniqueConstraint
+			case domain.PgCodeForeignKeyConstraint:
+				return nil, domain.ErrForeignKeyConstraint
+			}
+		}
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		return nil, errs
+	}
+
+	return data, nil
+}
+
+func (u *CouponUseCase) GetCoupons(beegoCtx *beegoContext.Context, req domain.GetCouponListRequest) (*database.Paginator, error) {
+	var entities []domain.Coupon
+
+	query := `SELECT * FROM coupon WHERE deleted_at IS NULL`
+	countQuery := `SELECT COUNT(*) FROM coupon WHERE deleted_at IS NULL`
+
+	data, err := u.couponRepo.FetchWithFilterAndPagination(
+		beegoCtx.Request.Context(),
+		req.Page,
+		req.Limit,
+		query,
+		countQuery,
+		"ORDER BY created_at DESC, id DESC",
+		&entities,
+	)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	couponIDs := make([]int, 0, len(entities))
+	for _, v := range entities {
+		couponIDs = append(couponIDs, v.ID)
+	}
+	targets, err := u.couponRepo.GetTargets(beegoCtx.Request.Context(), u.couponRepo.DB(), couponIDs)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	for i := range entities {
+		setTargets(&entities[i], targets)
+	}
+
+	return data, nil
+}
+
+// UpdateCoupon changes the given fields only. The code and the discount are kept
+// as they were, so orders that already used the coupon still match it.
+func (u *CouponUseCase) UpdateCoupon(beegoCtx *beegoContext.Context, req domain.UpdateCouponRequest) (*domain.Coupon, error) {
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+		"updated_by": "System",
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.MinSpend != nil {
+		updates["min_spend"] = *req.MinSpend
+	}
+	if req.UsageLimit != nil {
+		updates["usage_limit"] = *req.UsageLimit
+	}
+	if req.UsagePerCustomer != nil {
+		updates["usage_per_customer"] = *req.UsagePerCustomer
+	}
+	if req.StartsAt != nil {
+		updates["starts_at"] = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		updates["ends_at"] = *req.EndsAt
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	affected, err := u.couponRepo.UpdateCoupon(beegoCtx.Request.Context(), req.ID, updates)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	data, err := u.couponRepo.GetCouponByID(beegoCtx.Request.Context(), req.ID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	targets, err := u.couponRepo.GetTargets(beegoCtx.Request.Context(), u.couponRepo.DB(), []int{data.ID})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	setTargets(&data, targets)
+
+	return &data, nil
+}
+
+// ValidateCoupon previews the discount of the coupon on the order. The usage limits
+// are checked too, but they are only enforced when the order is checked out.
+func (u *CouponUseCase) ValidateCoupon(beegoCtx *beegoContext.Context, req domain.ValidateCouponRequest) (*domain.CouponDiscount, error) {
+	//the coupon is priced on the lines left to pay after the promotions, as at checkout
+	promotions, err := u.promotionUC.EvaluateOrder(beegoCtx.Request.Context(), u.couponRepo.DB(), req.Order)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	order, err := promotions.NetOf(req.Order)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	data, err := u.PriceOrder(beegoCtx.Request.Context(), u.couponRepo.DB(), req.CouponCode, order)
+	if err != nil {
+		if !isCouponError(err) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return nil, err
+	}
+
+	entity, err := u.couponRepo.GetCouponByID(beegoCtx.Request.Context(), data.CouponID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	if entity.UsageLimit != nil && entity.UsedCount >= *entity.UsageLimit {
+		return nil, domain.ErrCouponUsageLimit
+	}
+	if entity.UsagePerCustomer != nil {
+		count, err := u.couponRepo.CountCustomerRedemptions(beegoCtx.Request.Context(), u.couponRepo.DB(), entity.ID, req.CustomerID)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return nil, err
+		}
+		if count >= int64(*entity.UsagePerCustomer) {
+			return nil, domain.ErrCouponUsageLimit
+		}
+	}
+
+	return data, nil
+}
+
+// PriceOrder computes the discount of the coupon on the order lines. An unknown
+// code is reported as an invalid coupon.
+func (u *CouponUseCase) PriceOrder(ctx context.Context, tx *gorm.DB, code string, order []domain.OrderRequest) (*domain.CouponDiscount, error) {
+	entity, err := u.couponRepo.GetCouponByCode(ctx, tx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCouponInvalid
+		}
+		return nil, err
+	}
+
+	targets, err := u.couponRepo.GetTargets(ctx, tx, []int{entity.ID})
+	if err != nil {
+		return nil, err
+	}
+	setTargets(&entity, targets)
+
+	productIDs := make([]int, 0, len(order))
+	for _, v := range order {
+		productIDs = append(productIDs, v.ProductID)
+	}
+	categories, err := u.couponRepo.GetProductCategories(ctx, tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	categoryByProduct := make(map[int]int, len(categories))
+	for _, v := range categories {
+		categoryByProduct[v.ProductID] = v.CategoryID
+	}
+
+	var subtotal money.Money
+	lines := make([]domain.CouponLine, 0, len(order))
+	for _, v := range order {
+		if subtotal, err = subtotal.Add(v.Price); err != nil {
+			return nil, err
+		}
+		lines = append(lines, domain.CouponLine{
+			ProductID:  v.ProductID,
+			CategoryID: categoryByProduct[v.ProductID],
+			Amount:     v.Price,
+		})
+	}
+
+	discount, err := entity.Discount(time.Now(), lines)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := subtotal.Sub(discount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CouponDiscount{
+		CouponID:   entity.ID,
+		CouponCode: entity.Code,
+		Subtotal:   subtotal,
+		Discount:   discount,
+		Total:      total,
+	}, nil
+}
+
+// Redeem counts the use of the coupon by the order. The coupon row is locked by the
+// usage count until the transaction ends, which keeps concurrent checkouts from
+// going over the usage limits.
+func (u *CouponUseCase) Redeem(ctx context.Context, tx *gorm.DB, discount domain.CouponDiscount, customerID, orderID int) error {
+	entity, affected, err := u.couponRepo.ReserveUsage(ctx, tx, discount.CouponID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrCouponUsageLimit
+	}
+
+	if entity.UsagePerCustomer != nil {
+		count, err := u.couponRepo.CountCustomerRedemptions(ctx, tx, entity.ID, customerID)
+		if err != nil {
+			return err
+		}
+		if count >= int64(*entity.UsagePerCustomer) {
+			return domain.ErrCouponUsageLimit
+		}
+	}
+
+	return u.couponRepo.InsertRedemption(ctx, tx, domain.CouponRedemption{
+		CouponID:   entity.ID,
+		CustomerID: customerID,
+		OrderID:    orderID,
+		Discount:   discount.Discount,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// Release gives the uses of the coupons redeemed by the order back, within tx, the
+// transaction cancelling the order.
+func (u *CouponUseCase) Release(ctx context.Context, tx *gorm.DB, orderID int) error {
+	couponIDs, err := u.couponRepo.DeleteRedemptionsByOrderID(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range couponIDs {
+		if err := u.couponRepo.ReleaseUsage(ctx, tx, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setTargets(entity *domain.Coupon, targets []domain.CouponTarget) {
+	entity.ProductIDs, entity.CategoryIDs = []int{}, []int{}
+	for _, v := range targets {
+		if v.CouponID != entity.ID {
+			continue
+		}
+		if v.ProductID != nil {
+			entity.ProductIDs = append(entity.ProductIDs, *v.ProductID)
+		}
+		if v.CategoryID != nil {
+			entity.CategoryIDs = append(entity.CategoryIDs, *v.CategoryID)
+		}
+	}
+}
+
+func isCouponError(err error) bool {
+	return errors.Is(err, domain.ErrCouponInvalid) || errors.Is(err, domain.ErrCouponMinSpend) ||
+		errors.Is(err, domain.ErrCouponNotApplicable) || errors.Is(err, domain.ErrCouponUsageLimit)
+}
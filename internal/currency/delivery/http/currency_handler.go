@@ -0,0 +1,99 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/online-store/internal/currency"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/response"
+	"github.com/online-store/pkg/validator"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/beego/i18n"
+)
+
+type CurrencyHandler struct {
+	beego.Controller
+	currency.UseCase
+	i18n.Locale
+	response.APIResponseInterface
+	time.Duration
+}
+
+func NewCurrencyHandler(useCase currency.UseCase, executionTimeout time.Duration, apiResponse response.APIResponseInterface) {
+	handler := &CurrencyHandler{
+		UseCase:              useCase,
+		APIResponseInterface: apiResponse,
+		Duration:             executionTimeout,
+	}
+
+	beego.Router("/api/v1/currencies", handler, "get:GetExchangeRates")
+	beego.Router("/admin/v1/exchange-rates", handler, "get:GetExchangeRates;put:UpdateExchangeRates")
+}
+
+func (h *CurrencyHandler) Prepare() {
+	// check user access when needed
+	h.Lang = pkg.GetLangVersion(h.Ctx)
+	requestTime := time.Now().UnixNano() / int64(time.Millisecond)
+	h.Ctx.Input.SetData("request_time", requestTime)
+}
+
+func (h *CurrencyHandler) GetExchangeRates() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	res, err := h.UseCase.GetExchangeRates(h.Ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *CurrencyHandler) UpdateExchangeRates() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	var request domain.UpdateExchangeRatesRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	res, err := h.UseCase.UpdateExchangeRates(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUnsupportedCurrency) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.UnsupportedCurrencyErrorCode, domain.ErrorCodeText(domain.UnsupportedCurrencyErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.updatedSuccess"), res, nil)
+}
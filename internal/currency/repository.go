@@ -0,0 +1,14 @@
+package currency
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	GetExchangeRates(ctx context.Context) ([]domain.ExchangeRate, error)
+	GetExchangeRate(ctx context.Context, currency string) (domain.ExchangeRate, error)
+	UpsertExchangeRates(ctx context.Context, tx *gorm.DB, data []domain.ExchangeRate) error
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/currency"
+	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
+)
+
+type CurrencyRepository struct {
+	db *gorm.DB
+}
+
+func NewCurrencyRepository(db *gorm.DB) currency.Repository {
+	return &CurrencyRepository{db}
+}
+
+func (r *CurrencyRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *CurrencyRepository) GetExchangeRates(ctx context.Context) ([]domain.ExchangeRate, error) {
+	var data []domain.ExchangeRate
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Order("currency").Find(&data).Error
+	return data, err
+}
+
+func (r *CurrencyRepository) GetExchangeRate(ctx context.Context, currency string) (domain.ExchangeRate, error) {
+	var data domain.ExchangeRate
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("currency = ?", currency).First(&data).Error
+	return data, err
+}
+
+// UpsertExchangeRates replaces the rates of the currencies, adding the new ones.
+func (r *CurrencyRepository) UpsertExchangeRates(ctx context.Context, tx *gorm.DB, data []domain.ExchangeRate) error {
+	for _, v := range data {
+		err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+			Exec(`INSERT INTO exchange_rate (currency, rate, updated_at, updated_by) VALUES (?, ?, ?, ?)
+				ON CONFLICT (currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by`,
+				v.Currency, v.Rate, v.UpdatedAt, v.UpdatedBy).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package currency
+
+import (
+	"context"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+)
+
+type UseCase interface {
+	GetExchangeRates(beegoCtx *beegoContext.Context) (*domain.ExchangeRateListResponse, error)
+	UpdateExchangeRates(beegoCtx *beegoContext.Context, req domain.UpdateExchangeRatesRequest) (*domain.ExchangeRateListResponse, error)
+	GetExchangeRate(ctx context.Context, currency string) (domain.ExchangeRate, error)
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/currency"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/money"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type CurrencyUseCase struct {
+	currencyRepo currency.Repository
+	zapLogger    zaplogger.Logger
+}
+
+func NewCurrencyUseCase(currencyRepo currency.Repository, zapLogger zaplogger.Logger) currency.UseCase {
+	return &CurrencyUseCase{
+		currencyRepo: currencyRepo,
+		zapLogger:    zapLogger,
+	}
+}
+
+func (u *CurrencyUseCase) GetExchangeRates(beegoCtx *beegoContext.Context) (*domain.ExchangeRateListResponse, error) {
+	data, err := u.currencyRepo.GetExchangeRates(beegoCtx.Request.Context())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return &domain.ExchangeRateListResponse{
+		BaseCurrency: money.DefaultCurrency(),
+		Rates:        append([]domain.ExchangeRate{}, data...),
+	}, nil
+}
+
+// UpdateExchangeRates sets the rates of the display currencies, a currency is
+// available once it has a rate. The base currency has no rate.
+func (u *CurrencyUseCase) UpdateExchangeRates(beegoCtx *beegoContext.Context, req domain.UpdateExchangeRatesRequest) (*domain.ExchangeRateListResponse, error) {
+	var rates []domain.ExchangeRate
+	for _, v := range req.Rates {
+		code := strings.ToUpper(v.Currency)
+		if code == money.DefaultCurrency() {
+			return nil, domain.ErrUnsupportedCurrency
+		}
+		rates = append(rates, domain.ExchangeRate{
+			Currency:  code,
+			Rate:      v.Rate,
+			UpdatedAt: time.Now(),
+			UpdatedBy: "System",
+		})
+	}
+
+	//start transaction
+	err := u.currencyRepo.DB().Transaction(func(tx *gorm.DB) error {
+		return u.currencyRepo.UpsertExchangeRates(beegoCtx.Request.Context(), tx, rates)
+	})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return u.GetExchangeRates(beegoCtx)
+}
+
+// GetExchangeRate returns the rate prices are converted at to currency. No
+// currency is the base currency.
+func (u *CurrencyUseCase) GetExchangeRate(ctx context.Context, code string) (domain.ExchangeRate, error) {
+	code = strings.ToUpper(code)
+	if code == "" || code == money.DefaultCurrency() {
+		return domain.BaseExchangeRate(), nil
+	}
+
+	data, err := u.currencyRepo.GetExchangeRate(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ExchangeRate{}, domain.ErrUnsupportedCurrency
+		}
+		return domain.ExchangeRate{}, err
+	}
+	return data, nil
+}
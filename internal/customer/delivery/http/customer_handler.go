@@ -8,8 +8,10 @@ import (
 	"github.com/online-store/internal/customer"
 	"github.com/online-store/internal/domain"
 	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/jwtkey"
 	"github.com/online-store/pkg/response"
 	"github.com/online-store/pkg/validator"
+	"gorm.io/gorm"
 	"net/http"
 	"time"
 )
@@ -31,6 +33,9 @@ func NewCustomerHandler(useCase customer.UseCase, executionTimeout time.Duration
 
 	beego.Router("/auth/v1/customer/login", handler, "post:LoginCustomer")
 	beego.Router("/auth/v1/customer/register", handler, "post:CreateCustomer")
+	beego.Router("/.well-known/jwks.json", handler, "get:GetJWKS")
+	beego.Router("/customer/v1/account/export", handler, "get:ExportCustomerData")
+	beego.Router("/customer/v1/account", handler, "delete:DeleteCustomer")
 }
 
 func (h *CustomerHandler) Prepare() {
@@ -108,3 +113,76 @@ func (h *CustomerHandler) CreateCustomer() {
 
 	h.Ok(h.Ctx, h.Tr("message.success"), nil, nil)
 }
+
+// GetJWKS publishes the public token verification keys in the standard JWKS format.
+func (h *CustomerHandler) GetJWKS() {
+	h.Ctx.Output.Header("Cache-Control", "public, max-age=300")
+	h.Ctx.Output.JSON(jwtkey.Default.JWKS(), false, false)
+}
+
+func (h *CustomerHandler) ExportCustomerData() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	res, err := h.UseCase.ExportCustomerData(h.Ctx, h.Ctx.Input.GetData("userID").(int))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	h.Ctx.Output.Header("Content-Disposition", "attachment; filename=customer-data.json")
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *CustomerHandler) DeleteCustomer() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	var request domain.DeleteAccountRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	request.CustomerID = h.Ctx.Input.GetData("userID").(int)
+	if err := h.UseCase.DeleteCustomer(h.Ctx, request); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidCredential) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidCredentialErrorCode, domain.ErrorCodeText(domain.InvalidCredentialErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.deletedSuccess"), nil, nil)
+}
@@ -3,9 +3,20 @@ package customer
 import (
 	"context"
 	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
 )
 
 type Repository interface {
+	DB() *gorm.DB
 	InsertCustomer(ctx context.Context, request domain.Customer) error
 	GetUserByEmail(ctx context.Context, email string) (domain.Customer, error)
+	GetCustomerByID(ctx context.Context, customerID int) (domain.Customer, error)
+	GetCartByCustomerID(ctx context.Context, customerID int) ([]domain.CartProduct, error)
+	GetOrdersByCustomerID(ctx context.Context, customerID int) ([]domain.Order, error)
+	GetOrderItemsByOrderIDs(ctx context.Context, orderIDs []int) ([]domain.OrderItem, error)
+	GetPaymentsByIDs(ctx context.Context, paymentIDs []int) ([]domain.Payment, error)
+	AnonymizeCustomer(ctx context.Context, tx *gorm.DB, customerID int) error
+	DeleteCartByCustomerID(ctx context.Context, tx *gorm.DB, customerID int) error
+	RevokeApiKeysByCustomerID(ctx context.Context, tx *gorm.DB, customerID int) error
+	InsertAuditLog(ctx context.Context, tx *gorm.DB, data domain.AuditLog) error
 }
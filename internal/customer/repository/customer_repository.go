@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/online-store/internal/customer"
 	"github.com/online-store/internal/domain"
 	"gorm.io/gorm"
+	"time"
 )
 
 type CustomerRepository struct {
@@ -16,6 +18,10 @@ func NewCustomerRepository(db *gorm.DB) customer.Repository {
 	return &CustomerRepository{db: db}
 }
 
+func (r *CustomerRepository) DB() *gorm.DB {
+	return r.db
+}
+
 func (r *CustomerRepository) InsertCustomer(ctx context.Context, request domain.Customer) error {
 	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("CustomerID", "UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&request).Error
 	return err
@@ -24,6 +30,108 @@ func (r *CustomerRepository) InsertCustomer(ctx context.Context, request domain.
 func (r *CustomerRepository) GetUserByEmail(ctx context.Context, email string) (domain.Customer, error) {
 	var data domain.Customer
 
-	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("email = ?", email).First(&data)
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("email = ? AND deleted_at IS NULL", email).First(&data)
+	return data, result.Error
+}
+
+func (r *CustomerRepository) GetCustomerByID(ctx context.Context, customerID int) (domain.Customer, error) {
+	var data domain.Customer
+
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("customer_id = ? AND deleted_at IS NULL", customerID).First(&data)
 	return data, result.Error
 }
+
+func (r *CustomerRepository) GetCartByCustomerID(ctx context.Context, customerID int) ([]domain.CartProduct, error) {
+	var data []domain.CartProduct
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT 
+					c.cart_id ,
+					p.name as product_name,
+					p.description as product_description,
+					c.variant_id,
+					v.sku,
+					ca."name" as category_name,
+					COALESCE(v.price, p.price) as product_price,
+					c.quantity as quantity,
+					c.created_at
+					from cart c 
+					join product p ON p.id = c.product_id 
+					join category ca on ca.id = p.category_id 
+					left join product_variant v on v.id = c.variant_id 
+				WHERE c.deleted_at IS NULL AND c.customer_id = ?
+				ORDER BY c.created_at DESC`, customerID).Scan(&data).Error
+	return data, err
+}
+
+func (r *CustomerRepository) GetOrdersByCustomerID(ctx context.Context, customerID int) ([]domain.Order, error) {
+	var data []domain.Order
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("customer_id = ? AND deleted_at IS NULL", customerID).Order("created_at DESC").Find(&data).Error
+	for i := range data {
+		data[i].SetChargedCurrency()
+	}
+	return data, err
+}
+
+func (r *CustomerRepository) GetOrderItemsByOrderIDs(ctx context.Context, orderIDs []int) ([]domain.OrderItem, error) {
+	var data []domain.OrderItem
+	if len(orderIDs) == 0 {
+		return data, nil
+	}
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("order_id IN ? AND deleted_at IS NULL", orderIDs).Find(&data).Error
+	return data, err
+}
+
+func (r *CustomerRepository) GetPaymentsByIDs(ctx context.Context, paymentIDs []int) ([]domain.Payment, error) {
+	var data []domain.Payment
+	if len(paymentIDs) == 0 {
+		return data, nil
+	}
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id IN ? AND deleted_at IS NULL", paymentIDs).Find(&data).Error
+	for i := range data {
+		data[i].SetChargedCurrency()
+	}
+	return data, err
+}
+
+// AnonymizeCustomer replaces the customer PII with placeholders and marks the row deleted.
+// Orders and payments keep pointing at the row so accounting stays intact.
+func (r *CustomerRepository) AnonymizeCustomer(ctx context.Context, tx *gorm.DB, customerID int) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("customer").Where("customer_id = ? AND deleted_at IS NULL", customerID).
+		Updates(map[string]interface{}{
+			"first_name":   "Deleted",
+			"last_name":    "Customer",
+			"email":        fmt.Sprintf("deleted-%d@anonymized.invalid", customerID),
+			"password":     "",
+			"address":      "",
+			"phone_number": "",
+			"deleted_at":   time.Now(),
+			"deleted_by":   "Customer",
+		}).Error
+}
+
+func (r *CustomerRepository) DeleteCartByCustomerID(ctx context.Context, tx *gorm.DB, customerID int) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("cart").Where("customer_id = ? AND deleted_at IS NULL", customerID).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": "System",
+		}).Error
+}
+
+func (r *CustomerRepository) RevokeApiKeysByCustomerID(ctx context.Context, tx *gorm.DB, customerID int) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("api_key").Where("customer_id = ? AND revoked_at IS NULL AND deleted_at IS NULL", customerID).
+		Updates(map[string]interface{}{
+			"revoked_at": time.Now(),
+			"updated_at": time.Now(),
+			"updated_by": "System",
+		}).Error
+}
+
+func (r *CustomerRepository) InsertAuditLog(ctx context.Context, tx *gorm.DB, data domain.AuditLog) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("ID").Create(&data).Error
+}
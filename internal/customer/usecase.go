@@ -8,4 +8,6 @@ import (
 type UseCase interface {
 	InsertCustomer(beegoCtx *beegoContext.Context, req domain.InsertCustomerRequest) error
 	LoginCustomer(beegoCtx *beegoContext.Context, req domain.LoginRequest) (*domain.Customer, error)
+	ExportCustomerData(beegoCtx *beegoContext.Context, customerID int) (*domain.CustomerDataExport, error)
+	DeleteCustomer(beegoCtx *beegoContext.Context, req domain.DeleteAccountRequest) error
 }
@@ -2,24 +2,30 @@ package usecase
 
 import (
 	"errors"
+	"fmt"
 	beegoContext "github.com/beego/beego/v2/server/web/context"
 	"github.com/jackc/pgconn"
 	"github.com/online-store/internal/customer"
 	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/cache"
 	"github.com/online-store/pkg/zaplogger"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 	"time"
 )
 
 type CustomerUseCase struct {
 	customerRepo customer.Repository
 	zapLogger    zaplogger.Logger
+	cacheRepo    cache.RedisRepository
 }
 
-func NewCustomerUseCase(customerRepo customer.Repository, zapLogger zaplogger.Logger) customer.UseCase {
+func NewCustomerUseCase(customerRepo customer.Repository, zapLogger zaplogger.Logger, cacheRepo cache.RedisRepository) customer.UseCase {
 	return &CustomerUseCase{
 		customerRepo: customerRepo,
 		zapLogger:    zapLogger,
+		cacheRepo:    cacheRepo,
 	}
 }
 
@@ -73,3 +79,156 @@ func (u *CustomerUseCase) LoginCustomer(beegoCtx *beegoContext.Context, req doma
 	}
 	return &user, nil
 }
+
+func (u *CustomerUseCase) ExportCustomerData(beegoCtx *beegoContext.Context, customerID int) (*domain.CustomerDataExport, error) {
+	ctx := beegoCtx.Request.Context()
+
+	user, err := u.customerRepo.GetCustomerByID(ctx, customerID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	cartItems, err := u.customerRepo.GetCartByCustomerID(ctx, customerID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	orders, err := u.customerRepo.GetOrdersByCustomerID(ctx, customerID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	var orderIDs, paymentIDs []int
+	for _, v := range orders {
+		orderIDs = append(orderIDs, v.ID)
+		if v.PaymentID != nil {
+			paymentIDs = append(paymentIDs, *v.PaymentID)
+		}
+	}
+
+	orderItems, err := u.customerRepo.GetOrderItemsByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	payments, err := u.customerRepo.GetPaymentsByIDs(ctx, paymentIDs)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	itemsByOrder := make(map[int][]domain.OrderItem)
+	for _, v := range orderItems {
+		itemsByOrder[v.OrderID] = append(itemsByOrder[v.OrderID], v)
+	}
+	paymentByID := make(map[int]domain.Payment)
+	for _, v := range payments {
+		paymentByID[v.ID] = v
+	}
+
+	result := &domain.CustomerDataExport{
+		Profile: domain.CustomerProfile{
+			CustomerID:  user.CustomerID,
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			Email:       user.Email,
+			Address:     user.Address,
+			PhoneNumber: user.PhoneNumber,
+			CreatedAt:   user.CreatedAt,
+			UpdatedAt:   user.UpdatedAt,
+		},
+		Cart:       cartItems,
+		Orders:     []domain.CustomerOrderExport{},
+		ExportedAt: time.Now(),
+	}
+	for _, v := range orders {
+		orderExport := domain.CustomerOrderExport{
+			Order: v,
+			Items: itemsByOrder[v.ID],
+		}
+		if v.PaymentID != nil {
+			if payment, ok := paymentByID[*v.PaymentID]; ok {
+				orderExport.Payment = &payment
+			}
+		}
+		result.Orders = append(result.Orders, orderExport)
+	}
+
+	err = u.customerRepo.InsertAuditLog(ctx, u.customerRepo.DB(), domain.AuditLog{
+		CustomerID:  customerID,
+		Action:      domain.AuditActionCustomerDataExport,
+		Description: "customer requested a copy of their personal data",
+		CreatedAt:   time.Now(),
+		CreatedBy:   "Customer",
+	})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (u *CustomerUseCase) DeleteCustomer(beegoCtx *beegoContext.Context, req domain.DeleteAccountRequest) error {
+	ctx := beegoCtx.Request.Context()
+
+	user, err := u.customerRepo.GetCustomerByID(ctx, req.CustomerID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(domain.ErrInvalidCredential))
+		return domain.ErrInvalidCredential
+	}
+
+	//start transaction
+	errs := u.customerRepo.DB().Transaction(func(tx *gorm.DB) error {
+		if err := u.customerRepo.AnonymizeCustomer(ctx, tx, req.CustomerID); err != nil {
+			return err
+		}
+
+		if err := u.customerRepo.DeleteCartByCustomerID(ctx, tx, req.CustomerID); err != nil {
+			return err
+		}
+
+		if err := u.customerRepo.RevokeApiKeysByCustomerID(ctx, tx, req.CustomerID); err != nil {
+			return err
+		}
+
+		err := u.customerRepo.InsertAuditLog(ctx, tx, domain.AuditLog{
+			CustomerID:  req.CustomerID,
+			Action:      domain.AuditActionCustomerDeleted,
+			Description: "customer account deleted and personal data anonymized",
+			CreatedAt:   time.Now(),
+			CreatedBy:   "Customer",
+		})
+		if err != nil {
+			return err
+		}
+
+		//revoke every token issued to the customer until the longest lived one expires,
+		//the deletion is rolled back when the revocation cannot be stored
+		return u.cacheRepo.Save(ctx, fmt.Sprintf("%s:%d", domain.RevokedTokenKeyCache, req.CustomerID), time.Now().Unix(), pkg.TokenExpiration)
+	})
+	if errs != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		return errs
+	}
+
+	//delete existing cache
+	err = u.cacheRepo.Deletes(ctx, []string{
+		domain.CartCustomerKeyCache(req.CustomerID),
+	})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+	}
+
+	return nil
+}
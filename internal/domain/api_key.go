@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ApiKeyHeader = "X-API-Key"
+	ApiKeyPrefix = "osk_"
+
+	ScopeCatalogRead = "catalog:read"
+	ScopeOrderWrite  = "order:write"
+)
+
+type (
+	ApiKey struct {
+		ID         int        `gorm:"column:id" json:"id"`
+		Name       string     `gorm:"column:name" json:"name"`
+		KeyPrefix  string     `gorm:"column:key_prefix" json:"key_prefix"`
+		KeyHash    string     `gorm:"column:key_hash" json:"-"`
+		Scopes     string     `gorm:"column:scopes" json:"scopes"`
+		CustomerID int        `gorm:"column:customer_id" json:"customer_id"`
+		ExpiredAt  *time.Time `gorm:"column:expired_at" json:"expired_at"`
+		RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	CreateApiKeyRequest struct {
+		Name       string     `json:"name" validate:"required"`
+		Scopes     []string   `json:"scopes" validate:"required,dive,oneof=catalog:read order:write"`
+		CustomerID int        `json:"customer_id" validate:"required,number"`
+		ExpiredAt  *time.Time `json:"expired_at"`
+	}
+
+	CreateApiKeyResponse struct {
+		ApiKey
+		Key string `json:"key"`
+	}
+)
+
+func (ApiKey) TableName() string {
+	return "api_key"
+}
+
+// HasScope reports whether the key was issued with the given scope.
+func (a ApiKey) HasScope(scope string) bool {
+	for _, v := range strings.Split(a.Scopes, ",") {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
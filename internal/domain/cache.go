@@ -1,10 +1,25 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 const (
 	HalfCacheExpiration = 12 * time.Hour
 
 	ProductKeyCache = "product"
 	CartKeyCache    = "cart"
+
+	// ProductListKeyCache prefixes the cached pages of the product list, the import
+	// jobs share the product prefix and are not part of it.
+	ProductListKeyCache = ProductKeyCache + ":ALL|"
+
+	RevokedTokenKeyCache     = "revoked_token"
+	ProductImportJobKeyCache = "product_import_job"
 )
+
+// CartCustomerKeyCache prefixes the cached pages of the cart of a customer.
+func CartCustomerKeyCache(customerID int) string {
+	return fmt.Sprintf("%s:%d|", CartKeyCache, customerID)
+}
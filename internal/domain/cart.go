@@ -1,12 +1,17 @@
 package domain
 
-import "time"
+import (
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+	"time"
+)
 
 type (
 	Cart struct {
-		ProductID  int `gorm:"column:product_id" json:"product_id"`
-		Quantity   int `gorm:"column:quantity" json:"quantity"`
-		CustomerID int `gorm:"column:customer_id" json:"customer_id"`
+		ProductID  int  `gorm:"column:product_id" json:"product_id"`
+		VariantID  *int `gorm:"column:variant_id" json:"variant_id"`
+		Quantity   int  `gorm:"column:quantity" json:"quantity"`
+		CustomerID int  `gorm:"column:customer_id" json:"customer_id"`
 
 		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
 		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
@@ -22,23 +27,39 @@ type (
 	}
 
 	CartItem struct {
-		ProductID int `json:"product_id" validate:"required,number"`
-		Quantity  int `json:"quantity" validate:"required,number"`
+		ProductID int  `json:"product_id" validate:"required,number"`
+		VariantID *int `json:"variant_id" validate:"omitempty,number"`
+		Quantity  int  `json:"quantity" validate:"required,min=1,max=1000"`
 	}
 
 	GetListCartRequest struct {
-		Page       int `json:"-"`
-		Limit      int `json:"-"`
-		CustomerID int `json:"customer_id"`
+		Page       int    `json:"-"`
+		Limit      int    `json:"-"`
+		CursorMode bool   `json:"-"`
+		Cursor     string `json:"-"`
+		CustomerID int    `json:"customer_id"`
+		Currency   string `json:"-"`
 	}
 
 	CartProduct struct {
-		CartID             int     `gorm:"column:cart_id" json:"cart_id"`
-		ProductName        string  `gorm:"column:product_name" json:"product_name"`
-		ProductDescription string  `gorm:"column:product_description" json:"product_description"`
-		CategoryName       string  `gorm:"column:category_name" json:"category_name"`
-		ProductPrice       float64 `gorm:"column:product_price" json:"product_price"`
-		Quantity           int     `gorm:"column:quantity" json:"quantity"`
+		CartID             int         `gorm:"column:cart_id" json:"cart_id"`
+		ProductID          int         `gorm:"column:product_id" json:"product_id"`
+		ProductName        string      `gorm:"column:product_name" json:"product_name"`
+		ProductDescription string      `gorm:"column:product_description" json:"product_description"`
+		VariantID          *int        `gorm:"column:variant_id" json:"variant_id"`
+		SKU                *string     `gorm:"column:sku" json:"sku"`
+		CategoryID         int         `gorm:"column:category_id" json:"category_id"`
+		CategoryName       string      `gorm:"column:category_name" json:"category_name"`
+		ProductPrice       money.Money `gorm:"column:product_price" json:"product_price"`
+		Quantity           int         `gorm:"column:quantity" json:"quantity"`
+		CreatedAt          time.Time   `gorm:"column:created_at" json:"created_at"`
+	}
+
+	// CartList is a page of the cart with the products recommended for the whole
+	// cart.
+	CartList struct {
+		*database.Paginator
+		Recommendations []RelatedProduct `json:"recommendations"`
 	}
 )
 
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+const (
+	CouponDiscountPercentage = "percentage"
+	CouponDiscountFixed      = "fixed"
+)
+
+type (
+	// Coupon takes DiscountValue percent off, or DiscountAmount off when its
+	// DiscountType is fixed.
+	Coupon struct {
+		ID               int          `gorm:"column:id" json:"id"`
+		Code             string       `gorm:"column:code" json:"code"`
+		Description      string       `gorm:"column:description" json:"description"`
+		DiscountType     string       `gorm:"column:discount_type" json:"discount_type"`
+		DiscountValue    *float64     `gorm:"column:discount_value" json:"discount_value"`
+		DiscountAmount   *money.Money `gorm:"column:discount_amount" json:"discount_amount"`
+		MaxDiscount      *money.Money `gorm:"column:max_discount" json:"max_discount"`
+		MinSpend         money.Money  `gorm:"column:min_spend" json:"min_spend"`
+		UsageLimit       *int         `gorm:"column:usage_limit" json:"usage_limit"`
+		UsagePerCustomer *int         `gorm:"column:usage_per_customer" json:"usage_per_customer"`
+		UsedCount        int          `gorm:"column:used_count" json:"used_count"`
+		StartsAt         *time.Time   `gorm:"column:starts_at" json:"starts_at"`
+		EndsAt           *time.Time   `gorm:"column:ends_at" json:"ends_at"`
+		IsActive         bool         `gorm:"column:is_active" json:"is_active"`
+
+		ProductIDs  []int `gorm:"-" json:"product_ids"`
+		CategoryIDs []int `gorm:"-" json:"category_ids"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	// CouponTarget restricts a coupon to a product or to the products of a category.
+	CouponTarget struct {
+		CouponID   int  `gorm:"column:coupon_id"`
+		ProductID  *int `gorm:"column:product_id"`
+		CategoryID *int `gorm:"column:category_id"`
+	}
+
+	CouponRedemption struct {
+		ID         int         `gorm:"column:id" json:"id"`
+		CouponID   int         `gorm:"column:coupon_id" json:"coupon_id"`
+		CustomerID int         `gorm:"column:customer_id" json:"customer_id"`
+		OrderID    int         `gorm:"column:order_id" json:"order_id"`
+		Discount   money.Money `gorm:"column:discount" json:"discount"`
+
+		CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	}
+
+	CreateCouponRequest struct {
+		Code             string       `json:"code" validate:"required,alphanum,max=30"`
+		Description      string       `json:"description" validate:"max=255"`
+		DiscountType     string       `json:"discount_type" validate:"required,oneof=percentage fixed"`
+		DiscountValue    *float64     `json:"discount_value" validate:"required_if=DiscountType percentage,omitempty,gt=0"`
+		DiscountAmount   *money.Money `json:"discount_amount" validate:"required_if=DiscountType fixed,omitempty,gt=0"`
+		MaxDiscount      *money.Money `json:"max_discount" validate:"omitempty,gt=0"`
+		MinSpend         money.Money  `json:"min_spend" validate:"min=0"`
+		UsageLimit       *int         `json:"usage_limit" validate:"omitempty,min=1"`
+		UsagePerCustomer *int         `json:"usage_per_customer" validate:"omitempty,min=1"`
+		StartsAt         *time.Time   `json:"starts_at"`
+		EndsAt           *time.Time   `json:"ends_at"`
+		ProductIDs       []int        `json:"product_ids" validate:"dive,min=1"`
+		CategoryIDs      []int        `json:"category_ids" validate:"dive,min=1"`
+	}
+
+	UpdateCouponRequest struct {
+		ID               int          `json:"-"`
+		Description      *string      `json:"description" validate:"omitempty,max=255"`
+		MinSpend         *money.Money `json:"min_spend" validate:"omitempty,min=0"`
+		UsageLimit       *int         `json:"usage_limit" validate:"omitempty,min=1"`
+		UsagePerCustomer *int         `json:"usage_per_customer" validate:"omitempty,min=1"`
+		StartsAt         *time.Time   `json:"starts_at"`
+		EndsAt           *time.Time   `json:"ends_at"`
+		IsActive         *bool        `json:"is_active"`
+	}
+
+	GetCouponListRequest struct {
+		Page  int `json:"-"`
+		Limit int `json:"-"`
+	}
+
+	ValidateCouponRequest struct {
+		CouponCode string         `json:"coupon_code" validate:"required,max=30"`
+		Order      []OrderRequest `json:"order" validate:"required,dive"`
+		CustomerID int            `json:"-"`
+	}
+
+	// CouponLine is an order line as coupons see it.
+	CouponLine struct {
+		ProductID  int         `gorm:"column:product_id"`
+		CategoryID int         `gorm:"column:category_id"`
+		Amount     money.Money `gorm:"-"`
+	}
+
+	CouponDiscount struct {
+		CouponID   int         `json:"coupon_id"`
+		CouponCode string      `json:"coupon_code"`
+		Subtotal   money.Money `json:"subtotal"`
+		Discount   money.Money `json:"discount"`
+		Total      money.Money `json:"total"`
+	}
+)
+
+func (Coupon) TableName() string {
+	return "coupon"
+}
+
+func (CouponTarget) TableName() string {
+	return "coupon_target"
+}
+
+func (CouponRedemption) TableName() string {
+	return "coupon_redemption"
+}
+
+// Targets reports whether the coupon applies to the line, coupons without targets
+// apply to every line.
+func (c Coupon) Targets(line CouponLine) bool {
+	if len(c.ProductIDs) == 0 && len(c.CategoryIDs) == 0 {
+		return true
+	}
+	for _, v := range c.ProductIDs {
+		if v == line.ProductID {
+			return true
+		}
+	}
+	for _, v := range c.CategoryIDs {
+		if v == line.CategoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount checks the coupon can be used at now on the lines and computes its
+// discount. Only the lines it targets are discounted, the minimum spend applies to
+// the whole order. Usage limits are checked when the coupon is redeemed.
+func (c Coupon) Discount(now time.Time, lines []CouponLine) (money.Money, error) {
+	if !c.IsActive || (c.StartsAt != nil && now.Before(*c.StartsAt)) || (c.EndsAt != nil && !now.Before(*c.EndsAt)) {
+		return money.Money{}, ErrCouponInvalid
+	}
+
+	var (
+		subtotal, eligible money.Money
+		err                error
+	)
+	for _, v := range lines {
+		if subtotal, err = subtotal.Add(v.Amount); err != nil {
+			return money.Money{}, err
+		}
+		if c.Targets(v) {
+			if eligible, err = eligible.Add(v.Amount); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+
+	if cmp, err := subtotal.Cmp(c.MinSpend); err != nil {
+		return money.Money{}, err
+	} else if cmp < 0 {
+		return money.Money{}, ErrCouponMinSpend
+	}
+	if eligible.Amount <= 0 {
+		return money.Money{}, ErrCouponNotApplicable
+	}
+
+	var discount money.Money
+	switch {
+	case !ValidDiscount(c.DiscountType, c.DiscountValue, c.DiscountAmount):
+		return money.Money{}, ErrCouponInvalid
+	case c.DiscountType == CouponDiscountFixed:
+		discount = *c.DiscountAmount
+	default:
+		if discount, err = eligible.Percent(*c.DiscountValue); err != nil {
+			return money.Money{}, err
+		}
+		if c.MaxDiscount != nil {
+			if discount, err = money.Min(discount, *c.MaxDiscount); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+
+	return money.Min(discount, eligible)
+}
+
+// ValidDiscount reports whether the discount is given the way of its type: a
+// percentage as percent, a fixed discount as amount, never both.
+func ValidDiscount(discountType string, percent *float64, amount *money.Money) bool {
+	if discountType == CouponDiscountFixed {
+		return amount != nil && percent == nil
+	}
+	return percent != nil && amount == nil
+}
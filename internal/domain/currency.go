@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+type (
+	// ExchangeRate is how much of Currency one major unit of the base currency, the
+	// store currency, is worth.
+	ExchangeRate struct {
+		Currency string  `gorm:"column:currency;primaryKey" json:"currency"`
+		Rate     float64 `gorm:"column:rate" json:"rate"`
+
+		UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy string    `gorm:"column:updated_by" json:"updated_by"`
+	}
+
+	ExchangeRateRequest struct {
+		Currency string  `json:"currency" validate:"required,len=3,alpha"`
+		Rate     float64 `json:"rate" validate:"required,gt=0"`
+	}
+
+	UpdateExchangeRatesRequest struct {
+		Rates []ExchangeRateRequest `json:"rates" validate:"required,min=1,dive"`
+	}
+
+	ExchangeRateListResponse struct {
+		BaseCurrency string         `json:"base_currency"`
+		Rates        []ExchangeRate `json:"rates"`
+	}
+)
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rate"
+}
+
+// BaseExchangeRate is the rate of the base currency to itself.
+func BaseExchangeRate() ExchangeRate {
+	return ExchangeRate{Currency: money.DefaultCurrency(), Rate: 1}
+}
+
+// Convert converts an amount of the base currency to the currency of the rate.
+func (r ExchangeRate) Convert(m money.Money) (money.Money, error) {
+	return m.Convert(r.Currency, r.Rate)
+}
+
+// ConvertPtr converts an optional amount of the base currency.
+func (r ExchangeRate) ConvertPtr(m *money.Money) (*money.Money, error) {
+	if m == nil {
+		return nil, nil
+	}
+	converted, err := r.Convert(*m)
+	if err != nil {
+		return nil, err
+	}
+	return &converted, nil
+}
+
+// ToBase converts an amount of the currency of the rate back to the base currency.
+func (r ExchangeRate) ToBase(m money.Money) (money.Money, error) {
+	return m.ConvertInverse(money.DefaultCurrency(), r.Rate)
+}
@@ -33,8 +33,56 @@ type (
 		Email    string `json:"email" validate:"required,email_address"`
 		Password string `json:"password" validate:"required"`
 	}
+
+	DeleteAccountRequest struct {
+		Password   string `json:"password" validate:"required"`
+		CustomerID int    `json:"-"`
+	}
+
+	CustomerProfile struct {
+		CustomerID  int        `json:"customer_id"`
+		FirstName   string     `json:"first_name"`
+		LastName    string     `json:"last_name"`
+		Email       string     `json:"email"`
+		Address     string     `json:"address"`
+		PhoneNumber string     `json:"phone_number"`
+		CreatedAt   time.Time  `json:"created_at"`
+		UpdatedAt   *time.Time `json:"updated_at"`
+	}
+
+	CustomerOrderExport struct {
+		Order
+		Items   []OrderItem `json:"items"`
+		Payment *Payment    `json:"payment"`
+	}
+
+	CustomerDataExport struct {
+		Profile    CustomerProfile       `json:"profile"`
+		Cart       []CartProduct         `json:"cart"`
+		Orders     []CustomerOrderExport `json:"orders"`
+		ExportedAt time.Time             `json:"exported_at"`
+	}
+
+	AuditLog struct {
+		ID          int    `gorm:"column:id" json:"id"`
+		CustomerID  int    `gorm:"column:customer_id" json:"customer_id"`
+		Action      string `gorm:"column:action" json:"action"`
+		Description string `gorm:"column:description" json:"description"`
+
+		CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string    `gorm:"column:created_by" json:"created_by"`
+	}
+)
+
+const (
+	AuditActionCustomerDataExport = "customer.data_export"
+	AuditActionCustomerDeleted    = "customer.deleted"
 )
 
 func (Customer) TableName() string {
 	return "customer"
 }
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
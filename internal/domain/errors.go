@@ -22,6 +22,31 @@ const (
 	DataAlreadyExist              = "STR-API-010"
 	DataAlreadyExistByCondition   = "STR-API-011"
 	ForeignKeyConstraintErrorCode = "STR-API-012"
+	InsufficientStockErrorCode    = "STR-API-013"
+	UnsupportedMediaTypeErrorCode = "STR-API-014"
+	FileTooLargeErrorCode         = "STR-API-015"
+	InvalidImportFileErrorCode    = "STR-API-016"
+	ProductInStockErrorCode       = "STR-API-017"
+	CouponInvalidErrorCode        = "STR-API-018"
+	CouponMinSpendErrorCode       = "STR-API-019"
+	CouponNotApplicableErrorCode  = "STR-API-020"
+	CouponUsageLimitErrorCode     = "STR-API-021"
+	UnsupportedCurrencyErrorCode  = "STR-API-022"
+	ShippingUnavailableErrorCode  = "STR-API-023"
+	OrderNotShippableErrorCode    = "STR-API-024"
+	ShipmentQuantityErrorCode     = "STR-API-025"
+	ShipmentStatusErrorCode       = "STR-API-026"
+	ReturnQuantityErrorCode       = "STR-API-027"
+	ReturnStatusErrorCode         = "STR-API-028"
+	ReturnInspectionErrorCode     = "STR-API-029"
+	OrderNotPaidErrorCode         = "STR-API-030"
+	ReviewNotAllowedErrorCode     = "STR-API-031"
+	OrderNotPayableErrorCode      = "STR-API-032"
+	PaymentAmountErrorCode        = "STR-API-033"
+	OrderNotCancellableErrorCode  = "STR-API-034"
+	PriceChangedErrorCode         = "STR-API-035"
+	InvalidDiscountValueErrorCode = "STR-API-036"
+	VariantRequiredErrorCode      = "STR-API-037"
 
 	PgCodeUniqueConstraint     = "23505"
 	PgCodeForeignKeyConstraint = "23503"
@@ -29,12 +54,54 @@ const (
 
 	MissingTokenErrorCode = "STR-AUTH-001"
 	InvalidTokenErrorCode = "STR-AUTH-002"
+
+	MissingApiKeyErrorCode       = "STR-AUTH-003"
+	InvalidApiKeyErrorCode       = "STR-AUTH-004"
+	ApiKeyNotRegisteredErrorCode = "STR-AUTH-005"
 )
 
 var (
 	ErrInvalidUrlQueryParam = errors.New("query param is invalid")
 	ErrForeignKeyConstraint = errors.New("foreign key constraint")
 	ErrUniqueConstraint     = errors.New("unique_constraint")
+
+	ErrInvalidCredential = errors.New("invalid credentials")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrUnsupportedMedia  = errors.New("unsupported media type")
+	ErrFileTooLarge      = errors.New("file too large")
+	ErrInvalidImportFile = errors.New("invalid import file")
+	ErrProductInStock    = errors.New("product is in stock")
+
+	ErrCouponInvalid        = errors.New("coupon is invalid or expired")
+	ErrCouponMinSpend       = errors.New("coupon minimum spend not reached")
+	ErrCouponNotApplicable  = errors.New("coupon does not apply to the order")
+	ErrCouponUsageLimit     = errors.New("coupon usage limit reached")
+	ErrInvalidDiscountValue = errors.New("discount is not given the way of its type")
+
+	ErrUnsupportedCurrency = errors.New("currency is not supported")
+	ErrShippingUnavailable = errors.New("shipping method is not available for the address")
+
+	ErrOrderNotShippable = errors.New("order is not paid")
+	ErrShipmentQuantity  = errors.New("shipment quantity exceeds the quantity left to ship")
+	ErrShipmentStatus    = errors.New("shipment status can't change to the given status")
+
+	ErrReturnQuantity   = errors.New("return quantity exceeds the delivered quantity left to return")
+	ErrReturnStatus     = errors.New("return status can't change to the given status")
+	ErrReturnInspection = errors.New("every returned item must be inspected once")
+
+	ErrOrderNotPaid = errors.New("order is not paid, it has no invoice")
+
+	ErrReviewNotAllowed = errors.New("product is not in a delivered order of the customer")
+
+	ErrOrderNotPayable     = errors.New("order is not waiting for payment")
+	ErrPaymentAmount       = errors.New("payment amount does not match the order total")
+	ErrOrderNotCancellable = errors.New("order can no longer be cancelled")
+	ErrPriceChanged        = errors.New("prices changed during checkout")
+	ErrVariantRequired     = errors.New("product has variants, choose one")
+
+	ErrApiKeyNotRegistered = errors.New("api key is not registered")
+	ErrApiKeyInvalid       = errors.New("api key is expired or revoked")
+	ErrApiKeyForbidden     = errors.New("api key scope is not permitted")
 )
 
 func ErrorCodeText(code, locale string, args ...interface{}) string {
@@ -51,6 +118,72 @@ func ErrorCodeText(code, locale string, args ...interface{}) string {
 		return i18n.Tr(locale, "message.errorInvalidToken", args)
 	case MissingTokenErrorCode:
 		return i18n.Tr(locale, "message.errorMissingToken", args)
+	case MissingApiKeyErrorCode:
+		return i18n.Tr(locale, "message.errorMissingApiKey", args)
+	case InvalidApiKeyErrorCode:
+		return i18n.Tr(locale, "message.errorInvalidApiKey", args)
+	case ApiKeyNotRegisteredErrorCode:
+		return i18n.Tr(locale, "message.errorApiKeyNotRegistered", args)
+	case RequestForbiddenErrorCode:
+		return i18n.Tr(locale, "message.errorRequestForbidden", args)
+	case InsufficientStockErrorCode:
+		return i18n.Tr(locale, "message.errorInsufficientStock", args)
+	case UnsupportedMediaTypeErrorCode:
+		return i18n.Tr(locale, "message.errorUnsupportedMediaType", args)
+	case FileTooLargeErrorCode:
+		return i18n.Tr(locale, "message.errorFileTooLarge", args)
+	case InvalidImportFileErrorCode:
+		return i18n.Tr(locale, "message.errorInvalidImportFile", args)
+	case ProductInStockErrorCode:
+		return i18n.Tr(locale, "message.errorProductInStock", args)
+	case CouponInvalidErrorCode:
+		return i18n.Tr(locale, "message.errorCouponInvalid", args)
+	case CouponMinSpendErrorCode:
+		return i18n.Tr(locale, "message.errorCouponMinSpend", args)
+	case CouponNotApplicableErrorCode:
+		return i18n.Tr(locale, "message.errorCouponNotApplicable", args)
+	case CouponUsageLimitErrorCode:
+		return i18n.Tr(locale, "message.errorCouponUsageLimit", args)
+	case UnsupportedCurrencyErrorCode:
+		return i18n.Tr(locale, "message.errorUnsupportedCurrency", args)
+	case ShippingUnavailableErrorCode:
+		return i18n.Tr(locale, "message.errorShippingUnavailable", args)
+	case OrderNotShippableErrorCode:
+		return i18n.Tr(locale, "message.errorOrderNotShippable", args)
+	case ShipmentQuantityErrorCode:
+		return i18n.Tr(locale, "message.errorShipmentQuantity", args)
+	case ShipmentStatusErrorCode:
+		return i18n.Tr(locale, "message.errorShipmentStatus", args)
+	case ReturnQuantityErrorCode:
+		return i18n.Tr(locale, "message.errorReturnQuantity", args)
+	case ReturnStatusErrorCode:
+		return i18n.Tr(locale, "message.errorReturnStatus", args)
+	case ReturnInspectionErrorCode:
+		return i18n.Tr(locale, "message.errorReturnInspection", args)
+	case OrderNotPaidErrorCode:
+		return i18n.Tr(locale, "message.errorOrderNotPaid", args)
+	case ReviewNotAllowedErrorCode:
+		return i18n.Tr(locale, "message.errorReviewNotAllowed", args)
+	case OrderNotPayableErrorCode:
+		return i18n.Tr(locale, "message.errorOrderNotPayable", args)
+	case PaymentAmountErrorCode:
+		return i18n.Tr(locale, "message.errorPaymentAmount", args)
+	case OrderNotCancellableErrorCode:
+		return i18n.Tr(locale, "message.errorOrderNotCancellable", args)
+	case PriceChangedErrorCode:
+		return i18n.Tr(locale, "message.errorPriceChanged", args)
+	case InvalidDiscountValueErrorCode:
+		return i18n.Tr(locale, "message.errorInvalidDiscountValue", args)
+	case VariantRequiredErrorCode:
+		return i18n.Tr(locale, "message.errorVariantRequired", args)
+	case InvalidUrlParamErrorCode:
+		return i18n.Tr(locale, "message.errorInvalidUrlParamErrorCode", args)
+	case InvalidUrlQueryParamErrorCode:
+		return i18n.Tr(locale, "message.errorInvalidUrlQueryParamErrorCode", args)
+	case InvalidCredentialErrorCode:
+		return i18n.Tr(locale, "message.errorInvalidCredential", args)
+	case DataNotFoundErrorCode:
+		return i18n.Tr(locale, "message.errorDataNotFound", args)
 	case ForeignKeyConstraintErrorCode:
 		msg := i18n.Tr(locale, "message.errorForeignKeyConstraint", nil)
 		if len(args) > 0 {
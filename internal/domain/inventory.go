@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+	"github.com/online-store/pkg/database"
+	"time"
+)
+
+// Reasons of the inventory movements. Quantity moves the stock on hand and
+// ReservedQuantity the stock held for unpaid orders.
+const (
+	InventoryReasonOpeningBalance      = "opening_balance"
+	InventoryReasonCheckoutReservation = "checkout_reservation"
+	InventoryReasonCancellationRelease = "cancellation_release"
+	InventoryReasonSale                = "sale"
+	InventoryReasonRefundRestock       = "refund_restock"
+	InventoryReasonManualAdjustment    = "manual_adjustment"
+	InventoryReasonImport              = "import"
+
+	InventoryActorAdmin    = "admin"
+	InventoryActorCustomer = "customer"
+	InventoryActorSystem   = "System"
+)
+
+type (
+	InventoryMovement struct {
+		ID               int     `gorm:"column:id" json:"id"`
+		ProductID        int     `gorm:"column:product_id" json:"product_id"`
+		VariantID        *int    `gorm:"column:variant_id" json:"variant_id"`
+		WarehouseID      *int    `gorm:"column:warehouse_id" json:"warehouse_id"`
+		Quantity         int     `gorm:"column:quantity" json:"quantity"`
+		ReservedQuantity int     `gorm:"column:reserved_quantity" json:"reserved_quantity"`
+		Reason           string  `gorm:"column:reason" json:"reason"`
+		Reference        *string `gorm:"column:reference" json:"reference"`
+		Note             *string `gorm:"column:note" json:"note"`
+		Actor            string  `gorm:"column:actor" json:"actor"`
+
+		CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	}
+
+	InventoryAdjustmentRequest struct {
+		WarehouseID int    `json:"warehouse_id" validate:"required,number"`
+		ProductID   int    `json:"product_id" validate:"required,number"`
+		VariantID   *int   `json:"variant_id" validate:"omitempty,number"`
+		Quantity    int    `json:"quantity" validate:"required,number"`
+		Note        string `json:"note" validate:"required,max=255"`
+	}
+
+	GetInventoryMovementRequest struct {
+		Page        int        `json:"-"`
+		Limit       int        `json:"-"`
+		ProductID   int        `json:"-"`
+		VariantID   *int       `json:"variant_id"`
+		WarehouseID *int       `json:"warehouse_id"`
+		From        *time.Time `json:"from"`
+		To          *time.Time `json:"to"`
+	}
+
+	// InventoryMovementSummary sums the movements of the requested range, the
+	// opening stock is the stock on hand when the range starts.
+	InventoryMovementSummary struct {
+		OpeningStock int `gorm:"column:opening_stock" json:"opening_stock"`
+		StockIn      int `gorm:"column:stock_in" json:"stock_in"`
+		StockOut     int `gorm:"column:stock_out" json:"stock_out"`
+		ClosingStock int `gorm:"column:closing_stock" json:"closing_stock"`
+	}
+
+	InventoryMovementReport struct {
+		database.Paginator
+		Summary InventoryMovementSummary `json:"summary"`
+	}
+
+	// InventoryDiscrepancy is a stock that no longer matches the sum of its movements,
+	// the stock of a warehouse when WarehouseID is set and the total stock otherwise.
+	InventoryDiscrepancy struct {
+		ProductID      int  `gorm:"column:product_id" json:"product_id"`
+		VariantID      *int `gorm:"column:variant_id" json:"variant_id"`
+		WarehouseID    *int `gorm:"column:warehouse_id" json:"warehouse_id"`
+		Stock          int  `gorm:"column:stock" json:"stock"`
+		LedgerStock    int  `gorm:"column:ledger_stock" json:"ledger_stock"`
+		ReservedStock  int  `gorm:"column:reserved_stock" json:"reserved_stock"`
+		LedgerReserved int  `gorm:"column:ledger_reserved" json:"ledger_reserved"`
+	}
+)
+
+func (InventoryMovement) TableName() string {
+	return "inventory_movement"
+}
+
+// CustomerActor is the actor of the movements a customer causes.
+func CustomerActor(customerID int) string {
+	return fmt.Sprintf("customer:%d", customerID)
+}
+
+// OrderReference is the reference of the movements caused by an order.
+func OrderReference(orderID int) string {
+	return fmt.Sprintf("order:%d", orderID)
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"fmt"
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+type (
+	// Invoice is the tax invoice of a paid order. Its Sequence runs without gaps
+	// within the Year it is issued, Number formats both.
+	Invoice struct {
+		ID       int       `gorm:"column:id" json:"id"`
+		OrderID  int       `gorm:"column:order_id" json:"order_id"`
+		Number   string    `gorm:"column:number" json:"number"`
+		Year     int       `gorm:"column:year" json:"year"`
+		Sequence int       `gorm:"column:sequence" json:"sequence"`
+		IssuedAt time.Time `gorm:"column:issued_at" json:"issued_at"`
+
+		CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string    `gorm:"column:created_by" json:"created_by"`
+	}
+
+	// InvoiceIssuer is the company the invoices are issued by, from the [company]
+	// section of the configuration.
+	InvoiceIssuer struct {
+		Name      string
+		Address   string
+		Phone     string
+		Email     string
+		TaxNumber string
+	}
+
+	// InvoiceLine is an order item as the invoice shows it, Price is the line total.
+	InvoiceLine struct {
+		Name      string      `gorm:"column:name"`
+		SKU       *string     `gorm:"column:sku"`
+		Quantity  int         `gorm:"column:quantity"`
+		Price     money.Money `gorm:"column:price"`
+		Discount  money.Money `gorm:"column:discount"`
+		TaxRate   float64     `gorm:"column:tax_rate"`
+		TaxAmount money.Money `gorm:"column:tax_amount"`
+	}
+
+	// InvoiceDocument is everything printed on an invoice.
+	InvoiceDocument struct {
+		Invoice  Invoice
+		Order    Order
+		Customer Customer
+		Lines    []InvoiceLine
+	}
+)
+
+func (Invoice) TableName() string {
+	return "invoice"
+}
+
+// InvoiceNumber formats the number of an invoice, like INV/2024/000042.
+func InvoiceNumber(prefix string, year, sequence int) string {
+	return fmt.Sprintf("%s/%d/%06d", prefix, year, sequence)
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+const (
+	ImageSizeOriginal = "original"
+	ImageSizeSmall    = "small"
+	ImageSizeMedium   = "medium"
+	ImageSizeLarge    = "large"
+
+	DefaultMaxImageUploadSize = 5 << 20
+)
+
+// ImageThumbnailSizes is the longest side, in pixels, of every generated thumbnail.
+var ImageThumbnailSizes = map[string]int{
+	ImageSizeSmall:  150,
+	ImageSizeMedium: 400,
+	ImageSizeLarge:  800,
+}
+
+// ImageContentTypes maps the accepted image content types to their file extension.
+var ImageContentTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+type (
+	ProductImage struct {
+		ID          int    `gorm:"column:id" json:"id"`
+		ProductID   int    `gorm:"column:product_id" json:"product_id"`
+		Position    int    `gorm:"column:position" json:"position"`
+		StorageKey  string `gorm:"column:storage_key" json:"-"`
+		ContentType string `gorm:"column:content_type" json:"content_type"`
+		Size        int64  `gorm:"column:size" json:"size"`
+		Width       int    `gorm:"column:width" json:"width"`
+		Height      int    `gorm:"column:height" json:"height"`
+
+		URLs map[string]string `gorm:"-" json:"urls"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	UploadProductImageRequest struct {
+		ProductID int
+		Content   []byte
+	}
+
+	ReorderProductImageRequest struct {
+		ProductID int   `json:"-"`
+		ImageIDs  []int `json:"image_ids" validate:"required,unique,dive,required"`
+	}
+)
+
+func (ProductImage) TableName() string {
+	return "product_image"
+}
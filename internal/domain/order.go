@@ -1,24 +1,71 @@
 package domain
 
-import "time"
+import (
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+// Order statuses, an order is pending until paid and ships in one or more
+// shipments. It is delivered once every item is. A pending order is cancelled
+// by its customer or once it is left unpaid for too long.
+const (
+	OrderStatusPending          = "pending"
+	OrderStatusCancelled        = "cancelled"
+	OrderStatusPaid             = "paid"
+	OrderStatusPartiallyShipped = "partially_shipped"
+	OrderStatusShipped          = "shipped"
+	OrderStatusDelivered        = "delivered"
+)
+
+// OrderPaidStatuses are the statuses of the paid orders, pending and cancelled
+// orders were never paid.
+var OrderPaidStatuses = []string{OrderStatusPaid, OrderStatusPartiallyShipped, OrderStatusShipped, OrderStatusDelivered}
+
+// IsPaidOrderStatus reports whether an order of the status was paid.
+func IsPaidOrderStatus(status string) bool {
+	for _, v := range OrderPaidStatuses {
+		if v == status {
+			return true
+		}
+	}
+	return false
+}
 
 type (
+	// CreateOrderCheckoutRequest ships the order to ShippingAddress with the method
+	// of ShippingMethodID, an order without both is not shipped. The region of the
+	// address is the tax region by default.
 	CreateOrderCheckoutRequest struct {
-		Order      []OrderRequest `json:"order" validate:"required,dive"`
-		CustomerID int            `json:"customer_id"`
+		Order            []OrderRequest   `json:"order" validate:"required,dive"`
+		CustomerID       int              `json:"customer_id"`
+		CouponCode       string           `json:"coupon_code" validate:"omitempty,max=30"`
+		Region           string           `json:"region" validate:"omitempty,max=50"`
+		ShippingMethodID *int             `json:"shipping_method_id" validate:"required_with=ShippingAddress,omitempty,min=1"`
+		ShippingAddress  *ShippingAddress `json:"shipping_address" validate:"required_with=ShippingMethodID"`
+		Currency         string           `json:"-"`
 	}
 
+	// OrderRequest is a line of the order. Price is the line total, at checkout it
+	// is priced from the catalog whatever is sent.
 	OrderRequest struct {
-		ProductID int     `json:"product_id" validate:"required,number"`
-		Quantity  int     `json:"quantity" validate:"required,number"`
-		Price     float64 `json:"price" validate:"required,number"`
+		ProductID int         `json:"product_id" validate:"required,number"`
+		VariantID *int        `json:"variant_id" validate:"omitempty,number"`
+		Quantity  int         `json:"quantity" validate:"required,min=1,max=1000"`
+		Price     money.Money `json:"price" validate:"omitempty,min=0"`
 	}
 
 	OrderItem struct {
-		ProductID int     `json:"product_id"`
-		Price     float64 `json:"price"`
-		Quantity  int     `json:"quantity"`
-		OrderID   int     `json:"order_id"`
+		ID        int         `gorm:"column:id" json:"id"`
+		ProductID int         `json:"product_id"`
+		VariantID *int        `json:"variant_id"`
+		SKU       *string     `json:"sku"`
+		Price     money.Money `json:"price"`
+		Discount  money.Money `gorm:"column:discount" json:"discount"`
+		TaxRateID *int        `gorm:"column:tax_rate_id" json:"tax_rate_id"`
+		TaxRate   float64     `gorm:"column:tax_rate" json:"tax_rate"`
+		TaxAmount money.Money `gorm:"column:tax_amount" json:"tax_amount"`
+		Quantity  int         `json:"quantity"`
+		OrderID   int         `json:"order_id"`
 
 		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
 		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
@@ -28,10 +75,33 @@ type (
 		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
 	}
 
+	// Order amounts are in the base currency. The customer is charged ChargedTotal,
+	// the total converted to Currency at ExchangeRate. TotalPrice includes TaxTotal,
+	// added on top of the prices in the exclusive TaxMode, and ShippingCost.
 	Order struct {
-		ID         int     `gorm:"column:id" json:"id"`
-		TotalPrice float64 `json:"total_price"`
-		CustomerID int     `json:"customer_id"`
+		ID                int         `gorm:"column:id" json:"id"`
+		Status            string      `gorm:"column:status" json:"status"`
+		Subtotal          money.Money `gorm:"column:subtotal" json:"subtotal"`
+		PromotionDiscount money.Money `gorm:"column:promotion_discount" json:"promotion_discount"`
+		Discount          money.Money `gorm:"column:discount" json:"discount"`
+		CouponID          *int        `gorm:"column:coupon_id" json:"coupon_id"`
+		TaxMode           string      `gorm:"column:tax_mode" json:"tax_mode"`
+		TaxTotal          money.Money `gorm:"column:tax_total" json:"tax_total"`
+		Region            *string     `gorm:"column:region" json:"region"`
+		ShippingMethodID  *int        `gorm:"column:shipping_method_id" json:"shipping_method_id"`
+		ShippingMethod    *string     `gorm:"column:shipping_method" json:"shipping_method"`
+		ShippingCost      money.Money `gorm:"column:shipping_cost" json:"shipping_cost"`
+		ShippingAddress   *string     `gorm:"column:shipping_address" json:"shipping_address"`
+		ShippingCity      *string     `gorm:"column:shipping_city" json:"shipping_city"`
+		ShippingPostCode  *string     `gorm:"column:shipping_postal_code" json:"shipping_postal_code"`
+		TotalPrice        money.Money `json:"total_price"`
+		Currency          string      `gorm:"column:currency" json:"currency"`
+		ExchangeRate      float64     `gorm:"column:exchange_rate" json:"exchange_rate"`
+		ChargedTotal      money.Money `gorm:"column:charged_total" json:"charged_total"`
+		CustomerID        int         `json:"customer_id"`
+		PaymentID         *int        `gorm:"column:payment_id" json:"payment_id"`
+
+		Taxes []OrderTax `gorm:"-" json:"taxes"`
 
 		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
 		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
@@ -42,16 +112,19 @@ type (
 	}
 
 	PaymentRequest struct {
-		OrderID string  `json:"order_id"`
-		Amount  float64 `json:"amount" validate:"required,number"`
-		Method  string  `json:"method" validate:"required"`
+		OrderID    string      `json:"order_id"`
+		CustomerID int         `json:"-"`
+		Amount     money.Money `json:"amount" validate:"required,min=0"`
+		Method     string      `json:"method" validate:"required"`
 	}
 
+	// Payment is paid in the currency the order was charged in.
 	Payment struct {
-		ID     int     `json:"id"`
-		Method string  `json:"method"`
-		Amount float64 `json:"amount"`
-		Status string  `json:"status"`
+		ID       int         `json:"id"`
+		Method   string      `json:"method"`
+		Amount   money.Money `json:"amount"`
+		Currency string      `json:"-"`
+		Status   string      `json:"status"`
 
 		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
 		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
@@ -71,3 +144,19 @@ func (Order) TableName() string {
 func (Payment) TableName() string {
 	return "payment"
 }
+
+// SetChargedCurrency gives the amount the currency of the payment, the amounts are
+// stored in minor units only.
+func (p *Payment) SetChargedCurrency() {
+	if p.Currency != "" {
+		p.Amount.Currency = p.Currency
+	}
+}
+
+// SetChargedCurrency sets the currency of ChargedTotal once read from the database,
+// amounts are read in the base currency.
+func (o *Order) SetChargedCurrency() {
+	if o.Currency != "" {
+		o.ChargedTotal.Currency = o.Currency
+	}
+}
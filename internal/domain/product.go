@@ -1,16 +1,48 @@
 package domain
 
-import "time"
+import (
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+const (
+	ProductSortPriceAsc  = "price_asc"
+	ProductSortPriceDesc = "price_desc"
+	ProductSortNewest    = "newest"
+	ProductSortName      = "name"
+	ProductSortRelevance = "relevance"
+)
+
+// ProductAvailableStockColumn selects the stock of the product and its variants that
+// can still be ordered, summed over the active warehouses.
+const ProductAvailableStockColumn = `COALESCE((SELECT SUM(ws.stock - ws.reserved_stock) FROM warehouse_stock ws JOIN warehouse w ON w.id = ws.warehouse_id WHERE ws.product_id = p.id AND w.is_active AND w.deleted_at IS NULL), 0) AS available_stock`
+
+// ProductPriceBuckets are the boundaries of the price facet in major units of the
+// default currency, the last bucket has no upper bound.
+var ProductPriceBuckets = []float64{0, 50000, 100000, 500000, 1000000}
 
 type (
+	// Product is shipped by its Weight in grams, its dimensions are in millimeters.
 	Product struct {
-		ID           int     `gorm:"column:id" json:"id"`
-		Name         string  `gorm:"column:name" json:"name"`
-		Description  string  `gorm:"column:description" json:"description"`
-		CategoryID   string  `gorm:"column:category_id" json:"category_id"`
-		CategoryName string  `gorm:"column:category_name" json:"category_name"`
-		Price        float64 `gorm:"column:price" json:"price"`
-		Stock        int     `gorm:"column:stock" json:"stock"`
+		ID             int         `gorm:"column:id" json:"id"`
+		SKU            *string     `gorm:"column:sku" json:"sku"`
+		Name           string      `gorm:"column:name" json:"name"`
+		Description    string      `gorm:"column:description" json:"description"`
+		CategoryID     string      `gorm:"column:category_id" json:"category_id"`
+		CategoryName   string      `gorm:"column:category_name" json:"category_name"`
+		Price          money.Money `gorm:"column:price" json:"price"`
+		Weight         int         `gorm:"column:weight" json:"weight"`
+		Length         int         `gorm:"column:length" json:"length"`
+		Width          int         `gorm:"column:width" json:"width"`
+		Height         int         `gorm:"column:height" json:"height"`
+		Stock          int         `gorm:"column:stock" json:"stock"`
+		AvailableStock int         `gorm:"column:available_stock;->" json:"available_stock"`
+		RatingAverage  float64     `gorm:"column:rating_average;->" json:"rating_average"`
+		RatingCount    int         `gorm:"column:rating_count;->" json:"rating_count"`
+		Rank           float64     `gorm:"column:rank;->" json:"rank,omitempty"`
+
+		Images []ProductImage `gorm:"-" json:"images"`
 
 		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
 		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
@@ -21,9 +53,38 @@ type (
 	}
 
 	GetProductListRequest struct {
-		Page            int    `json:"-"`
-		Limit           int    `json:"-"`
-		ProductCategory string `json:"product_category"`
-		Search          string `json:"search"`
+		Page              int          `json:"-"`
+		Limit             int          `json:"-"`
+		CursorMode        bool         `json:"-"`
+		Cursor            string       `json:"-"`
+		ProductCategories []string     `json:"product_category"`
+		Search            string       `json:"search"`
+		MinPrice          *money.Money `json:"min_price" validate:"omitempty,min=0"`
+		MaxPrice          *money.Money `json:"max_price" validate:"omitempty,min=0"`
+		InStock           bool         `json:"in_stock"`
+		Sort              string       `json:"sort" validate:"omitempty,oneof=price_asc price_desc newest name relevance"`
+		Currency          string       `json:"-"`
+	}
+
+	CategoryFacet struct {
+		CategoryName string `gorm:"column:category_name" json:"category_name"`
+		Count        int64  `gorm:"column:count" json:"count"`
+	}
+
+	PriceBucketFacet struct {
+		Bucket int          `gorm:"column:bucket" json:"-"`
+		Min    money.Money  `gorm:"-" json:"min"`
+		Max    *money.Money `gorm:"-" json:"max"`
+		Count  int64        `gorm:"column:count" json:"count"`
+	}
+
+	ProductFacets struct {
+		Categories   []CategoryFacet    `json:"categories"`
+		PriceBuckets []PriceBucketFacet `json:"price_buckets"`
+	}
+
+	ProductListResponse struct {
+		database.Paginator
+		Facets ProductFacets `json:"facets"`
 	}
 )
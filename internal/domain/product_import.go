@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+const (
+	ProductImportStatusRunning   = "running"
+	ProductImportStatusCompleted = "completed"
+	ProductImportStatusFailed    = "failed"
+
+	// ProductImportBatchSize is the number of rows upserted per transaction.
+	ProductImportBatchSize = 500
+
+	// ProductImportJobExpiration is how long the status of an import job can be polled.
+	ProductImportJobExpiration = 24 * time.Hour
+
+	DefaultMaxImportUploadSize = 20 << 20
+)
+
+// ProductImportColumns is the header of the import file, exports use the same
+// columns so an export can be edited and imported back. The stock is the stock
+// of the warehouse, the default warehouse when the column is left empty. The
+// weight, in grams, and the dimensions, in millimeters, are optional.
+var ProductImportColumns = []string{"sku", "name", "description", "category", "price", "stock", "warehouse", "weight", "length", "width", "height"}
+
+// productImportOptionalColumns may be left out of the header of the import file.
+var productImportOptionalColumns = map[string]bool{"description": true, "warehouse": true, "weight": true, "length": true, "width": true, "height": true}
+
+// IsOptionalImportColumn tells whether the import file may leave the column out.
+func IsOptionalImportColumn(column string) bool {
+	return productImportOptionalColumns[column]
+}
+
+type (
+	ProductImportRow struct {
+		Row         int         `json:"-"`
+		SKU         string      `json:"sku" validate:"required,max=64"`
+		Name        string      `json:"name" validate:"required,max=50"`
+		Description string      `json:"description" validate:"max=100"`
+		Category    string      `json:"category" validate:"required"`
+		CategoryID  int         `json:"-"`
+		Price       money.Money `json:"price" validate:"min=0"`
+		Stock       int         `json:"stock" validate:"min=0,max=32767"`
+		Warehouse   string      `json:"warehouse"`
+		WarehouseID int         `json:"-"`
+		Weight      int         `json:"weight" validate:"min=0"`
+		Length      int         `json:"length" validate:"min=0"`
+		Width       int         `json:"width" validate:"min=0"`
+		Height      int         `json:"height" validate:"min=0"`
+	}
+
+	ImportFieldError struct {
+		Field       string `json:"field"`
+		Description string `json:"description"`
+	}
+
+	ProductImportRowError struct {
+		Row    int                `json:"row"`
+		SKU    string             `json:"sku"`
+		Errors []ImportFieldError `json:"errors"`
+	}
+
+	// ProductImportJob is the progress and the per-row report of an import.
+	ProductImportJob struct {
+		ID            string                  `json:"id"`
+		Status        string                  `json:"status"`
+		TotalRows     int                     `json:"total_rows"`
+		ProcessedRows int                     `json:"processed_rows"`
+		Inserted      int                     `json:"inserted"`
+		Updated       int                     `json:"updated"`
+		Failed        int                     `json:"failed"`
+		Errors        []ProductImportRowError `json:"errors"`
+		CreatedAt     time.Time               `json:"created_at"`
+		FinishedAt    *time.Time              `json:"finished_at"`
+	}
+
+	ImportProductRequest struct {
+		FileName string
+		Content  []byte
+		Lang     string
+	}
+
+	// ProductUpsertResult tells whether the row of a SKU was inserted or updated.
+	ProductUpsertResult struct {
+		ID       int    `gorm:"column:id"`
+		SKU      string `gorm:"column:sku"`
+		Inserted bool   `gorm:"column:inserted"`
+	}
+
+	Category struct {
+		ID   int    `gorm:"column:id" json:"id"`
+		Name string `gorm:"column:name" json:"name"`
+	}
+)
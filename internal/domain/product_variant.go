@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+type (
+	ProductVariant struct {
+		ID            int          `gorm:"column:id" json:"id"`
+		ProductID     int          `gorm:"column:product_id" json:"product_id"`
+		SKU           string       `gorm:"column:sku" json:"sku"`
+		Price         *money.Money `gorm:"column:price" json:"price"`
+		Stock         int          `gorm:"column:stock" json:"stock"`
+		ReservedStock int          `gorm:"column:reserved_stock" json:"reserved_stock"`
+		Barcode       *string      `gorm:"column:barcode" json:"barcode"`
+
+		EffectivePrice money.Money     `gorm:"-" json:"effective_price"`
+		Options        []VariantOption `gorm:"-" json:"options"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	VariantOption struct {
+		VariantID int    `gorm:"column:variant_id" json:"-"`
+		Name      string `gorm:"column:name" json:"name" validate:"required"`
+		Value     string `gorm:"column:value" json:"value" validate:"required"`
+	}
+
+	CreateProductVariantRequest struct {
+		ProductID   int             `json:"-"`
+		SKU         string          `json:"sku" validate:"required,max=64"`
+		Price       *money.Money    `json:"price" validate:"omitempty,min=0"`
+		Stock       int             `json:"stock" validate:"min=0"`
+		WarehouseID *int            `json:"warehouse_id" validate:"omitempty,number"`
+		Barcode     *string         `json:"barcode" validate:"omitempty,max=64"`
+		Options     []VariantOption `json:"options" validate:"required,dive"`
+	}
+
+	// ProductOption lists every value an option (size, color, ...) takes across the variants of a product.
+	ProductOption struct {
+		Name   string   `json:"name"`
+		Values []string `json:"values"`
+	}
+
+	ProductDetail struct {
+		Product
+		Options      []ProductOption         `json:"options"`
+		Variants     []ProductVariant        `json:"variants"`
+		Availability []WarehouseAvailability `json:"availability"`
+		Related      []RelatedProduct        `json:"related"`
+	}
+)
+
+func (ProductVariant) TableName() string {
+	return "product_variant"
+}
+
+func (VariantOption) TableName() string {
+	return "product_variant_option"
+}
+
+// AvailableStock is the stock that can still be reserved.
+func (v ProductVariant) AvailableStock() int {
+	return v.Stock - v.ReservedStock
+}
@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+// Rule types of the automatic promotions.
+const (
+	PromotionBuyXGetY = "buy_x_get_y"
+	PromotionBundle   = "bundle"
+	PromotionTiered   = "tiered"
+)
+
+type (
+	// Promotion is a rule applied automatically to the cart and at checkout. Rules are
+	// evaluated by ascending Priority, a rule that is not Stackable is never combined
+	// with another one.
+	Promotion struct {
+		ID          int          `gorm:"column:id" json:"id"`
+		Name        string       `gorm:"column:name" json:"name"`
+		RuleType    string       `gorm:"column:rule_type" json:"rule_type"`
+		Priority    int          `gorm:"column:priority" json:"priority"`
+		Stackable   bool         `gorm:"column:stackable" json:"stackable"`
+		BuyQuantity *int         `gorm:"column:buy_quantity" json:"buy_quantity"`
+		GetQuantity *int         `gorm:"column:get_quantity" json:"get_quantity"`
+		BundlePrice *money.Money `gorm:"column:bundle_price" json:"bundle_price"`
+		StartsAt    *time.Time   `gorm:"column:starts_at" json:"starts_at"`
+		EndsAt      *time.Time   `gorm:"column:ends_at" json:"ends_at"`
+		IsActive    bool         `gorm:"column:is_active" json:"is_active"`
+
+		ProductIDs  []int           `gorm:"-" json:"product_ids"`
+		CategoryIDs []int           `gorm:"-" json:"category_ids"`
+		Tiers       []PromotionTier `gorm:"-" json:"tiers"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	// PromotionTarget restricts a promotion to a product or to the products of a
+	// category. The products of a bundle are its product targets.
+	PromotionTarget struct {
+		PromotionID int  `gorm:"column:promotion_id"`
+		ProductID   *int `gorm:"column:product_id"`
+		CategoryID  *int `gorm:"column:category_id"`
+	}
+
+	// PromotionTier is a step of a tiered promotion, reached once the cart total is at
+	// least MinTotal. Like a coupon it takes DiscountValue percent off, or
+	// DiscountAmount off when its DiscountType is fixed.
+	PromotionTier struct {
+		PromotionID    int          `gorm:"column:promotion_id" json:"-"`
+		MinTotal       money.Money  `gorm:"column:min_total" json:"min_total" validate:"min=0"`
+		DiscountType   string       `gorm:"column:discount_type" json:"discount_type" validate:"required,oneof=percentage fixed"`
+		DiscountValue  *float64     `gorm:"column:discount_value" json:"discount_value" validate:"required_if=DiscountType percentage,omitempty,gt=0"`
+		DiscountAmount *money.Money `gorm:"column:discount_amount" json:"discount_amount" validate:"required_if=DiscountType fixed,omitempty,gt=0"`
+	}
+
+	// OrderPromotion is a promotion applied to an order.
+	OrderPromotion struct {
+		OrderID     int         `gorm:"column:order_id" json:"order_id"`
+		PromotionID int         `gorm:"column:promotion_id" json:"promotion_id"`
+		Name        string      `gorm:"column:name" json:"name"`
+		Discount    money.Money `gorm:"column:discount" json:"discount"`
+
+		CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	}
+
+	CreatePromotionRequest struct {
+		Name        string          `json:"name" validate:"required,max=100"`
+		RuleType    string          `json:"rule_type" validate:"required,oneof=buy_x_get_y bundle tiered"`
+		Priority    int             `json:"priority" validate:"min=0"`
+		Stackable   bool            `json:"stackable"`
+		BuyQuantity *int            `json:"buy_quantity" validate:"required_if=RuleType buy_x_get_y,omitempty,min=1"`
+		GetQuantity *int            `json:"get_quantity" validate:"required_if=RuleType buy_x_get_y,omitempty,min=1"`
+		BundlePrice *money.Money    `json:"bundle_price" validate:"required_if=RuleType bundle,omitempty,gt=0"`
+		StartsAt    *time.Time      `json:"starts_at"`
+		EndsAt      *time.Time      `json:"ends_at"`
+		ProductIDs  []int           `json:"product_ids" validate:"required_if=RuleType bundle,dive,min=1"`
+		CategoryIDs []int           `json:"category_ids" validate:"dive,min=1"`
+		Tiers       []PromotionTier `json:"tiers" validate:"required_if=RuleType tiered,dive"`
+	}
+
+	UpdatePromotionRequest struct {
+		ID        int        `json:"-"`
+		Name      *string    `json:"name" validate:"omitempty,max=100"`
+		Priority  *int       `json:"priority" validate:"omitempty,min=0"`
+		Stackable *bool      `json:"stackable"`
+		StartsAt  *time.Time `json:"starts_at"`
+		EndsAt    *time.Time `json:"ends_at"`
+		IsActive  *bool      `json:"is_active"`
+	}
+
+	GetPromotionListRequest struct {
+		Page  int `json:"-"`
+		Limit int `json:"-"`
+	}
+
+	// PromotionLine is a cart or order line as promotions see it.
+	PromotionLine struct {
+		ProductID  int         `gorm:"column:product_id" json:"product_id"`
+		VariantID  *int        `gorm:"-" json:"variant_id"`
+		CategoryID int         `gorm:"column:category_id" json:"category_id"`
+		UnitPrice  money.Money `gorm:"-" json:"unit_price"`
+		Quantity   int         `gorm:"-" json:"quantity"`
+	}
+
+	AppliedPromotion struct {
+		PromotionID int         `json:"promotion_id"`
+		Name        string      `json:"name"`
+		RuleType    string      `json:"rule_type"`
+		Discount    money.Money `json:"discount"`
+		ProductIDs  []int       `json:"product_ids"`
+	}
+
+	// PromotionResult is the discount of the promotions, Lines splits it over the
+	// lines evaluated, in their order.
+	PromotionResult struct {
+		Subtotal money.Money        `json:"subtotal"`
+		Discount money.Money        `json:"discount"`
+		Total    money.Money        `json:"total"`
+		Applied  []AppliedPromotion `json:"applied"`
+		Lines    []money.Money      `json:"-"`
+	}
+)
+
+func (Promotion) TableName() string {
+	return "promotion"
+}
+
+func (PromotionTarget) TableName() string {
+	return "promotion_target"
+}
+
+func (PromotionTier) TableName() string {
+	return "promotion_tier"
+}
+
+func (OrderPromotion) TableName() string {
+	return "order_promotion"
+}
+
+// Targets reports whether the promotion applies to the line, promotions without
+// targets apply to every line.
+func (p Promotion) Targets(line PromotionLine) bool {
+	if len(p.ProductIDs) == 0 && len(p.CategoryIDs) == 0 {
+		return true
+	}
+	for _, v := range p.ProductIDs {
+		if v == line.ProductID {
+			return true
+		}
+	}
+	for _, v := range p.CategoryIDs {
+		if v == line.CategoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount returns the discount of the tier on base, at most base.
+func (t PromotionTier) Discount(base money.Money) (money.Money, error) {
+	if !ValidDiscount(t.DiscountType, t.DiscountValue, t.DiscountAmount) {
+		return money.Money{}, ErrInvalidDiscountValue
+	}
+
+	discount := money.Money{}
+	if t.DiscountType == CouponDiscountFixed {
+		discount = *t.DiscountAmount
+	} else {
+		var err error
+		if discount, err = base.Percent(*t.DiscountValue); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return money.Min(discount, base)
+}
+
+// NetOf returns the order lines, the lines evaluated, less their promotion discount,
+// so the discounts coming after the promotions don't discount the same units again.
+func (r PromotionResult) NetOf(order []OrderRequest) ([]OrderRequest, error) {
+	data := make([]OrderRequest, 0, len(order))
+	for i, v := range order {
+		if i < len(r.Lines) {
+			discount, err := money.Min(r.Lines[i], v.Price)
+			if err != nil {
+				return nil, err
+			}
+			if v.Price, err = v.Price.Sub(discount); err != nil {
+				return nil, err
+			}
+		}
+		data = append(data, v)
+	}
+	return data, nil
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "github.com/online-store/pkg/money"
+
+// Sources of a recommended product.
+const (
+	RecommendationBoughtTogether     = "bought_together"
+	RecommendationCategoryBestseller = "category_bestseller"
+
+	// RecommendationLockKey keeps the instances of the service from refreshing the
+	// recommendations at the same time.
+	RecommendationLockKey = 7305001
+)
+
+type (
+	// RelatedProduct is a product recommended with others, Source tells whether it
+	// is often bought with them or sells best in their categories.
+	RelatedProduct struct {
+		ProductID      int         `gorm:"column:product_id" json:"product_id"`
+		Name           string      `gorm:"column:name" json:"name"`
+		Price          money.Money `gorm:"column:price" json:"price"`
+		AvailableStock int         `gorm:"column:available_stock" json:"available_stock"`
+		RatingAverage  float64     `gorm:"column:rating_average" json:"rating_average"`
+		RatingCount    int         `gorm:"column:rating_count" json:"rating_count"`
+		Source         string      `gorm:"-" json:"source"`
+	}
+)
@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"fmt"
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+// Return statuses. A requested return is approved or rejected, the items of an
+// approved one are received then inspected, which completes it.
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusReceived  = "received"
+	ReturnStatusCompleted = "completed"
+
+	// Outcomes of the inspection of a returned item.
+	ReturnOutcomeRestock  = "restock"
+	ReturnOutcomeWriteOff = "write_off"
+
+	RefundStatusSuccess = "Success"
+)
+
+// returnTransitions lists the statuses a return can move to from each status.
+var returnTransitions = map[string][]string{
+	ReturnStatusRequested: {ReturnStatusApproved, ReturnStatusRejected},
+	ReturnStatusApproved:  {ReturnStatusReceived},
+	ReturnStatusReceived:  {ReturnStatusCompleted},
+}
+
+type (
+	// Return asks to send back delivered items of an order, RefundAmount is what
+	// the customer gets back once it is approved.
+	Return struct {
+		ID           int         `gorm:"column:id" json:"id"`
+		OrderID      int         `gorm:"column:order_id" json:"order_id"`
+		CustomerID   int         `gorm:"column:customer_id" json:"customer_id"`
+		Status       string      `gorm:"column:status" json:"status"`
+		RefundAmount money.Money `gorm:"column:refund_amount" json:"refund_amount"`
+
+		Items  []ReturnItem  `gorm:"-" json:"items"`
+		Events []ReturnEvent `gorm:"-" json:"events,omitempty"`
+		Refund *Refund       `gorm:"-" json:"refund,omitempty"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	// ReturnItem is an order item sent back. Its Outcome and WarehouseID are set
+	// by the inspection.
+	ReturnItem struct {
+		ReturnID     int         `gorm:"column:return_id" json:"-"`
+		OrderItemID  int         `gorm:"column:order_item_id" json:"order_item_id"`
+		ProductID    int         `gorm:"column:product_id;->" json:"product_id"`
+		VariantID    *int        `gorm:"column:variant_id;->" json:"variant_id"`
+		Quantity     int         `gorm:"column:quantity" json:"quantity"`
+		Reason       string      `gorm:"column:reason" json:"reason"`
+		Note         *string     `gorm:"column:note" json:"note"`
+		RefundAmount money.Money `gorm:"column:refund_amount" json:"refund_amount"`
+		Outcome      *string     `gorm:"column:outcome" json:"outcome"`
+		WarehouseID  *int        `gorm:"column:warehouse_id" json:"warehouse_id"`
+	}
+
+	// ReturnEvent records a step of a return, who took it and when.
+	ReturnEvent struct {
+		ReturnID  int       `gorm:"column:return_id" json:"-"`
+		Status    string    `gorm:"column:status" json:"status"`
+		Actor     string    `gorm:"column:actor" json:"actor"`
+		Note      *string   `gorm:"column:note" json:"note"`
+		CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	}
+
+	// Refund pays back an approved return. Amount is in the base currency, the
+	// customer gets ChargedAmount in the currency the order was charged in.
+	Refund struct {
+		ID            int         `gorm:"column:id" json:"id"`
+		ReturnID      int         `gorm:"column:return_id" json:"return_id"`
+		OrderID       int         `gorm:"column:order_id" json:"order_id"`
+		PaymentID     *int        `gorm:"column:payment_id" json:"payment_id"`
+		Amount        money.Money `gorm:"column:amount" json:"amount"`
+		Currency      string      `gorm:"column:currency" json:"currency"`
+		ChargedAmount money.Money `gorm:"column:charged_amount" json:"charged_amount"`
+		Status        string      `gorm:"column:status" json:"status"`
+
+		CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string    `gorm:"column:created_by" json:"created_by"`
+	}
+
+	// ReturnableItem is how much of an order item was delivered and how much of it
+	// is already returned, by the returns not rejected.
+	ReturnableItem struct {
+		OrderItemID int `gorm:"column:order_item_id"`
+		Delivered   int `gorm:"column:delivered"`
+		Returned    int `gorm:"column:returned"`
+	}
+
+	CreateReturnRequest struct {
+		OrderID    int                 `json:"-"`
+		CustomerID int                 `json:"-"`
+		Items      []ReturnItemRequest `json:"items" validate:"required,min=1,unique=OrderItemID,dive"`
+	}
+
+	ReturnItemRequest struct {
+		OrderItemID int     `json:"order_item_id" validate:"required,min=1"`
+		Quantity    int     `json:"quantity" validate:"required,min=1"`
+		Reason      string  `json:"reason" validate:"required,oneof=damaged defective wrong_item not_as_described no_longer_needed other"`
+		Note        *string `json:"note" validate:"omitempty,max=255"`
+	}
+
+	// ReviewReturnRequest moves a return to Status, approving, rejecting or
+	// receiving it.
+	ReviewReturnRequest struct {
+		ID     int     `json:"-"`
+		Status string  `json:"-"`
+		Note   *string `json:"note" validate:"omitempty,max=255"`
+	}
+
+	InspectReturnRequest struct {
+		ID    int                       `json:"-"`
+		Items []ReturnInspectionRequest `json:"items" validate:"required,min=1,unique=OrderItemID,dive"`
+		Note  *string                   `json:"note" validate:"omitempty,max=255"`
+	}
+
+	// ReturnInspectionRequest restocks an item in WarehouseID, the default warehouse
+	// when it is left out, or writes it off.
+	ReturnInspectionRequest struct {
+		OrderItemID int    `json:"order_item_id" validate:"required,min=1"`
+		Outcome     string `json:"outcome" validate:"required,oneof=restock write_off"`
+		WarehouseID *int   `json:"warehouse_id" validate:"omitempty,min=1"`
+	}
+
+	GetReturnListRequest struct {
+		Page       int    `json:"-"`
+		Limit      int    `json:"-"`
+		CustomerID int    `json:"-"`
+		Status     string `json:"status" validate:"omitempty,oneof=requested approved rejected received completed"`
+	}
+)
+
+func (Return) TableName() string {
+	return "return_request"
+}
+
+func (ReturnItem) TableName() string {
+	return "return_item"
+}
+
+func (ReturnEvent) TableName() string {
+	return "return_event"
+}
+
+func (Refund) TableName() string {
+	return "refund"
+}
+
+// CanMoveTo tells whether the return can move to status.
+func (r Return) CanMoveTo(status string) bool {
+	for _, v := range returnTransitions[r.Status] {
+		if v == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SetChargedCurrency gives the charged amount the currency of the refund, the
+// amounts are stored in minor units only.
+func (r *Refund) SetChargedCurrency() {
+	if r.Currency != "" {
+		r.ChargedAmount.Currency = r.Currency
+	}
+}
+
+// ReturnReference is the reference of the movements caused by a return.
+func ReturnReference(returnID int) string {
+	return fmt.Sprintf("return:%d", returnID)
+}
+
+// RefundAmount is what quantity units of the item are paid back: their share of
+// the line after its discount, with its tax when the order added it on top.
+func (i OrderItem) RefundAmount(quantity int, taxMode string) (money.Money, error) {
+	paid, err := i.Price.Sub(i.Discount)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if taxMode == TaxExclusive {
+		if paid, err = paid.Add(i.TaxAmount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return paid.MulDiv(int64(quantity), int64(i.Quantity))
+}
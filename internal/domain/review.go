@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+// Review statuses. A review waits for an admin, only approved reviews are listed
+// and counted in the rating of the product.
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+
+	ReviewSortNewest  = "newest"
+	ReviewSortHelpful = "helpful"
+)
+
+type (
+	// Review rates a product the customer received from 1 to 5 stars. HelpfulCount
+	// counts the customers who found it helpful.
+	Review struct {
+		ID           int    `gorm:"column:id" json:"id"`
+		ProductID    int    `gorm:"column:product_id" json:"product_id"`
+		CustomerID   int    `gorm:"column:customer_id" json:"customer_id"`
+		CustomerName string `gorm:"column:customer_name;->" json:"customer_name"`
+		Rating       int    `gorm:"column:rating" json:"rating"`
+		Title        string `gorm:"column:title" json:"title"`
+		Body         string `gorm:"column:body" json:"body"`
+		Status       string `gorm:"column:status" json:"status"`
+		HelpfulCount int    `gorm:"column:helpful_count" json:"helpful_count"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	// ReviewVote records a customer finding a review helpful.
+	ReviewVote struct {
+		ReviewID   int       `gorm:"column:review_id"`
+		CustomerID int       `gorm:"column:customer_id"`
+		CreatedAt  time.Time `gorm:"column:created_at"`
+	}
+
+	CreateReviewRequest struct {
+		ProductID  int    `json:"-"`
+		CustomerID int    `json:"-"`
+		Rating     int    `json:"rating" validate:"required,min=1,max=5"`
+		Title      string `json:"title" validate:"required,max=100"`
+		Body       string `json:"body" validate:"required,max=2000"`
+	}
+
+	ModerateReviewRequest struct {
+		ID     int    `json:"-"`
+		Status string `json:"status" validate:"required,oneof=approved rejected"`
+	}
+
+	// GetReviewListRequest lists the reviews of a product when ProductID is set,
+	// of every product otherwise.
+	GetReviewListRequest struct {
+		Page      int
+		Limit     int
+		ProductID int
+		Status    string `validate:"omitempty,oneof=pending approved rejected"`
+		Sort      string `validate:"omitempty,oneof=newest helpful"`
+	}
+)
+
+func (Review) TableName() string {
+	return "review"
+}
+
+func (ReviewVote) TableName() string {
+	return "review_vote"
+}
@@ -0,0 +1,131 @@
+package domain
+
+import "time"
+
+// Shipment statuses. A pending shipment is packed but not handed to the carrier
+// yet, a cancelled one gives its items back to be shipped again.
+const (
+	ShipmentStatusPending   = "pending"
+	ShipmentStatusShipped   = "shipped"
+	ShipmentStatusInTransit = "in_transit"
+	ShipmentStatusDelivered = "delivered"
+	ShipmentStatusCancelled = "cancelled"
+)
+
+// shipmentTransitions lists the statuses a shipment can move to from each status.
+var shipmentTransitions = map[string][]string{
+	ShipmentStatusPending:   {ShipmentStatusShipped, ShipmentStatusCancelled},
+	ShipmentStatusShipped:   {ShipmentStatusInTransit, ShipmentStatusDelivered},
+	ShipmentStatusInTransit: {ShipmentStatusDelivered},
+}
+
+type (
+	// Shipment ships some of the items of an order, an order may ship in several.
+	Shipment struct {
+		ID             int        `gorm:"column:id" json:"id"`
+		OrderID        int        `gorm:"column:order_id" json:"order_id"`
+		Carrier        string     `gorm:"column:carrier" json:"carrier"`
+		TrackingNumber *string    `gorm:"column:tracking_number" json:"tracking_number"`
+		Status         string     `gorm:"column:status" json:"status"`
+		ShippedAt      *time.Time `gorm:"column:shipped_at" json:"shipped_at"`
+		DeliveredAt    *time.Time `gorm:"column:delivered_at" json:"delivered_at"`
+
+		Items []ShipmentItem `gorm:"-" json:"items"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	ShipmentItem struct {
+		ShipmentID  int  `gorm:"column:shipment_id" json:"-"`
+		OrderItemID int  `gorm:"column:order_item_id" json:"order_item_id"`
+		ProductID   int  `gorm:"column:product_id;->" json:"product_id"`
+		VariantID   *int `gorm:"column:variant_id;->" json:"variant_id"`
+		Quantity    int  `gorm:"column:quantity" json:"quantity"`
+	}
+
+	CreateShipmentRequest struct {
+		OrderID        int                   `json:"-"`
+		Carrier        string                `json:"carrier" validate:"required,max=50"`
+		TrackingNumber *string               `json:"tracking_number" validate:"omitempty,max=100"`
+		Status         string                `json:"status" validate:"omitempty,oneof=pending shipped"`
+		Items          []ShipmentItemRequest `json:"items" validate:"required,min=1,dive"`
+	}
+
+	ShipmentItemRequest struct {
+		OrderItemID int `json:"order_item_id" validate:"required,min=1"`
+		Quantity    int `json:"quantity" validate:"required,min=1"`
+	}
+
+	UpdateShipmentRequest struct {
+		ID             int     `json:"-"`
+		Carrier        *string `json:"carrier" validate:"omitempty,max=50"`
+		TrackingNumber *string `json:"tracking_number" validate:"omitempty,max=100"`
+		Status         *string `json:"status" validate:"omitempty,oneof=shipped in_transit delivered cancelled"`
+	}
+)
+
+func (Shipment) TableName() string {
+	return "shipment"
+}
+
+func (ShipmentItem) TableName() string {
+	return "shipment_item"
+}
+
+// CanMoveTo tells whether the shipment can move to status.
+func (s Shipment) CanMoveTo(status string) bool {
+	for _, v := range shipmentTransitions[s.Status] {
+		if v == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsShipped tells whether the carrier has the items of the shipment, or has
+// delivered them.
+func (s Shipment) IsShipped() bool {
+	return s.Status == ShipmentStatusShipped || s.Status == ShipmentStatusInTransit || s.Status == ShipmentStatusDelivered
+}
+
+// OrderStatusFromShipments is the status of a paid order whose items ship in
+// shipments: shipped once every item ships, delivered once every item is.
+func OrderStatusFromShipments(items []OrderItem, shipments []Shipment) string {
+	shipped := make(map[int]int)
+	delivered := make(map[int]int)
+	for _, s := range shipments {
+		for _, v := range s.Items {
+			if s.IsShipped() {
+				shipped[v.OrderItemID] += v.Quantity
+			}
+			if s.Status == ShipmentStatusDelivered {
+				delivered[v.OrderItemID] += v.Quantity
+			}
+		}
+	}
+
+	allShipped, allDelivered := true, true
+	for _, v := range items {
+		if shipped[v.ID] < v.Quantity {
+			allShipped = false
+		}
+		if delivered[v.ID] < v.Quantity {
+			allDelivered = false
+		}
+	}
+
+	switch {
+	case allDelivered:
+		return OrderStatusDelivered
+	case allShipped:
+		return OrderStatusShipped
+	case len(shipped) > 0:
+		return OrderStatusPartiallyShipped
+	}
+	return OrderStatusPaid
+}
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+// Rate types of the shipping methods. A flat rate costs Rate, a weight based one
+// Rate plus PerKgRate for every started kilogram, a carrier rate is quoted by the
+// carrier.
+const (
+	ShippingRateFlat    = "flat"
+	ShippingRateWeight  = "weight"
+	ShippingRateCarrier = "carrier"
+)
+
+type (
+	// ShippingZone groups the regions shipped to with the same methods, a region
+	// belongs to one zone at most.
+	ShippingZone struct {
+		ID       int      `gorm:"column:id" json:"id"`
+		Name     string   `gorm:"column:name" json:"name"`
+		IsActive bool     `gorm:"column:is_active" json:"is_active"`
+		Regions  []string `gorm:"-" json:"regions"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	ShippingZoneRegion struct {
+		ZoneID int    `gorm:"column:zone_id"`
+		Region string `gorm:"column:region"`
+	}
+
+	// ShippingMethod is a way to ship to the regions of a zone. The shipping is free
+	// once the subtotal of the goods reaches FreeOver.
+	ShippingMethod struct {
+		ID             int          `gorm:"column:id" json:"id"`
+		ZoneID         int          `gorm:"column:zone_id" json:"zone_id"`
+		Name           string       `gorm:"column:name" json:"name"`
+		RateType       string       `gorm:"column:rate_type" json:"rate_type"`
+		Rate           money.Money  `gorm:"column:rate" json:"rate"`
+		PerKgRate      money.Money  `gorm:"column:per_kg_rate" json:"per_kg_rate"`
+		Carrier        *string      `gorm:"column:carrier" json:"carrier"`
+		CarrierService *string      `gorm:"column:carrier_service" json:"carrier_service"`
+		FreeOver       *money.Money `gorm:"column:free_over" json:"free_over"`
+		EstimatedDays  *int         `gorm:"column:estimated_days" json:"estimated_days"`
+		IsActive       bool         `gorm:"column:is_active" json:"is_active"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	CreateShippingZoneRequest struct {
+		Name    string   `json:"name" validate:"required,max=100"`
+		Regions []string `json:"regions" validate:"required,min=1,dive,required,max=50"`
+	}
+
+	UpdateShippingZoneRequest struct {
+		ID       int      `json:"-"`
+		Name     *string  `json:"name" validate:"omitempty,max=100"`
+		Regions  []string `json:"regions" validate:"omitempty,min=1,dive,required,max=50"`
+		IsActive *bool    `json:"is_active"`
+	}
+
+	CreateShippingMethodRequest struct {
+		ZoneID         int          `json:"zone_id" validate:"required,min=1"`
+		Name           string       `json:"name" validate:"required,max=100"`
+		RateType       string       `json:"rate_type" validate:"required,oneof=flat weight carrier"`
+		Rate           money.Money  `json:"rate" validate:"min=0"`
+		PerKgRate      money.Money  `json:"per_kg_rate" validate:"min=0"`
+		Carrier        *string      `json:"carrier" validate:"required_if=RateType carrier,omitempty,max=50"`
+		CarrierService *string      `json:"carrier_service" validate:"omitempty,max=50"`
+		FreeOver       *money.Money `json:"free_over" validate:"omitempty,min=0"`
+		EstimatedDays  *int         `json:"estimated_days" validate:"omitempty,min=0"`
+	}
+
+	UpdateShippingMethodRequest struct {
+		ID            int          `json:"-"`
+		Name          *string      `json:"name" validate:"omitempty,max=100"`
+		Rate          *money.Money `json:"rate" validate:"omitempty,min=0"`
+		PerKgRate     *money.Money `json:"per_kg_rate" validate:"omitempty,min=0"`
+		FreeOver      *money.Money `json:"free_over" validate:"omitempty,min=0"`
+		EstimatedDays *int         `json:"estimated_days" validate:"omitempty,min=0"`
+		IsActive      *bool        `json:"is_active"`
+	}
+
+	GetShippingListRequest struct {
+		Page  int `json:"-"`
+		Limit int `json:"-"`
+	}
+
+	ShippingAddress struct {
+		Region     string `json:"region" validate:"required,max=50"`
+		City       string `json:"city" validate:"max=100"`
+		PostalCode string `json:"postal_code" validate:"max=20"`
+		Address    string `json:"address" validate:"max=255"`
+	}
+
+	ShippingQuoteRequest struct {
+		CustomerID int             `json:"-"`
+		Address    ShippingAddress `json:"address" validate:"required"`
+		Currency   string          `json:"-"`
+	}
+
+	// ShippingLine is an order line as shipping sees it, Weight is the weight in
+	// grams of one unit.
+	ShippingLine struct {
+		ProductID int `gorm:"column:product_id"`
+		Weight    int `gorm:"column:weight"`
+		Quantity  int `gorm:"-"`
+	}
+
+	// ShippingParcel is what a carrier is asked to rate: the goods of an order
+	// shipped to an address, weighing Weight grams and worth Value.
+	ShippingParcel struct {
+		Service string          `json:"service,omitempty"`
+		Address ShippingAddress `json:"address"`
+		Weight  int             `json:"weight"`
+		Value   money.Money     `json:"value"`
+	}
+
+	ShippingQuote struct {
+		MethodID      int         `json:"method_id"`
+		Name          string      `json:"name"`
+		RateType      string      `json:"rate_type"`
+		Carrier       *string     `json:"carrier"`
+		Cost          money.Money `json:"cost"`
+		EstimatedDays *int        `json:"estimated_days"`
+	}
+)
+
+func (ShippingZone) TableName() string {
+	return "shipping_zone"
+}
+
+func (ShippingZoneRegion) TableName() string {
+	return "shipping_zone_region"
+}
+
+func (ShippingMethod) TableName() string {
+	return "shipping_method"
+}
+
+// IsFree tells whether goods worth subtotal ship for free with the method.
+func (m ShippingMethod) IsFree(subtotal money.Money) (bool, error) {
+	if m.FreeOver == nil {
+		return false, nil
+	}
+	cmp, err := subtotal.Cmp(*m.FreeOver)
+	if err != nil {
+		return false, err
+	}
+	return cmp >= 0, nil
+}
+
+// Cost prices the flat and weight based methods for goods weighing weight grams
+// and worth subtotal. Carrier rates are quoted by the carrier instead.
+func (m ShippingMethod) Cost(weight int, subtotal money.Money) (money.Money, error) {
+	free, err := m.IsFree(subtotal)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if free {
+		return money.Zero(m.Rate.Currency), nil
+	}
+
+	if m.RateType != ShippingRateWeight {
+		return m.Rate, nil
+	}
+
+	kilograms := int64((weight + 999) / 1000)
+	perKg, err := m.PerKgRate.Mul(kilograms)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return m.Rate.Add(perKg)
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// DefaultLowStockThreshold applies to products without a threshold of their own.
+const DefaultLowStockThreshold = 5
+
+type (
+	SetLowStockThresholdRequest struct {
+		ProductID int  `json:"-"`
+		Threshold *int `json:"threshold" validate:"omitempty,min=0"`
+	}
+
+	// LowStockProduct is a product whose available stock, across the active
+	// warehouses, is at or below its threshold.
+	LowStockProduct struct {
+		ProductID int     `gorm:"column:product_id" json:"product_id"`
+		SKU       *string `gorm:"column:sku" json:"sku"`
+		Name      string  `gorm:"column:name" json:"name"`
+		Available int     `gorm:"column:available" json:"available"`
+		Threshold int     `gorm:"column:threshold" json:"threshold"`
+	}
+
+	// StockSubscription asks to notify a customer once the product, or one of its
+	// variants, can be ordered again.
+	StockSubscription struct {
+		ID         int        `gorm:"column:id" json:"id"`
+		CustomerID int        `gorm:"column:customer_id" json:"customer_id"`
+		ProductID  int        `gorm:"column:product_id" json:"product_id"`
+		VariantID  *int       `gorm:"column:variant_id" json:"variant_id"`
+		Lang       string     `gorm:"column:lang" json:"-"`
+		NotifiedAt *time.Time `gorm:"column:notified_at" json:"notified_at"`
+
+		CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	}
+
+	CreateStockSubscriptionRequest struct {
+		CustomerID int    `json:"-"`
+		ProductID  int    `json:"-"`
+		VariantID  *int   `json:"variant_id" validate:"omitempty,number"`
+		Lang       string `json:"-"`
+	}
+
+	// BackInStockNotice is a subscription to notify, with what the message needs.
+	BackInStockNotice struct {
+		SubscriptionID int    `gorm:"column:subscription_id"`
+		Email          string `gorm:"column:email"`
+		Lang           string `gorm:"column:lang"`
+		ProductName    string `gorm:"column:product_name"`
+		VariantSKU     string `gorm:"column:variant_sku"`
+	}
+)
+
+func (StockSubscription) TableName() string {
+	return "stock_subscription"
+}
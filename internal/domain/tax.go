@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+// Pricing modes, whether the prices include the tax or the tax is added on top.
+const (
+	TaxInclusive = "inclusive"
+	TaxExclusive = "exclusive"
+)
+
+type (
+	// TaxRate is a tax of Rate percent. It applies to the products of a category, to
+	// the orders shipped to a region, or to both, and to every line without either.
+	// The most specific rate of a line wins.
+	TaxRate struct {
+		ID         int     `gorm:"column:id" json:"id"`
+		Name       string  `gorm:"column:name" json:"name"`
+		Rate       float64 `gorm:"column:rate" json:"rate"`
+		CategoryID *int    `gorm:"column:category_id" json:"category_id"`
+		Region     *string `gorm:"column:region" json:"region"`
+		IsActive   bool    `gorm:"column:is_active" json:"is_active"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	CreateTaxRateRequest struct {
+		Name       string  `json:"name" validate:"required,max=100"`
+		Rate       float64 `json:"rate" validate:"min=0,max=100"`
+		CategoryID *int    `json:"category_id" validate:"omitempty,min=1"`
+		Region     *string `json:"region" validate:"omitempty,max=50"`
+	}
+
+	UpdateTaxRateRequest struct {
+		ID       int      `json:"-"`
+		Name     *string  `json:"name" validate:"omitempty,max=100"`
+		Rate     *float64 `json:"rate" validate:"omitempty,min=0,max=100"`
+		IsActive *bool    `json:"is_active"`
+	}
+
+	GetTaxRateListRequest struct {
+		Page  int `json:"-"`
+		Limit int `json:"-"`
+	}
+
+	// TaxLine is an order line as taxes see it, Amount is the line total.
+	TaxLine struct {
+		ProductID  int         `gorm:"column:product_id"`
+		CategoryID int         `gorm:"column:category_id"`
+		Amount     money.Money `gorm:"-"`
+	}
+
+	// LineTax is the tax of a line. Discount is its share of the order discounts,
+	// Taxable the amount the tax is computed on, without the tax.
+	LineTax struct {
+		TaxRateID *int        `json:"tax_rate_id"`
+		Rate      float64     `json:"rate"`
+		Discount  money.Money `json:"discount"`
+		Taxable   money.Money `json:"taxable"`
+		Tax       money.Money `json:"tax"`
+	}
+
+	// OrderTax sums the tax of an order by tax rate.
+	OrderTax struct {
+		OrderID   int         `gorm:"column:order_id" json:"-"`
+		TaxRateID int         `gorm:"column:tax_rate_id" json:"tax_rate_id"`
+		Name      string      `gorm:"column:name" json:"name"`
+		Rate      float64     `gorm:"column:rate" json:"rate"`
+		Taxable   money.Money `gorm:"column:taxable_amount" json:"taxable_amount"`
+		Tax       money.Money `gorm:"column:tax_amount" json:"tax_amount"`
+
+		CreatedAt time.Time `gorm:"column:created_at" json:"-"`
+	}
+
+	TaxResult struct {
+		Mode  string      `json:"mode"`
+		Lines []LineTax   `json:"lines"`
+		Taxes []OrderTax  `json:"taxes"`
+		Total money.Money `json:"total"`
+	}
+)
+
+func (TaxRate) TableName() string {
+	return "tax_rate"
+}
+
+func (OrderTax) TableName() string {
+	return "order_tax"
+}
+
+// Specificity ranks how closely the rate targets a line of category shipped to
+// region, -1 when it does not apply. A category beats a region.
+func (r TaxRate) Specificity(categoryID int, region string) int {
+	score := 0
+	if r.CategoryID != nil {
+		if *r.CategoryID != categoryID {
+			return -1
+		}
+		score += 2
+	}
+	if r.Region != nil {
+		if *r.Region != region {
+			return -1
+		}
+		score++
+	}
+	return score
+}
@@ -0,0 +1,111 @@
+package domain
+
+import "time"
+
+type (
+	Warehouse struct {
+		ID       int    `gorm:"column:id" json:"id"`
+		Code     string `gorm:"column:code" json:"code"`
+		Name     string `gorm:"column:name" json:"name"`
+		Address  string `gorm:"column:address" json:"address"`
+		Priority int    `gorm:"column:priority" json:"priority"`
+		IsActive bool   `gorm:"column:is_active" json:"is_active"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	CreateWarehouseRequest struct {
+		Code     string `json:"code" validate:"required,max=20"`
+		Name     string `json:"name" validate:"required,max=50"`
+		Address  string `json:"address" validate:"max=255"`
+		Priority int    `json:"priority" validate:"min=0"`
+	}
+
+	UpdateWarehouseRequest struct {
+		ID       int     `json:"-"`
+		Name     *string `json:"name" validate:"omitempty,max=50"`
+		Address  *string `json:"address" validate:"omitempty,max=255"`
+		Priority *int    `json:"priority" validate:"omitempty,min=0"`
+		IsActive *bool   `json:"is_active"`
+	}
+
+	// WarehouseStock is the stock of a product, or of one of its variants, in a
+	// warehouse. The stock of the product and the variant is the sum over warehouses.
+	WarehouseStock struct {
+		WarehouseID   int        `gorm:"column:warehouse_id" json:"warehouse_id"`
+		ProductID     int        `gorm:"column:product_id" json:"product_id"`
+		VariantID     *int       `gorm:"column:variant_id" json:"variant_id"`
+		Stock         int        `gorm:"column:stock" json:"stock"`
+		ReservedStock int        `gorm:"column:reserved_stock" json:"reserved_stock"`
+		Priority      int        `gorm:"column:priority;->" json:"-"`
+		WarehouseCode string     `gorm:"column:warehouse_code;->" json:"-"`
+		UpdatedAt     *time.Time `gorm:"column:updated_at" json:"updated_at"`
+	}
+
+	WarehouseAvailability struct {
+		WarehouseID   int    `gorm:"column:warehouse_id" json:"warehouse_id"`
+		WarehouseCode string `gorm:"column:warehouse_code" json:"warehouse_code"`
+		WarehouseName string `gorm:"column:warehouse_name" json:"warehouse_name"`
+		VariantID     *int   `gorm:"column:variant_id" json:"variant_id"`
+		Available     int    `gorm:"column:available" json:"available"`
+	}
+
+	// AllocationLine is a quantity of a product, or of a variant, to fulfill.
+	AllocationLine struct {
+		ProductID int
+		VariantID *int
+		Quantity  int
+	}
+
+	// Allocation is the quantity of a line a warehouse fulfills.
+	Allocation struct {
+		WarehouseID int
+		ProductID   int
+		VariantID   *int
+		Quantity    int
+	}
+
+	// OrderItemAllocation is the quantity of an order line a warehouse fulfills.
+	OrderItemAllocation struct {
+		OrderID     int  `gorm:"column:order_id" json:"order_id"`
+		ProductID   int  `gorm:"column:product_id" json:"product_id"`
+		VariantID   *int `gorm:"column:variant_id" json:"variant_id"`
+		WarehouseID int  `gorm:"column:warehouse_id" json:"warehouse_id"`
+		Quantity    int  `gorm:"column:quantity" json:"quantity"`
+
+		CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	}
+)
+
+func (Warehouse) TableName() string {
+	return "warehouse"
+}
+
+func (WarehouseStock) TableName() string {
+	return "warehouse_stock"
+}
+
+func (OrderItemAllocation) TableName() string {
+	return "order_item_allocation"
+}
+
+// AvailableStock is the stock of the warehouse that can still be reserved.
+func (w WarehouseStock) AvailableStock() int {
+	return w.Stock - w.ReservedStock
+}
+
+// SameItem reports whether the stock is the one of the product or variant of the line.
+func (l AllocationLine) SameItem(stock WarehouseStock) bool {
+	if l.ProductID != stock.ProductID {
+		return false
+	}
+	if l.VariantID == nil || stock.VariantID == nil {
+		return l.VariantID == nil && stock.VariantID == nil
+	}
+	return *l.VariantID == *stock.VariantID
+}
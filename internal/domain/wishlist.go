@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"github.com/online-store/pkg/money"
+	"time"
+)
+
+type (
+	// Wishlist is a product a customer saved for later. AddedPrice is the price of
+	// the product when it was saved, in the base currency.
+	Wishlist struct {
+		ID         int         `gorm:"column:id" json:"id"`
+		CustomerID int         `gorm:"column:customer_id" json:"customer_id"`
+		ProductID  int         `gorm:"column:product_id" json:"product_id"`
+		VariantID  *int        `gorm:"column:variant_id" json:"variant_id"`
+		AddedPrice money.Money `gorm:"column:added_price" json:"added_price"`
+
+		CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+		CreatedBy string     `gorm:"column:created_by" json:"created_by"`
+		UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+		UpdatedBy *string    `gorm:"column:updated_by" json:"updated_by"`
+		DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+		DeletedBy *string    `gorm:"column:deleted_by" json:"deleted_by"`
+	}
+
+	// WishlistProduct is a wishlist entry with the current price and stock of its
+	// product. PriceDropped tells the price is lower than when it was saved.
+	WishlistProduct struct {
+		WishlistID     int         `gorm:"column:wishlist_id" json:"wishlist_id"`
+		ProductID      int         `gorm:"column:product_id" json:"product_id"`
+		ProductName    string      `gorm:"column:product_name" json:"product_name"`
+		VariantID      *int        `gorm:"column:variant_id" json:"variant_id"`
+		SKU            *string     `gorm:"column:sku" json:"sku"`
+		AddedPrice     money.Money `gorm:"column:added_price" json:"added_price"`
+		CurrentPrice   money.Money `gorm:"column:current_price" json:"current_price"`
+		PriceDropped   bool        `gorm:"column:price_dropped" json:"price_dropped"`
+		AvailableStock int         `gorm:"column:available_stock" json:"available_stock"`
+		CreatedAt      time.Time   `gorm:"column:created_at" json:"created_at"`
+	}
+
+	AddWishlistRequest struct {
+		CustomerID int  `json:"-"`
+		ProductID  int  `json:"product_id" validate:"required,number"`
+		VariantID  *int `json:"variant_id" validate:"omitempty,number"`
+	}
+
+	GetWishlistRequest struct {
+		Page       int
+		Limit      int
+		CustomerID int
+		Currency   string
+	}
+
+	// MoveWishlistToCartRequest moves a wishlist entry to the cart, one of it unless
+	// Quantity is given.
+	MoveWishlistToCartRequest struct {
+		ID         int `json:"-"`
+		CustomerID int `json:"-"`
+		Quantity   int `json:"quantity" validate:"omitempty,min=1,max=1000"`
+	}
+)
+
+func (Wishlist) TableName() string {
+	return "wishlist"
+}
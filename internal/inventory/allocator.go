@@ -0,0 +1,10 @@
+package inventory
+
+import "github.com/online-store/internal/domain"
+
+// Allocator picks the warehouses fulfilling the lines of an order. stocks holds
+// the stock of every line in the active warehouses, in order of preference.
+// domain.ErrInsufficientStock is returned when the lines can't be fulfilled.
+type Allocator interface {
+	Allocate(lines []domain.AllocationLine, stocks []domain.WarehouseStock) ([]domain.Allocation, error)
+}
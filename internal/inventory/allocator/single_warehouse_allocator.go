@@ -0,0 +1,102 @@
+package allocator
+
+import (
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/inventory"
+)
+
+type singleWarehouseAllocator struct{}
+
+// NewSingleWarehouseAllocator ships the whole order from the most preferred
+// warehouse holding every line. When none does the order is split, each time
+// taking the warehouse able to ship the most remaining units, so it leaves from
+// as few warehouses as possible.
+func NewSingleWarehouseAllocator() inventory.Allocator {
+	return &singleWarehouseAllocator{}
+}
+
+func (a singleWarehouseAllocator) Allocate(lines []domain.AllocationLine, stocks []domain.WarehouseStock) ([]domain.Allocation, error) {
+	var warehouseIDs []int
+	available := make(map[int][]domain.WarehouseStock)
+	for _, v := range stocks {
+		if _, ok := available[v.WarehouseID]; !ok {
+			warehouseIDs = append(warehouseIDs, v.WarehouseID)
+		}
+		available[v.WarehouseID] = append(available[v.WarehouseID], v)
+	}
+
+	remaining := make([]int, len(lines))
+	for i, v := range lines {
+		remaining[i] = v.Quantity
+	}
+
+	var result []domain.Allocation
+	for {
+		// the warehouses keep the order of preference, so ties go to the preferred one
+		best, bestUnits := 0, 0
+		for _, warehouseID := range warehouseIDs {
+			if units := coverage(lines, remaining, available[warehouseID]); units > bestUnits {
+				best, bestUnits = warehouseID, units
+			}
+		}
+		if bestUnits == 0 {
+			break
+		}
+
+		for i, line := range lines {
+			if remaining[i] == 0 {
+				continue
+			}
+			for j, stock := range available[best] {
+				if remaining[i] == 0 {
+					break
+				}
+				if !line.SameItem(stock) || stock.AvailableStock() <= 0 {
+					continue
+				}
+
+				quantity := min(remaining[i], stock.AvailableStock())
+				available[best][j].ReservedStock += quantity
+				remaining[i] -= quantity
+				result = append(result, domain.Allocation{
+					WarehouseID: best,
+					ProductID:   line.ProductID,
+					VariantID:   line.VariantID,
+					Quantity:    quantity,
+				})
+			}
+		}
+	}
+
+	for _, v := range remaining {
+		if v > 0 {
+			return nil, domain.ErrInsufficientStock
+		}
+	}
+
+	return result, nil
+}
+
+// coverage counts the remaining units of the lines the warehouse can ship.
+func coverage(lines []domain.AllocationLine, remaining []int, stocks []domain.WarehouseStock) int {
+	units := 0
+	used := make([]int, len(stocks))
+	for i, line := range lines {
+		need := remaining[i]
+		for j, stock := range stocks {
+			if need == 0 {
+				break
+			}
+			if !line.SameItem(stock) {
+				continue
+			}
+			quantity := min(need, stock.AvailableStock()-used[j])
+			if quantity > 0 {
+				used[j] += quantity
+				need -= quantity
+				units += quantity
+			}
+		}
+	}
+	return units
+}
@@ -0,0 +1,315 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/inventory"
+	"github.com/online-store/pkg"
+	paging "github.com/online-store/pkg/paging"
+	"github.com/online-store/pkg/response"
+	"github.com/online-store/pkg/validator"
+	"gorm.io/gorm"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/beego/i18n"
+)
+
+type InventoryHandler struct {
+	beego.Controller
+	inventory.UseCase
+	i18n.Locale
+	response.APIResponseInterface
+	time.Duration
+}
+
+func NewInventoryHandler(useCase inventory.UseCase, executionTimeout time.Duration, apiResponse response.APIResponseInterface) {
+	handler := &InventoryHandler{
+		UseCase:              useCase,
+		APIResponseInterface: apiResponse,
+		Duration:             executionTimeout,
+	}
+
+	beego.Router("/admin/v1/inventory/adjustments", handler, "post:AdjustStock")
+	beego.Router("/admin/v1/inventory/products/:id/movements", handler, "get:GetMovementReport")
+	beego.Router("/admin/v1/inventory/discrepancies", handler, "get:GetDiscrepancies")
+	beego.Router("/admin/v1/inventory/reconcile", handler, "post:Reconcile")
+	beego.Router("/admin/v1/warehouses", handler, "post:CreateWarehouse;get:GetWarehouses")
+	beego.Router("/admin/v1/warehouses/:id", handler, "put:UpdateWarehouse")
+}
+
+func (h *InventoryHandler) Prepare() {
+	// check user access when needed
+	h.Lang = pkg.GetLangVersion(h.Ctx)
+	requestTime := time.Now().UnixNano() / int64(time.Millisecond)
+	h.Ctx.Input.SetData("request_time", requestTime)
+}
+
+func (h *InventoryHandler) AdjustStock() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	var request domain.InventoryAdjustmentRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	res, err := h.UseCase.AdjustStock(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InsufficientStockErrorCode, domain.ErrorCodeText(domain.InsufficientStockErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.insertedSuccess"), res, nil)
+}
+
+func (h *InventoryHandler) GetMovementReport() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	productID, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	request, err := parseMovementQuery(h.Ctx.Input.Query("variant_id"), h.Ctx.Input.Query("warehouse_id"), h.Ctx.Input.Query("from"), h.Ctx.Input.Query("to"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlQueryParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlQueryParamErrorCode, h.Locale.Lang), domain.ErrInvalidUrlQueryParam)
+		return
+	}
+
+	limit, page, err := paging.PageAndPageSizeValidation(h.Ctx.Input.Query("limit"), h.Ctx.Input.Query("page"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlQueryParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlQueryParamErrorCode, h.Locale.Lang), domain.ErrInvalidUrlQueryParam)
+		return
+	}
+
+	request.ProductID = productID
+	request.Limit = limit
+	request.Page = page
+
+	res, err := h.UseCase.GetMovementReport(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *InventoryHandler) GetDiscrepancies() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	res, err := h.UseCase.GetDiscrepancies(h.Ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *InventoryHandler) Reconcile() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	res, err := h.UseCase.Reconcile(h.Ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.updatedSuccess"), res, nil)
+}
+
+func (h *InventoryHandler) CreateWarehouse() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	var request domain.CreateWarehouseRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	res, err := h.UseCase.CreateWarehouse(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUniqueConstraint) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.DataAlreadyExist, domain.ErrorCodeText(domain.DataAlreadyExist, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.insertedSuccess"), res, nil)
+}
+
+func (h *InventoryHandler) GetWarehouses() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	res, err := h.UseCase.GetWarehouses(h.Ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *InventoryHandler) UpdateWarehouse() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	warehouseID, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	var request domain.UpdateWarehouseRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	request.ID = warehouseID
+
+	res, err := h.UseCase.UpdateWarehouse(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.updatedSuccess"), res, nil)
+}
+
+// parseMovementQuery reads the optional variant, warehouse and date range, both
+// dates are inclusive days in the YYYY-MM-DD format.
+func parseMovementQuery(variantQuery, warehouseQuery, fromQuery, toQuery string) (request domain.GetInventoryMovementRequest, err error) {
+	if variantQuery != "" {
+		variantID, err := strconv.Atoi(variantQuery)
+		if err != nil {
+			return request, domain.ErrInvalidUrlQueryParam
+		}
+		request.VariantID = &variantID
+	}
+
+	if warehouseQuery != "" {
+		warehouseID, err := strconv.Atoi(warehouseQuery)
+		if err != nil {
+			return request, domain.ErrInvalidUrlQueryParam
+		}
+		request.WarehouseID = &warehouseID
+	}
+
+	if fromQuery != "" {
+		from, err := time.ParseInLocation("2006-01-02", fromQuery, time.Local)
+		if err != nil {
+			return request, domain.ErrInvalidUrlQueryParam
+		}
+		request.From = &from
+	}
+
+	if toQuery != "" {
+		to, err := time.ParseInLocation("2006-01-02", toQuery, time.Local)
+		if err != nil {
+			return request, domain.ErrInvalidUrlQueryParam
+		}
+		to = to.AddDate(0, 0, 1)
+		request.To = &to
+	}
+
+	if request.From != nil && request.To != nil && !request.From.Before(*request.To) {
+		return request, domain.ErrInvalidUrlQueryParam
+	}
+
+	return request, nil
+}
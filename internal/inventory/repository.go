@@ -0,0 +1,30 @@
+package inventory
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	StockExists(ctx context.Context, productID int, variantID *int) (bool, error)
+	AddWarehouseStock(ctx context.Context, tx *gorm.DB, warehouseID, productID int, variantID *int, quantity int) (int64, error)
+	GetWarehouseStock(ctx context.Context, tx *gorm.DB, warehouseID, productID int, variantID *int) (domain.WarehouseStock, error)
+	LockWarehouseStocks(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.WarehouseStock, error)
+	ReserveWarehouseStock(ctx context.Context, tx *gorm.DB, data domain.Allocation) (int64, error)
+	GetDefaultWarehouseID(ctx context.Context, tx *gorm.DB) (int, error)
+	GetWarehouses(ctx context.Context) ([]domain.Warehouse, error)
+	GetWarehouseByID(ctx context.Context, warehouseID int) (domain.Warehouse, error)
+	InsertWarehouse(ctx context.Context, data domain.Warehouse) (*domain.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouseID int, data map[string]interface{}) (int64, error)
+	InsertMovements(ctx context.Context, tx *gorm.DB, data []domain.InventoryMovement) error
+	InsertMovement(ctx context.Context, tx *gorm.DB, data domain.InventoryMovement) (*domain.InventoryMovement, error)
+	FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+	GetMovementSummary(ctx context.Context, query string, args ...interface{}) (domain.InventoryMovementSummary, error)
+	GetDiscrepancies(ctx context.Context) ([]domain.InventoryDiscrepancy, error)
+	ReconcileProductStock(ctx context.Context, tx *gorm.DB, productID int) error
+	ReconcileVariantStock(ctx context.Context, tx *gorm.DB, variantID int) error
+	ReconcileWarehouseStock(ctx context.Context, tx *gorm.DB, warehouseID, productID int, variantID *int) error
+}
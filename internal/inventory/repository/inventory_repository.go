@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/inventory"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type InventoryRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryRepository(db *gorm.DB) inventory.Repository {
+	return &InventoryRepository{db: db}
+}
+
+func (r *InventoryRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *InventoryRepository) StockExists(ctx context.Context, productID int, variantID *int) (bool, error) {
+	var count int64
+
+	db := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+	if variantID != nil {
+		db = db.Table("product_variant").Where("id = ? AND product_id = ? AND deleted_at IS NULL", *variantID, productID)
+	} else {
+		db = db.Table("product").Where("id = ? AND deleted_at IS NULL", productID)
+	}
+
+	err := db.Count(&count).Error
+	return count > 0, err
+}
+
+// AddWarehouseStock moves the stock of a product or variant in a warehouse along with
+// its total stock. The stock can't drop below what is reserved, nothing is updated then.
+func (r *InventoryRepository) AddWarehouseStock(ctx context.Context, tx *gorm.DB, warehouseID, productID int, variantID *int, quantity int) (int64, error) {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+
+	var result *gorm.DB
+	if quantity > 0 {
+		result = db.Exec(`INSERT INTO warehouse_stock (warehouse_id, product_id, variant_id, stock, reserved_stock, updated_at) 
+				VALUES (?, ?, ?, ?, 0, now()) 
+				ON CONFLICT (warehouse_id, product_id, (COALESCE(variant_id, 0))) DO UPDATE SET stock = warehouse_stock.stock + EXCLUDED.stock, updated_at = now()`,
+			warehouseID, productID, variantID, quantity)
+	} else {
+		result = db.Exec(`UPDATE warehouse_stock 
+				SET stock = stock + ?, updated_at = now() 
+				WHERE warehouse_id = ? AND product_id = ? AND variant_id IS NOT DISTINCT FROM ? AND stock + ? >= reserved_stock`,
+			quantity, warehouseID, productID, variantID, quantity)
+	}
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.RowsAffected, result.Error
+	}
+
+	if variantID != nil {
+		result = db.Exec(`UPDATE product_variant 
+				SET stock = stock + ?, updated_at = now(), updated_by = 'System' 
+				WHERE id = ? AND product_id = ? AND deleted_at IS NULL`, quantity, *variantID, productID)
+	} else {
+		result = db.Exec(`UPDATE product 
+				SET stock = stock + ?, updated_at = now(), updated_by = 'System' 
+				WHERE id = ? AND deleted_at IS NULL`, quantity, productID)
+	}
+	return result.RowsAffected, result.Error
+}
+
+// GetWarehouseStock locks the stock of a product or variant in a warehouse until the
+// end of the transaction. A zero stock is returned when the warehouse never held it.
+func (r *InventoryRepository) GetWarehouseStock(ctx context.Context, tx *gorm.DB, warehouseID, productID int, variantID *int) (domain.WarehouseStock, error) {
+	data := domain.WarehouseStock{WarehouseID: warehouseID, ProductID: productID, VariantID: variantID}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT warehouse_id, product_id, variant_id, stock, reserved_stock, updated_at 
+				FROM warehouse_stock 
+				WHERE warehouse_id = ? AND product_id = ? AND variant_id IS NOT DISTINCT FROM ? 
+				FOR UPDATE`, warehouseID, productID, variantID).Scan(&data).Error
+	return data, err
+}
+
+// LockWarehouseStocks locks the stock of the products in the active warehouses, in
+// order of preference of the warehouses.
+func (r *InventoryRepository) LockWarehouseStocks(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.WarehouseStock, error) {
+	var data []domain.WarehouseStock
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT 
+					ws.warehouse_id, ws.product_id, ws.variant_id, ws.stock, ws.reserved_stock, ws.updated_at, w.priority 
+				FROM warehouse_stock ws 
+				JOIN warehouse w ON w.id = ws.warehouse_id 
+				WHERE w.is_active AND w.deleted_at IS NULL AND ws.product_id IN ? 
+				ORDER BY w.priority, w.id, ws.product_id, ws.variant_id 
+				FOR UPDATE OF ws`, productIDs).Scan(&data).Error
+	return data, err
+}
+
+func (r *InventoryRepository) ReserveWarehouseStock(ctx context.Context, tx *gorm.DB, data domain.Allocation) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Exec(`UPDATE warehouse_stock 
+				SET reserved_stock = reserved_stock + ?, updated_at = now() 
+				WHERE warehouse_id = ? AND product_id = ? AND variant_id IS NOT DISTINCT FROM ? AND stock - reserved_stock >= ?`,
+		data.Quantity, data.WarehouseID, data.ProductID, data.VariantID, data.Quantity)
+	return result.RowsAffected, result.Error
+}
+
+// GetDefaultWarehouseID returns the most preferred active warehouse, which receives
+// the stock when no warehouse is given.
+func (r *InventoryRepository) GetDefaultWarehouseID(ctx context.Context, tx *gorm.DB) (int, error) {
+	var data domain.Warehouse
+
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("is_active AND deleted_at IS NULL").Order("priority, id").Limit(1).Find(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return data.ID, result.Error
+}
+
+func (r *InventoryRepository) GetWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	var data []domain.Warehouse
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("deleted_at IS NULL").Order("priority, id").Find(&data).Error
+	return data, err
+}
+
+func (r *InventoryRepository) GetWarehouseByID(ctx context.Context, warehouseID int) (domain.Warehouse, error) {
+	var data domain.Warehouse
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", warehouseID).First(&data).Error
+	return data, err
+}
+
+func (r *InventoryRepository) InsertWarehouse(ctx context.Context, data domain.Warehouse) (*domain.Warehouse, error) {
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *InventoryRepository) UpdateWarehouse(ctx context.Context, warehouseID int, data map[string]interface{}) (int64, error) {
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("warehouse").Where("id = ? AND deleted_at IS NULL", warehouseID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
+
+func (r *InventoryRepository) InsertMovements(ctx context.Context, tx *gorm.DB, data []domain.InventoryMovement) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("ID").CreateInBatches(&data, 100).Error
+}
+
+func (r *InventoryRepository) InsertMovement(ctx context.Context, tx *gorm.DB, data domain.InventoryMovement) (*domain.InventoryMovement, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("ID").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *InventoryRepository) FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	paginate := database.NewPaginator(r.db, page, pageSize, model).Raw(query, args, countQuery, args)
+
+	if err := paginate.FindWithOrderBy(ctx, orderBy).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
+
+func (r *InventoryRepository) GetMovementSummary(ctx context.Context, query string, args ...interface{}) (domain.InventoryMovementSummary, error) {
+	var data domain.InventoryMovementSummary
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(query, args...).Scan(&data).Error
+	return data, err
+}
+
+// GetDiscrepancies lists the products, variants and warehouse stocks whose stock
+// differs from the sum of their movements.
+func (r *InventoryRepository) GetDiscrepancies(ctx context.Context) ([]domain.InventoryDiscrepancy, error) {
+	var data []domain.InventoryDiscrepancy
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT 
+					p.id AS product_id, NULL::int8 AS variant_id, NULL::int8 AS warehouse_id, p.stock, COALESCE(m.quantity, 0) AS ledger_stock, p.reserved_stock, COALESCE(m.reserved_quantity, 0) AS ledger_reserved 
+				FROM product p 
+				LEFT JOIN (SELECT product_id, SUM(quantity) AS quantity, SUM(reserved_quantity) AS reserved_quantity FROM inventory_movement WHERE variant_id IS NULL GROUP BY product_id) m ON m.product_id = p.id 
+				WHERE p.stock <> COALESCE(m.quantity, 0) OR p.reserved_stock <> COALESCE(m.reserved_quantity, 0) 
+				UNION ALL 
+				SELECT 
+					v.product_id, v.id AS variant_id, NULL::int8 AS warehouse_id, v.stock, COALESCE(m.quantity, 0) AS ledger_stock, v.reserved_stock, COALESCE(m.reserved_quantity, 0) AS ledger_reserved 
+				FROM product_variant v 
+				LEFT JOIN (SELECT variant_id, SUM(quantity) AS quantity, SUM(reserved_quantity) AS reserved_quantity FROM inventory_movement WHERE variant_id IS NOT NULL GROUP BY variant_id) m ON m.variant_id = v.id 
+				WHERE v.stock <> COALESCE(m.quantity, 0) OR v.reserved_stock <> COALESCE(m.reserved_quantity, 0) 
+				UNION ALL 
+				SELECT 
+					ws.product_id, ws.variant_id, ws.warehouse_id, ws.stock, COALESCE(m.quantity, 0) AS ledger_stock, ws.reserved_stock, COALESCE(m.reserved_quantity, 0) AS ledger_reserved 
+				FROM warehouse_stock ws 
+				LEFT JOIN (SELECT warehouse_id, product_id, variant_id, SUM(quantity) AS quantity, SUM(reserved_quantity) AS reserved_quantity FROM inventory_movement WHERE warehouse_id IS NOT NULL GROUP BY warehouse_id, product_id, variant_id) m 
+					ON m.warehouse_id = ws.warehouse_id AND m.product_id = ws.product_id AND m.variant_id IS NOT DISTINCT FROM ws.variant_id 
+				WHERE ws.stock <> COALESCE(m.quantity, 0) OR ws.reserved_stock <> COALESCE(m.reserved_quantity, 0) 
+				ORDER BY product_id, variant_id NULLS FIRST, warehouse_id NULLS FIRST`).Scan(&data).Error
+	return data, err
+}
+
+// ReconcileProductStock resets the stock of a product to the sum of its movements. The
+// row is locked first so the sum, read by the next statement, includes every movement
+// committed along with a stock change.
+func (r *InventoryRepository) ReconcileProductStock(ctx context.Context, tx *gorm.DB, productID int) error {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+	if err := db.Exec(`SELECT id FROM product WHERE id = ? FOR UPDATE`, productID).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`UPDATE product p 
+				SET stock = m.quantity, reserved_stock = m.reserved_quantity, updated_at = now(), updated_by = 'System' 
+				FROM (SELECT COALESCE(SUM(quantity), 0) AS quantity, COALESCE(SUM(reserved_quantity), 0) AS reserved_quantity FROM inventory_movement WHERE product_id = ? AND variant_id IS NULL) m 
+				WHERE p.id = ?`, productID, productID).Error
+}
+
+func (r *InventoryRepository) ReconcileVariantStock(ctx context.Context, tx *gorm.DB, variantID int) error {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+	if err := db.Exec(`SELECT id FROM product_variant WHERE id = ? FOR UPDATE`, variantID).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`UPDATE product_variant v 
+				SET stock = m.quantity, reserved_stock = m.reserved_quantity, updated_at = now(), updated_by = 'System' 
+				FROM (SELECT COALESCE(SUM(quantity), 0) AS quantity, COALESCE(SUM(reserved_quantity), 0) AS reserved_quantity FROM inventory_movement WHERE variant_id = ?) m 
+				WHERE v.id = ?`, variantID, variantID).Error
+}
+
+func (r *InventoryRepository) ReconcileWarehouseStock(ctx context.Context, tx *gorm.DB, warehouseID, productID int, variantID *int) error {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+	if err := db.Exec(`SELECT warehouse_id FROM warehouse_stock WHERE warehouse_id = ? AND product_id = ? AND variant_id IS NOT DISTINCT FROM ? FOR UPDATE`, warehouseID, productID, variantID).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`UPDATE warehouse_stock ws 
+				SET stock = m.quantity, reserved_stock = m.reserved_quantity, updated_at = now() 
+				FROM (SELECT COALESCE(SUM(quantity), 0) AS quantity, COALESCE(SUM(reserved_quantity), 0) AS reserved_quantity FROM inventory_movement WHERE warehouse_id = ? AND product_id = ? AND variant_id IS NOT DISTINCT FROM ?) m 
+				WHERE ws.warehouse_id = ? AND ws.product_id = ? AND ws.variant_id IS NOT DISTINCT FROM ?`, warehouseID, productID, variantID, warehouseID, productID, variantID).Error
+}
@@ -0,0 +1,16 @@
+package inventory
+
+import (
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+)
+
+type UseCase interface {
+	AdjustStock(beegoCtx *beegoContext.Context, req domain.InventoryAdjustmentRequest) (*domain.InventoryMovement, error)
+	GetMovementReport(beegoCtx *beegoContext.Context, req domain.GetInventoryMovementRequest) (*domain.InventoryMovementReport, error)
+	GetDiscrepancies(beegoCtx *beegoContext.Context) ([]domain.InventoryDiscrepancy, error)
+	Reconcile(beegoCtx *beegoContext.Context) ([]domain.InventoryDiscrepancy, error)
+	CreateWarehouse(beegoCtx *beegoContext.Context, req domain.CreateWarehouseRequest) (*domain.Warehouse, error)
+	GetWarehouses(beegoCtx *beegoContext.Context) ([]domain.Warehouse, error)
+	UpdateWarehouse(beegoCtx *beegoContext.Context, req domain.UpdateWarehouseRequest) (*domain.Warehouse, error)
+}
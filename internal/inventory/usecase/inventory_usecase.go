@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/inventory"
+	"github.com/online-store/internal/stockalert"
+	"github.com/online-store/pkg/cache"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type InventoryUseCase struct {
+	inventoryRepo inventory.Repository
+	stockAlertUC  stockalert.UseCase
+	cacheRepo     cache.RedisRepository
+	zapLogger     zaplogger.Logger
+}
+
+func NewInventoryUseCase(inventoryRepo inventory.Repository, stockAlertUC stockalert.UseCase, cacheRepo cache.RedisRepository, zapLogger zaplogger.Logger) inventory.UseCase {
+	return &InventoryUseCase{
+		inventoryRepo: inventoryRepo,
+		stockAlertUC:  stockAlertUC,
+		cacheRepo:     cacheRepo,
+		zapLogger:     zapLogger,
+	}
+}
+
+// AdjustStock moves the stock on hand of a warehouse by the requested quantity and
+// records why. The stock can't drop below what is reserved for unpaid orders.
+func (u *InventoryUseCase) AdjustStock(beegoCtx *beegoContext.Context, req domain.InventoryAdjustmentRequest) (*domain.InventoryMovement, error) {
+	var (
+		data *domain.InventoryMovement
+		err  error
+	)
+
+	//start transaction
+	errs := u.inventoryRepo.DB().Transaction(func(tx *gorm.DB) error {
+		if _, err := u.inventoryRepo.GetWarehouseByID(beegoCtx.Request.Context(), req.WarehouseID); err != nil {
+			return err
+		}
+
+		var affected int64
+		affected, err = u.inventoryRepo.AddWarehouseStock(beegoCtx.Request.Context(), tx, req.WarehouseID, req.ProductID, req.VariantID, req.Quantity)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeForeignKeyConstraint {
+				return gorm.ErrRecordNotFound
+			}
+			return err
+		}
+
+		if affected == 0 {
+			exists, err := u.inventoryRepo.StockExists(beegoCtx.Request.Context(), req.ProductID, req.VariantID)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return gorm.ErrRecordNotFound
+			}
+			return domain.ErrInsufficientStock
+		}
+
+		data, err = u.inventoryRepo.InsertMovement(beegoCtx.Request.Context(), tx, domain.InventoryMovement{
+			ProductID:   req.ProductID,
+			VariantID:   req.VariantID,
+			WarehouseID: &req.WarehouseID,
+			Quantity:    req.Quantity,
+			Reason:      domain.InventoryReasonManualAdjustment,
+			Note:        &req.Note,
+			Actor:       domain.InventoryActorAdmin,
+			CreatedAt:   time.Now(),
+		})
+		return err
+	})
+
+	if errs != nil {
+		if !errors.Is(errs, gorm.ErrRecordNotFound) && !errors.Is(errs, domain.ErrInsufficientStock) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		}
+		return nil, errs
+	}
+
+	u.stockAlertUC.CheckStockInBackground([]int{req.ProductID})
+
+	//delete existing cache
+	if err := u.cacheRepo.Deletes(beegoCtx.Request.Context(), []string{domain.ProductListKeyCache}); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+	}
+
+	return data, nil
+}
+
+func (u *InventoryUseCase) GetMovementReport(beegoCtx *beegoContext.Context, req domain.GetInventoryMovementRequest) (*domain.InventoryMovementReport, error) {
+	var entities []domain.InventoryMovement
+
+	filter := ` WHERE product_id = ?`
+	args := []interface{}{req.ProductID}
+	if req.VariantID != nil {
+		filter += ` AND variant_id = ?`
+		args = append(args, *req.VariantID)
+	}
+	if req.WarehouseID != nil {
+		filter += ` AND warehouse_id = ?`
+		args = append(args, *req.WarehouseID)
+	}
+
+	rangeFilter := filter
+	rangeArgs := append([]interface{}{}, args...)
+	if req.From != nil {
+		rangeFilter += ` AND created_at >= ?`
+		rangeArgs = append(rangeArgs, *req.From)
+	}
+	if req.To != nil {
+		rangeFilter += ` AND created_at < ?`
+		rangeArgs = append(rangeArgs, *req.To)
+	}
+
+	query := `SELECT id, product_id, variant_id, warehouse_id, quantity, reserved_quantity, reason, reference, note, actor, created_at FROM inventory_movement` + rangeFilter
+	countQuery := `SELECT COUNT(*) FROM inventory_movement` + rangeFilter
+
+	data, err := u.inventoryRepo.FetchWithFilterAndPagination(
+		beegoCtx.Request.Context(),
+		req.Page,
+		req.Limit,
+		query,
+		countQuery,
+		"ORDER BY created_at DESC, id DESC",
+		&entities,
+		rangeArgs...,
+	)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	// the opening stock sums everything before the range, the open ends of the
+	// range are replaced by bounds matching every movement
+	from, to := time.Time{}, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	if req.From != nil {
+		from = *req.From
+	}
+	if req.To != nil {
+		to = *req.To
+	}
+
+	summaryArgs := []interface{}{from, from, to, from, to, to}
+	summary, err := u.inventoryRepo.GetMovementSummary(beegoCtx.Request.Context(), `SELECT 
+					COALESCE(SUM(quantity) FILTER (WHERE created_at < ?), 0) AS opening_stock, 
+					COALESCE(SUM(quantity) FILTER (WHERE created_at >= ? AND created_at < ? AND quantity > 0), 0) AS stock_in, 
+					COALESCE(-SUM(quantity) FILTER (WHERE created_at >= ? AND created_at < ? AND quantity < 0), 0) AS stock_out, 
+					COALESCE(SUM(quantity) FILTER (WHERE created_at < ?), 0) AS closing_stock 
+				FROM inventory_movement`+filter, append(summaryArgs, args...)...)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return &domain.InventoryMovementReport{
+		Paginator: *data,
+		Summary:   summary,
+	}, nil
+}
+
+func (u *InventoryUseCase) GetDiscrepancies(beegoCtx *beegoContext.Context) ([]domain.InventoryDiscrepancy, error) {
+	data, err := u.inventoryRepo.GetDiscrepancies(beegoCtx.Request.Context())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Reconcile resets every stock that drifted from the ledger to the sum of its
+// movements, the ledger being the source of truth. It returns what was reset.
+func (u *InventoryUseCase) Reconcile(beegoCtx *beegoContext.Context) ([]domain.InventoryDiscrepancy, error) {
+	data, err := u.inventoryRepo.GetDiscrepancies(beegoCtx.Request.Context())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	//start transaction
+	errs := u.inventoryRepo.DB().Transaction(func(tx *gorm.DB) error {
+		for _, v := range data {
+			if v.WarehouseID != nil {
+				err = u.inventoryRepo.ReconcileWarehouseStock(beegoCtx.Request.Context(), tx, *v.WarehouseID, v.ProductID, v.VariantID)
+			} else if v.VariantID != nil {
+				err = u.inventoryRepo.ReconcileVariantStock(beegoCtx.Request.Context(), tx, *v.VariantID)
+			} else {
+				err = u.inventoryRepo.ReconcileProductStock(beegoCtx.Request.Context(), tx, v.ProductID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if errs != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		return nil, errs
+	}
+
+	//delete existing cache
+	if err := u.cacheRepo.Deletes(beegoCtx.Request.Context(), []string{domain.ProductListKeyCache}); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+	}
+
+	return data, nil
+}
+
+func (u *InventoryUseCase) CreateWarehouse(beegoCtx *beegoContext.Context, req domain.CreateWarehouseRequest) (*domain.Warehouse, error) {
+	data, err := u.inventoryRepo.InsertWarehouse(beegoCtx.Request.Context(), domain.Warehouse{
+		Code:      req.Code,
+		Name:      req.Name,
+		Address:   req.Address,
+		Priority:  req.Priority,
+		IsActive:  true,
+		CreatedAt: time.Now(),
+		CreatedBy: "System",
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeUniqueConstraint {
+			return nil, domain.ErrUniqueConstraint
+		}
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (u *InventoryUseCase) GetWarehouses(beegoCtx *beegoContext.Context) ([]domain.Warehouse, error) {
+	data, err := u.inventoryRepo.GetWarehouses(beegoCtx.Request.Context())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// UpdateWarehouse changes the given fields only. An inactive warehouse keeps its
+// stock but is no longer picked to fulfill orders.
+func (u *InventoryUseCase) UpdateWarehouse(beegoCtx *beegoContext.Context, req domain.UpdateWarehouseRequest) (*domain.Warehouse, error) {
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+		"updated_by": "System",
+	}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Address != nil {
+		updates["address"] = *req.Address
+	}
+	if req.Priority != nil {
+		updates["priority"] = *req.Priority
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	affected, err := u.inventoryRepo.UpdateWarehouse(beegoCtx.Request.Context(), req.ID, updates)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	data, err := u.inventoryRepo.GetWarehouseByID(beegoCtx.Request.Context(), req.ID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return &data, nil
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/invoice"
+	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/response"
+	"gorm.io/gorm"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/beego/i18n"
+)
+
+type InvoiceHandler struct {
+	beego.Controller
+	invoice.UseCase
+	i18n.Locale
+	response.APIResponseInterface
+	time.Duration
+}
+
+func NewInvoiceHandler(useCase invoice.UseCase, executionTimeout time.Duration, apiResponse response.APIResponseInterface) {
+	handler := &InvoiceHandler{
+		UseCase:              useCase,
+		APIResponseInterface: apiResponse,
+		Duration:             executionTimeout,
+	}
+
+	beego.Router("/admin/v1/orders/:order_id/invoice", handler, "get:GetInvoice")
+	beego.Router("/customer/v1/order/:order_id/invoice", handler, "get:GetCustomerInvoice")
+}
+
+func (h *InvoiceHandler) Prepare() {
+	// check user access when needed
+	h.Lang = pkg.GetLangVersion(h.Ctx)
+	requestTime := time.Now().UnixNano() / int64(time.Millisecond)
+	h.Ctx.Input.SetData("request_time", requestTime)
+}
+
+func (h *InvoiceHandler) GetInvoice() {
+	h.getInvoice(0)
+}
+
+func (h *InvoiceHandler) GetCustomerInvoice() {
+	h.getInvoice(h.Ctx.Input.GetData("userID").(int))
+}
+
+// getInvoice downloads the invoice of an order of the customer, of any order when
+// customerID is 0.
+func (h *InvoiceHandler) getInvoice(customerID int) {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	orderID, err := strconv.Atoi(h.Ctx.Input.Param(":order_id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	data, res, err := h.UseCase.GetInvoicePDF(h.Ctx, orderID, customerID, h.Lang)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrOrderNotPaid) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.OrderNotPaidErrorCode, domain.ErrorCodeText(domain.OrderNotPaidErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ctx.Output.Header("Content-Type", "application/pdf")
+	h.Ctx.Output.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, strings.ReplaceAll(data.Number, "/", "-")))
+	if err := h.Ctx.Output.Body(res); err != nil {
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+	}
+}
@@ -0,0 +1,19 @@
+package invoice
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	GetOrderByID(ctx context.Context, orderID int) (domain.Order, error)
+	GetOrderForUpdate(ctx context.Context, tx *gorm.DB, orderID int) (domain.Order, error)
+	GetInvoiceByOrderID(ctx context.Context, tx *gorm.DB, orderID int) (*domain.Invoice, error)
+	NextInvoiceSequence(ctx context.Context, tx *gorm.DB, year int) (int, error)
+	InsertInvoice(ctx context.Context, tx *gorm.DB, data domain.Invoice) (*domain.Invoice, error)
+	GetInvoiceLines(ctx context.Context, orderID int) ([]domain.InvoiceLine, error)
+	GetOrderTaxes(ctx context.Context, orderID int) ([]domain.OrderTax, error)
+	GetCustomerByID(ctx context.Context, customerID int) (domain.Customer, error)
+}
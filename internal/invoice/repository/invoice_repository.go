@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/invoice"
+	"gorm.io/gorm"
+)
+
+type InvoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) invoice.Repository {
+	return &InvoiceRepository{db}
+}
+
+func (r *InvoiceRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *InvoiceRepository) GetOrderByID(ctx context.Context, orderID int) (domain.Order, error) {
+	var data domain.Order
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", orderID).First(&data).Error
+	data.SetChargedCurrency()
+	return data, err
+}
+
+// GetOrderForUpdate locks the order, it is invoiced once.
+func (r *InvoiceRepository) GetOrderForUpdate(ctx context.Context, tx *gorm.DB, orderID int) (domain.Order, error) {
+	var data domain.Order
+
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT * FROM "order" WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, orderID).Scan(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return data, gorm.ErrRecordNotFound
+	}
+	data.SetChargedCurrency()
+	return data, result.Error
+}
+
+// GetInvoiceByOrderID returns nil when the order is not invoiced.
+func (r *InvoiceRepository) GetInvoiceByOrderID(ctx context.Context, tx *gorm.DB, orderID int) (*domain.Invoice, error) {
+	var data []domain.Invoice
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("order_id = ?", orderID).Limit(1).Find(&data).Error
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	return &data[0], nil
+}
+
+// NextInvoiceSequence takes the next number of the year. The row of the year stays
+// locked until the transaction ends, a rolled back invoice gives its number back.
+func (r *InvoiceRepository) NextInvoiceSequence(ctx context.Context, tx *gorm.DB, year int) (int, error) {
+	var sequence int
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`INSERT INTO invoice_sequence (year, last_number) 
+				VALUES (?, 1) 
+				ON CONFLICT (year) DO UPDATE SET last_number = invoice_sequence.last_number + 1 
+				RETURNING last_number`, year).Scan(&sequence).Error
+	return sequence, err
+}
+
+func (r *InvoiceRepository) InsertInvoice(ctx context.Context, tx *gorm.DB, data domain.Invoice) (*domain.Invoice, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Create(&data).Error
+
+	return &data, err
+}
+
+func (r *InvoiceRepository) GetInvoiceLines(ctx context.Context, orderID int) ([]domain.InvoiceLine, error) {
+	var data []domain.InvoiceLine
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT p.name, oi.sku, oi.quantity, oi.price, oi.discount, oi.tax_rate, oi.tax_amount 
+				FROM order_item oi 
+				JOIN product p ON p.id = oi.product_id 
+				WHERE oi.order_id = ? AND oi.deleted_at IS NULL 
+				ORDER BY oi.id`, orderID).Scan(&data).Error
+	return data, err
+}
+
+func (r *InvoiceRepository) GetOrderTaxes(ctx context.Context, orderID int) ([]domain.OrderTax, error) {
+	var data []domain.OrderTax
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("order_id = ?", orderID).Order("tax_rate_id").Find(&data).Error
+	return data, err
+}
+
+// GetCustomerByID finds deleted customers too, their invoices are kept.
+func (r *InvoiceRepository) GetCustomerByID(ctx context.Context, customerID int) (domain.Customer, error) {
+	var data domain.Customer
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("customer_id = ?", customerID).First(&data).Error
+	return data, err
+}
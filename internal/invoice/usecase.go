@@ -0,0 +1,13 @@
+package invoice
+
+import (
+	"context"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
+)
+
+type UseCase interface {
+	IssueInvoice(ctx context.Context, tx *gorm.DB, orderID int) (*domain.Invoice, error)
+	GetInvoicePDF(beegoCtx *beegoContext.Context, orderID, customerID int, lang string) (*domain.Invoice, []byte, error)
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/beego/i18n"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/money"
+	"github.com/online-store/pkg/pdf"
+)
+
+const (
+	marginLeft  = 40.0
+	marginRight = pdf.PageWidth - 40
+	pageBottom  = pdf.PageHeight - 60
+
+	// right edges of the columns of the lines
+	colQuantity = 300.0
+	colPrice    = 375.0
+	colDiscount = 440.0
+	colTax      = 480.0
+	colAmount   = marginRight
+
+	// left edge of the labels of the totals
+	colTotals = 340.0
+)
+
+// invoiceWriter lays out an invoice top down, y is the baseline of the next line.
+type invoiceWriter struct {
+	doc  *pdf.Document
+	lang string
+	y    float64
+}
+
+func (w *invoiceWriter) tr(key string, args ...interface{}) string {
+	return i18n.Tr(w.lang, "message."+key, args...)
+}
+
+// renderInvoice writes the invoice as a PDF, its labels in lang. Amounts are in the
+// base currency, the charged total is added when the order was charged in another.
+func renderInvoice(out io.Writer, issuer domain.InvoiceIssuer, data domain.InvoiceDocument, lang string) error {
+	w := &invoiceWriter{lang: lang}
+	w.doc = pdf.New(w.tr("invoiceTitle") + " " + data.Invoice.Number)
+	w.doc.AddPage()
+
+	w.header(issuer, data)
+	w.parties(data)
+	w.linesHeader()
+	for _, v := range data.Lines {
+		if err := w.line(v); err != nil {
+			return err
+		}
+	}
+	w.totals(data.Order)
+
+	_, err := w.doc.WriteTo(out)
+	return err
+}
+
+func (w *invoiceWriter) header(issuer domain.InvoiceIssuer, data domain.InvoiceDocument) {
+	w.doc.Text(marginLeft, 60, pdf.Bold, 16, issuer.Name)
+	w.doc.TextRight(marginRight, 60, pdf.Bold, 20, strings.ToUpper(w.tr("invoiceTitle")))
+
+	y := 78.0
+	for _, v := range []string{issuer.Address, issuer.Phone, issuer.Email} {
+		if v != "" {
+			w.doc.Text(marginLeft, y, pdf.Regular, 9, v)
+			y += 12
+		}
+	}
+	if issuer.TaxNumber != "" {
+		w.doc.Text(marginLeft, y, pdf.Regular, 9, w.tr("invoiceTaxNumber")+": "+issuer.TaxNumber)
+		y += 12
+	}
+
+	details := [][2]string{
+		{w.tr("invoiceNumber"), data.Invoice.Number},
+		{w.tr("invoiceDate"), data.Invoice.IssuedAt.Format("2006-01-02")},
+		{w.tr("invoiceOrder"), fmt.Sprintf("#%d", data.Order.ID)},
+	}
+	for i, v := range details {
+		w.doc.Text(colTotals+40, 84+float64(i)*12, pdf.Regular, 9, v[0])
+		w.doc.TextRight(marginRight, 84+float64(i)*12, pdf.Bold, 9, v[1])
+	}
+
+	w.y = y + 20
+	w.doc.Line(marginLeft, w.y, marginRight, w.y)
+	w.y += 20
+}
+
+func (w *invoiceWriter) parties(data domain.InvoiceDocument) {
+	top := w.y
+	w.doc.Text(marginLeft, top, pdf.Bold, 10, w.tr("invoiceBillTo"))
+	y := top + 14
+	for _, v := range []string{strings.TrimSpace(data.Customer.FirstName + " " + data.Customer.LastName), data.Customer.Email, data.Customer.Address, data.Customer.PhoneNumber} {
+		if v != "" {
+			w.doc.Text(marginLeft, y, pdf.Regular, 9, fit(v, pdf.Regular, 9, colTotals-marginLeft-10))
+			y += 12
+		}
+	}
+
+	if data.Order.ShippingAddress != nil {
+		w.doc.Text(colTotals, top, pdf.Bold, 10, w.tr("invoiceShipTo"))
+		shipY := top + 14
+		city := strings.TrimSpace(value(data.Order.ShippingCity) + " " + value(data.Order.ShippingPostCode))
+		for _, v := range []string{*data.Order.ShippingAddress, city, value(data.Order.ShippingMethod)} {
+			if v != "" {
+				w.doc.Text(colTotals, shipY, pdf.Regular, 9, fit(v, pdf.Regular, 9, marginRight-colTotals))
+				shipY += 12
+			}
+		}
+		if shipY > y {
+			y = shipY
+		}
+	}
+
+	w.y = y + 16
+}
+
+func (w *invoiceWriter) linesHeader() {
+	w.doc.Text(marginLeft, w.y, pdf.Bold, 9, w.tr("invoiceItem"))
+	w.doc.TextRight(colQuantity, w.y, pdf.Bold, 9, w.tr("invoiceQuantity"))
+	w.doc.TextRight(colPrice, w.y, pdf.Bold, 9, w.tr("invoicePrice"))
+	w.doc.TextRight(colDiscount, w.y, pdf.Bold, 9, w.tr("invoiceDiscount"))
+	w.doc.TextRight(colTax, w.y, pdf.Bold, 9, w.tr("invoiceTax"))
+	w.doc.TextRight(colAmount, w.y, pdf.Bold, 9, w.tr("invoiceAmount"))
+	w.doc.Line(marginLeft, w.y+5, marginRight, w.y+5)
+	w.y += 18
+}
+
+// line writes an order item, on a new page when the page is full. Price is the line
+// total, the amount is the line after its discount.
+func (w *invoiceWriter) line(v domain.InvoiceLine) error {
+	if w.y > pageBottom {
+		w.doc.AddPage()
+		w.y = 60
+		w.linesHeader()
+	}
+
+	unitPrice, err := v.Price.Div(int64(v.Quantity))
+	if err != nil {
+		return err
+	}
+	amount, err := v.Price.Sub(v.Discount)
+	if err != nil {
+		return err
+	}
+
+	name := v.Name
+	if v.SKU != nil && *v.SKU != "" {
+		name += " (" + *v.SKU + ")"
+	}
+	w.doc.Text(marginLeft, w.y, pdf.Regular, 9, fit(name, pdf.Regular, 9, colQuantity-marginLeft-30))
+	w.doc.TextRight(colQuantity, w.y, pdf.Regular, 9, strconv.Itoa(v.Quantity))
+	w.doc.TextRight(colPrice, w.y, pdf.Regular, 9, unitPrice.Decimal())
+	w.doc.TextRight(colDiscount, w.y, pdf.Regular, 9, v.Discount.Decimal())
+	w.doc.TextRight(colTax, w.y, pdf.Regular, 9, percent(v.TaxRate))
+	w.doc.TextRight(colAmount, w.y, pdf.Regular, 9, amount.Decimal())
+	w.y += 14
+	return nil
+}
+
+func (w *invoiceWriter) totals(order domain.Order) {
+	rows := [][2]string{{w.tr("invoiceSubtotal"), order.Subtotal.String()}}
+	if !order.PromotionDiscount.IsZero() {
+		rows = append(rows, [2]string{w.tr("invoicePromotionDiscount"), "-" + order.PromotionDiscount.String()})
+	}
+	if !order.Discount.IsZero() {
+		rows = append(rows, [2]string{w.tr("invoiceCouponDiscount"), "-" + order.Discount.String()})
+	}
+	for _, v := range order.Taxes {
+		rows = append(rows, [2]string{fmt.Sprintf("%s %s", v.Name, percent(v.Rate)), v.Tax.String()})
+	}
+	taxLabel := w.tr("invoiceTaxTotal")
+	if order.TaxMode == domain.TaxInclusive {
+		taxLabel = w.tr("invoiceTaxIncluded")
+	}
+	rows = append(rows, [2]string{taxLabel, order.TaxTotal.String()})
+	if order.ShippingMethodID != nil {
+		rows = append(rows, [2]string{w.tr("invoiceShipping"), order.ShippingCost.String()})
+	}
+
+	// the totals stay together on one page
+	if w.y+float64(len(rows)+3)*14 > pdf.PageHeight-40 {
+		w.doc.AddPage()
+		w.y = 60
+	}
+
+	w.doc.Line(marginLeft, w.y-4, marginRight, w.y-4)
+	w.y += 10
+	for _, v := range rows {
+		w.doc.Text(colTotals, w.y, pdf.Regular, 9, v[0])
+		w.doc.TextRight(colAmount, w.y, pdf.Regular, 9, v[1])
+		w.y += 14
+	}
+
+	w.doc.Text(colTotals, w.y+4, pdf.Bold, 11, w.tr("invoiceTotal"))
+	w.doc.TextRight(colAmount, w.y+4, pdf.Bold, 11, order.TotalPrice.String())
+	w.y += 22
+
+	if order.Currency != "" && order.Currency != money.DefaultCurrency() {
+		w.doc.Text(colTotals, w.y, pdf.Regular, 9, w.tr("invoiceCharged", strconv.FormatFloat(order.ExchangeRate, 'f', -1, 64)))
+		w.doc.TextRight(colAmount, w.y, pdf.Bold, 9, order.ChargedTotal.String())
+	}
+}
+
+// fit shortens text with an ellipsis to fit in width.
+func fit(text string, font pdf.Font, size, width float64) string {
+	if pdf.TextWidth(text, font, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.TextWidth(string(runes)+"...", font, size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func percent(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64) + "%"
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/invoice"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type InvoiceUseCase struct {
+	invoiceRepo invoice.Repository
+	issuer      domain.InvoiceIssuer
+	prefix      string
+	zapLogger   zaplogger.Logger
+}
+
+func NewInvoiceUseCase(invoiceRepo invoice.Repository, issuer domain.InvoiceIssuer, prefix string, zapLogger zaplogger.Logger) invoice.UseCase {
+	return &InvoiceUseCase{
+		invoiceRepo: invoiceRepo,
+		issuer:      issuer,
+		prefix:      prefix,
+		zapLogger:   zapLogger,
+	}
+}
+
+// IssueInvoice invoices a paid order within tx, the payment transaction. The number
+// is taken from the sequence of the year, an order already invoiced keeps its invoice.
+func (u *InvoiceUseCase) IssueInvoice(ctx context.Context, tx *gorm.DB, orderID int) (*domain.Invoice, error) {
+	order, err := u.invoiceRepo.GetOrderForUpdate(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := u.invoiceRepo.GetInvoiceByOrderID(ctx, tx, orderID)
+	if err != nil || data != nil {
+		return data, err
+	}
+	if !domain.IsPaidOrderStatus(order.Status) {
+		return nil, domain.ErrOrderNotPaid
+	}
+
+	now := time.Now()
+	sequence, err := u.invoiceRepo.NextInvoiceSequence(ctx, tx, now.Year())
+	if err != nil {
+		return nil, err
+	}
+
+	return u.invoiceRepo.InsertInvoice(ctx, tx, domain.Invoice{
+		OrderID:   orderID,
+		Number:    domain.InvoiceNumber(u.prefix, now.Year(), sequence),
+		Year:      now.Year(),
+		Sequence:  sequence,
+		IssuedAt:  now,
+		CreatedAt: now,
+		CreatedBy: "System",
+	})
+}
+
+// GetInvoicePDF renders the invoice of an order in lang, of an order of the customer
+// unless customerID is 0. Orders paid before invoicing existed are invoiced now.
+func (u *InvoiceUseCase) GetInvoicePDF(beegoCtx *beegoContext.Context, orderID, customerID int, lang string) (*domain.Invoice, []byte, error) {
+	order, err := u.invoiceRepo.GetOrderByID(beegoCtx.Request.Context(), orderID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return nil, nil, err
+	}
+	if customerID != 0 && order.CustomerID != customerID {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	if !domain.IsPaidOrderStatus(order.Status) {
+		return nil, nil, domain.ErrOrderNotPaid
+	}
+
+	data, err := u.invoiceRepo.GetInvoiceByOrderID(beegoCtx.Request.Context(), u.invoiceRepo.DB(), orderID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, nil, err
+	}
+	if data == nil {
+		//start transaction
+		errs := u.invoiceRepo.DB().Transaction(func(tx *gorm.DB) error {
+			data, err = u.IssueInvoice(beegoCtx.Request.Context(), tx, orderID)
+			return err
+		})
+		if errs != nil {
+			if !errors.Is(errs, gorm.ErrRecordNotFound) && !errors.Is(errs, domain.ErrOrderNotPaid) {
+				beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+			}
+			return nil, nil, errs
+		}
+	}
+
+	doc := domain.InvoiceDocument{Invoice: *data, Order: order}
+	if doc.Lines, err = u.invoiceRepo.GetInvoiceLines(beegoCtx.Request.Context(), orderID); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, nil, err
+	}
+	if doc.Order.Taxes, err = u.invoiceRepo.GetOrderTaxes(beegoCtx.Request.Context(), orderID); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, nil, err
+	}
+	if doc.Customer, err = u.invoiceRepo.GetCustomerByID(beegoCtx.Request.Context(), order.CustomerID); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := renderInvoice(&buf, u.issuer, doc, lang); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, nil, err
+	}
+
+	return &doc.Invoice, buf.Bytes(), nil
+}
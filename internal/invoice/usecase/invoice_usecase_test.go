@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/invoice"
+	"gorm.io/gorm"
+)
+
+// fakeInvoiceRepository holds one order and counts the numbers taken from the
+// sequence, the other queries are not used by the tests.
+type fakeInvoiceRepository struct {
+	invoice.Repository
+	order    domain.Order
+	invoice  *domain.Invoice
+	sequence int
+}
+
+func (r *fakeInvoiceRepository) GetOrderByID(_ context.Context, orderID int) (domain.Order, error) {
+	if orderID != r.order.ID {
+		return domain.Order{}, gorm.ErrRecordNotFound
+	}
+	return r.order, nil
+}
+
+func (r *fakeInvoiceRepository) GetOrderForUpdate(ctx context.Context, _ *gorm.DB, orderID int) (domain.Order, error) {
+	return r.GetOrderByID(ctx, orderID)
+}
+
+func (r *fakeInvoiceRepository) GetInvoiceByOrderID(context.Context, *gorm.DB, int) (*domain.Invoice, error) {
+	return r.invoice, nil
+}
+
+func (r *fakeInvoiceRepository) NextInvoiceSequence(context.Context, *gorm.DB, int) (int, error) {
+	r.sequence++
+	return r.sequence, nil
+}
+
+func (r *fakeInvoiceRepository) InsertInvoice(_ context.Context, _ *gorm.DB, data domain.Invoice) (*domain.Invoice, error) {
+	r.invoice = &data
+	return r.invoice, nil
+}
+
+func TestIssueInvoice(t *testing.T) {
+	year := time.Now().Year()
+
+	tests := []struct {
+		name     string
+		status   string
+		invoice  *domain.Invoice
+		number   string
+		sequence int
+		err      error
+	}{
+		{name: "pending order is not invoiced", status: domain.OrderStatusPending, err: domain.ErrOrderNotPaid},
+		{name: "cancelled order is not invoiced", status: domain.OrderStatusCancelled, err: domain.ErrOrderNotPaid},
+		{name: "paid order takes the next number", status: domain.OrderStatusPaid, number: domain.InvoiceNumber("INV", year, 1), sequence: 1},
+		{name: "shipped order takes the next number", status: domain.OrderStatusShipped, number: domain.InvoiceNumber("INV", year, 1), sequence: 1},
+		{
+			name:    "invoiced order keeps its invoice",
+			status:  domain.OrderStatusDelivered,
+			invoice: &domain.Invoice{OrderID: 1, Number: domain.InvoiceNumber("INV", year-1, 7)},
+			number:  domain.InvoiceNumber("INV", year-1, 7),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeInvoiceRepository{order: domain.Order{ID: 1, Status: tt.status}, invoice: tt.invoice}
+			u := NewInvoiceUseCase(repo, domain.InvoiceIssuer{}, "INV", nil)
+
+			data, err := u.IssueInvoice(context.Background(), nil, 1)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("IssueInvoice() error = %v, want %v", err, tt.err)
+			}
+			if repo.sequence != tt.sequence {
+				t.Errorf("numbers taken = %d, want %d", repo.sequence, tt.sequence)
+			}
+			if tt.err != nil {
+				return
+			}
+			if data.Number != tt.number {
+				t.Errorf("IssueInvoice() number = %s, want %s", data.Number, tt.number)
+			}
+		})
+	}
+}
+
+func TestGetInvoicePDFOfUnpaidOrder(t *testing.T) {
+	for _, status := range []string{domain.OrderStatusPending, domain.OrderStatusCancelled} {
+		t.Run(status, func(t *testing.T) {
+			repo := &fakeInvoiceRepository{order: domain.Order{ID: 1, CustomerID: 2, Status: status}}
+			u := NewInvoiceUseCase(repo, domain.InvoiceIssuer{}, "INV", nil)
+
+			beegoCtx := beegoContext.NewContext()
+			beegoCtx.Reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/customer/v1/order/1/invoice", nil))
+
+			if _, _, err := u.GetInvoicePDF(beegoCtx, 1, 2, "en"); !errors.Is(err, domain.ErrOrderNotPaid) {
+				t.Fatalf("GetInvoicePDF() error = %v, want %v", err, domain.ErrOrderNotPaid)
+			}
+			if repo.sequence != 0 {
+				t.Errorf("numbers taken = %d, want 0", repo.sequence)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/media"
+	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/response"
+	"github.com/online-store/pkg/validator"
+	"gorm.io/gorm"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/beego/i18n"
+)
+
+type MediaHandler struct {
+	beego.Controller
+	media.UseCase
+	i18n.Locale
+	response.APIResponseInterface
+	time.Duration
+	maxUploadSize int64
+}
+
+// NewMediaHandler routes the media endpoints, uploads bigger than maxUploadSize
+// bytes are rejected while they are read.
+func NewMediaHandler(useCase media.UseCase, maxUploadSize int64, executionTimeout time.Duration, apiResponse response.APIResponseInterface) {
+	if maxUploadSize <= 0 {
+		maxUploadSize = domain.DefaultMaxImageUploadSize
+	}
+
+	handler := &MediaHandler{
+		UseCase:              useCase,
+		APIResponseInterface: apiResponse,
+		Duration:             executionTimeout,
+		maxUploadSize:        maxUploadSize,
+	}
+
+	beego.Router("/admin/v1/products/:id/images", handler, "post:UploadProductImage")
+	beego.Router("/admin/v1/products/:id/images/order", handler, "put:ReorderProductImages")
+	beego.Router("/admin/v1/products/:id/images/:image_id", handler, "delete:DeleteProductImage")
+}
+
+func (h *MediaHandler) Prepare() {
+	// check user access when needed
+	h.Lang = pkg.GetLangVersion(h.Ctx)
+	requestTime := time.Now().UnixNano() / int64(time.Millisecond)
+	h.Ctx.Input.SetData("request_time", requestTime)
+}
+
+func (h *MediaHandler) UploadProductImage() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	productID, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	file, _, err := h.GetFile("image")
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	defer file.Close()
+
+	//read one byte over the limit to tell a file of the limit from a bigger one
+	content, err := io.ReadAll(io.LimitReader(file, h.maxUploadSize+1))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	if int64(len(content)) > h.maxUploadSize {
+		h.ResponseError(h.Ctx, http.StatusRequestEntityTooLarge, domain.FileTooLargeErrorCode, domain.ErrorCodeText(domain.FileTooLargeErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	res, err := h.UseCase.UploadProductImage(h.Ctx, domain.UploadProductImageRequest{
+		ProductID: productID,
+		Content:   content,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrFileTooLarge) {
+			h.ResponseError(h.Ctx, http.StatusRequestEntityTooLarge, domain.FileTooLargeErrorCode, domain.ErrorCodeText(domain.FileTooLargeErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUnsupportedMedia) {
+			h.ResponseError(h.Ctx, http.StatusUnsupportedMediaType, domain.UnsupportedMediaTypeErrorCode, domain.ErrorCodeText(domain.UnsupportedMediaTypeErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrForeignKeyConstraint) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ForeignKeyConstraintErrorCode, domain.ErrorCodeText(domain.ForeignKeyConstraintErrorCode, h.Locale.Lang, "Data product"), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.insertedSuccess"), res, nil)
+}
+
+func (h *MediaHandler) ReorderProductImages() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	productID, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	var request domain.ReorderProductImageRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	request.ProductID = productID
+	res, err := h.UseCase.ReorderProductImages(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.updatedSuccess"), res, nil)
+}
+
+func (h *MediaHandler) DeleteProductImage() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	productID, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	imageID, err := strconv.Atoi(h.Ctx.Input.Param(":image_id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	if err := h.UseCase.DeleteProductImage(h.Ctx, productID, imageID); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.deletedSuccess"), nil, nil)
+}
@@ -0,0 +1,17 @@
+package media
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	InsertProductImage(ctx context.Context, tx *gorm.DB, data domain.ProductImage) (*domain.ProductImage, error)
+	GetNextImagePosition(ctx context.Context, tx *gorm.DB, productID int) (int, error)
+	GetImagesByProductIDs(ctx context.Context, productIDs []int) ([]domain.ProductImage, error)
+	GetProductImage(ctx context.Context, productID, imageID int) (domain.ProductImage, error)
+	UpdateImagePosition(ctx context.Context, tx *gorm.DB, productID, imageID, position int) (int64, error)
+	DeleteProductImage(ctx context.Context, tx *gorm.DB, productID, imageID int) error
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/media"
+	"gorm.io/gorm"
+	"time"
+)
+
+type MediaRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) media.Repository {
+	return &MediaRepository{db: db}
+}
+
+func (r *MediaRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *MediaRepository) InsertProductImage(ctx context.Context, tx *gorm.DB, data domain.ProductImage) (*domain.ProductImage, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *MediaRepository) GetNextImagePosition(ctx context.Context, tx *gorm.DB, productID int) (int, error) {
+	var position int
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Raw(`SELECT COALESCE(MAX(position) + 1, 0) FROM product_image WHERE product_id = ? AND deleted_at IS NULL`, productID).
+		Scan(&position).Error
+	return position, err
+}
+
+func (r *MediaRepository) GetImagesByProductIDs(ctx context.Context, productIDs []int) ([]domain.ProductImage, error) {
+	var data []domain.ProductImage
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("product_id IN ? AND deleted_at IS NULL", productIDs).Order("product_id, position, id").Find(&data).Error
+	return data, err
+}
+
+func (r *MediaRepository) GetProductImage(ctx context.Context, productID, imageID int) (domain.ProductImage, error) {
+	var data domain.ProductImage
+
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND product_id = ? AND deleted_at IS NULL", imageID, productID).First(&data)
+	return data, result.Error
+}
+
+func (r *MediaRepository) UpdateImagePosition(ctx context.Context, tx *gorm.DB, productID, imageID, position int) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("product_image").Where("id = ? AND product_id = ? AND deleted_at IS NULL", imageID, productID).
+		Updates(map[string]interface{}{
+			"position":   position,
+			"updated_at": time.Now(),
+			"updated_by": "System",
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *MediaRepository) DeleteProductImage(ctx context.Context, tx *gorm.DB, productID, imageID int) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("product_image").Where("id = ? AND product_id = ?", imageID, productID).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": "System",
+		}).Error
+}
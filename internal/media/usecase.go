@@ -0,0 +1,14 @@
+package media
+
+import (
+	"context"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+)
+
+type UseCase interface {
+	UploadProductImage(beegoCtx *beegoContext.Context, request domain.UploadProductImageRequest) (*domain.ProductImage, error)
+	ReorderProductImages(beegoCtx *beegoContext.Context, request domain.ReorderProductImageRequest) ([]domain.ProductImage, error)
+	DeleteProductImage(beegoCtx *beegoContext.Context, productID, imageID int) error
+	GetProductImages(ctx context.Context, productIDs []int) (map[int][]domain.ProductImage, error)
+}
@@ -0,0 +1,297 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/media"
+	"github.com/online-store/pkg/imaging"
+	"github.com/online-store/pkg/storage"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+// maxImagePixels rejects images whose decoded size would exhaust memory even
+// though the compressed upload is small.
+const maxImagePixels = 40_000_000
+
+type MediaUseCase struct {
+	mediaRepo     media.Repository
+	storage       storage.Storage
+	maxUploadSize int64
+	zapLogger     zaplogger.Logger
+}
+
+func NewMediaUseCase(mediaRepo media.Repository, storage storage.Storage, maxUploadSize int64, zapLogger zaplogger.Logger) media.UseCase {
+	if maxUploadSize <= 0 {
+		maxUploadSize = domain.DefaultMaxImageUploadSize
+	}
+
+	return &MediaUseCase{
+		mediaRepo:     mediaRepo,
+		storage:       storage,
+		maxUploadSize: maxUploadSize,
+		zapLogger:     zapLogger,
+	}
+}
+
+func (u MediaUseCase) UploadProductImage(beegoCtx *beegoContext.Context, req domain.UploadProductImageRequest) (*domain.ProductImage, error) {
+	ctx := beegoCtx.Request.Context()
+
+	if int64(len(req.Content)) > u.maxUploadSize {
+		return nil, domain.ErrFileTooLarge
+	}
+
+	// trust the content, not the content type sent by the client
+	contentType := http.DetectContentType(req.Content)
+	if _, ok := domain.ImageContentTypes[contentType]; !ok {
+		return nil, domain.ErrUnsupportedMedia
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(req.Content))
+	if err != nil || config.Width*config.Height > maxImagePixels {
+		return nil, domain.ErrUnsupportedMedia
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(req.Content))
+	if err != nil {
+		return nil, domain.ErrUnsupportedMedia
+	}
+
+	storageKey, err := newStorageKey(req.ProductID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	keys, err := u.storeImage(ctx, storageKey, contentType, req.Content, img)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		u.deleteObjects(ctx, keys)
+		return nil, err
+	}
+
+	var data *domain.ProductImage
+
+	//start transaction
+	errs := u.mediaRepo.DB().Transaction(func(tx *gorm.DB) error {
+		position, err := u.mediaRepo.GetNextImagePosition(ctx, tx, req.ProductID)
+		if err != nil {
+			return err
+		}
+
+		data, err = u.mediaRepo.InsertProductImage(ctx, tx, domain.ProductImage{
+			ProductID:   req.ProductID,
+			Position:    position,
+			StorageKey:  storageKey,
+			ContentType: contentType,
+			Size:        int64(len(req.Content)),
+			Width:       config.Width,
+			Height:      config.Height,
+			CreatedAt:   time.Now(),
+			CreatedBy:   "System",
+		})
+		return err
+	})
+
+	if errs != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		u.deleteObjects(ctx, keys)
+		if pgerr, ok := errs.(*pgconn.PgError); ok && pgerr.Code == domain.PgCodeForeignKeyConstraint {
+			return nil, domain.ErrForeignKeyConstraint
+		}
+		return nil, errs
+	}
+
+	data.URLs = u.imageURLs(*data)
+	return data, nil
+}
+
+func (u MediaUseCase) ReorderProductImages(beegoCtx *beegoContext.Context, req domain.ReorderProductImageRequest) ([]domain.ProductImage, error) {
+	ctx := beegoCtx.Request.Context()
+
+	images, err := u.mediaRepo.GetImagesByProductIDs(ctx, []int{req.ProductID})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	current := make(map[int]bool, len(images))
+	for _, v := range images {
+		current[v.ID] = true
+	}
+	for _, v := range req.ImageIDs {
+		if !current[v] {
+			return nil, gorm.ErrRecordNotFound
+		}
+	}
+
+	// images left out of the request keep their relative order after the listed ones
+	order := append([]int{}, req.ImageIDs...)
+	listed := make(map[int]bool, len(req.ImageIDs))
+	for _, v := range req.ImageIDs {
+		listed[v] = true
+	}
+	for _, v := range images {
+		if !listed[v.ID] {
+			order = append(order, v.ID)
+		}
+	}
+
+	//start transaction
+	errs := u.mediaRepo.DB().Transaction(func(tx *gorm.DB) error {
+		for position, imageID := range order {
+			affected, err := u.mediaRepo.UpdateImagePosition(ctx, tx, req.ProductID, imageID, position)
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		return nil
+	})
+
+	if errs != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		return nil, errs
+	}
+
+	result, err := u.GetProductImages(ctx, []int{req.ProductID})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return result[req.ProductID], nil
+}
+
+func (u MediaUseCase) DeleteProductImage(beegoCtx *beegoContext.Context, productID, imageID int) error {
+	ctx := beegoCtx.Request.Context()
+
+	data, err := u.mediaRepo.GetProductImage(ctx, productID, imageID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	if err := u.mediaRepo.DeleteProductImage(ctx, u.mediaRepo.DB(), productID, imageID); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	keys := []string{imageKey(data.StorageKey, data.ContentType, domain.ImageSizeOriginal)}
+	for size := range domain.ImageThumbnailSizes {
+		keys = append(keys, imageKey(data.StorageKey, data.ContentType, size))
+	}
+	u.deleteObjects(ctx, keys)
+
+	return nil
+}
+
+// GetProductImages returns the images of the given products keyed by product id,
+// in display order and with their URLs filled in.
+func (u MediaUseCase) GetProductImages(ctx context.Context, productIDs []int) (map[int][]domain.ProductImage, error) {
+	images, err := u.mediaRepo.GetImagesByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int][]domain.ProductImage, len(productIDs))
+	for _, v := range images {
+		v.URLs = u.imageURLs(v)
+		result[v.ProductID] = append(result[v.ProductID], v)
+	}
+
+	return result, nil
+}
+
+// storeImage saves the original upload and its thumbnails, returning the keys
+// written so far so they can be cleaned up when a later step fails.
+func (u MediaUseCase) storeImage(ctx context.Context, storageKey, contentType string, content []byte, img image.Image) ([]string, error) {
+	var keys []string
+
+	key := imageKey(storageKey, contentType, domain.ImageSizeOriginal)
+	if err := u.storage.Put(ctx, key, bytes.NewReader(content), contentType); err != nil {
+		return keys, err
+	}
+	keys = append(keys, key)
+
+	for size, maxSize := range domain.ImageThumbnailSizes {
+		var buf bytes.Buffer
+		if err := encodeThumbnail(&buf, imaging.Thumbnail(img, maxSize), contentType); err != nil {
+			return keys, err
+		}
+
+		key := imageKey(storageKey, contentType, size)
+		if err := u.storage.Put(ctx, key, &buf, thumbnailContentType(contentType)); err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (u MediaUseCase) deleteObjects(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := u.storage.Delete(ctx, key); err != nil {
+			u.zapLogger.SetMessageLog(err)
+		}
+	}
+}
+
+func (u MediaUseCase) imageURLs(data domain.ProductImage) map[string]string {
+	urls := map[string]string{
+		domain.ImageSizeOriginal: u.storage.URL(imageKey(data.StorageKey, data.ContentType, domain.ImageSizeOriginal)),
+	}
+	for size := range domain.ImageThumbnailSizes {
+		urls[size] = u.storage.URL(imageKey(data.StorageKey, data.ContentType, size))
+	}
+	return urls
+}
+
+func newStorageKey(productID int) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("products/%d/%s", productID, hex.EncodeToString(b)), nil
+}
+
+// imageKey is the storage key of one size of an image. Originals keep the format
+// they were uploaded in, thumbnails are JPEG for JPEG uploads and PNG otherwise.
+func imageKey(storageKey, contentType, size string) string {
+	ext := domain.ImageContentTypes[contentType]
+	if size != domain.ImageSizeOriginal {
+		ext = domain.ImageContentTypes[thumbnailContentType(contentType)]
+	}
+	return storageKey + "/" + size + "." + ext
+}
+
+func thumbnailContentType(contentType string) string {
+	if contentType == "image/jpeg" {
+		return contentType
+	}
+	return "image/png"
+}
+
+func encodeThumbnail(w io.Writer, img image.Image, contentType string) error {
+	if thumbnailContentType(contentType) == "image/jpeg" {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return png.Encode(w, img)
+}
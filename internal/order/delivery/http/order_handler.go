@@ -2,14 +2,18 @@ package http
 
 import (
 	"context"
+	"errors"
 	beego "github.com/beego/beego/v2/server/web"
 	"github.com/beego/i18n"
 	"github.com/online-store/internal/domain"
 	"github.com/online-store/internal/order"
 	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/money"
 	"github.com/online-store/pkg/response"
 	"github.com/online-store/pkg/validator"
+	"gorm.io/gorm"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -30,6 +34,8 @@ func NewOrderHandler(useCase order.UseCase, executionTimeout time.Duration, apiR
 
 	beego.Router("/customer/v1/order/check-out", handler, "post:OrderCheckout")
 	beego.Router("/customer/v1/order/payment/:order_id", handler, "post:OrderPayment")
+	beego.Router("/customer/v1/order/cancel/:order_id", handler, "post:OrderCancel")
+	beego.Router("/partner/v1/order/check-out", handler, "post:OrderCheckout")
 }
 
 func (h *OrderHandler) Prepare() {
@@ -57,9 +63,65 @@ func (h *OrderHandler) OrderCheckout() {
 	}
 
 	request.CustomerID = h.Ctx.Input.GetData("userID").(int)
+	request.Currency = pkg.GetCurrency(h.Ctx)
 
 	res, err := h.UseCase.CheckoutOrder(h.Ctx, request)
 	if err != nil {
+		if errors.Is(err, domain.ErrUnsupportedCurrency) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.UnsupportedCurrencyErrorCode, domain.ErrorCodeText(domain.UnsupportedCurrencyErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InsufficientStockErrorCode, domain.ErrorCodeText(domain.InsufficientStockErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrVariantRequired) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.VariantRequiredErrorCode, domain.ErrorCodeText(domain.VariantRequiredErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrPriceChanged) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.PriceChangedErrorCode, domain.ErrorCodeText(domain.PriceChangedErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrShippingUnavailable) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ShippingUnavailableErrorCode, domain.ErrorCodeText(domain.ShippingUnavailableErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrCouponInvalid) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.CouponInvalidErrorCode, domain.ErrorCodeText(domain.CouponInvalidErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrCouponMinSpend) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.CouponMinSpendErrorCode, domain.ErrorCodeText(domain.CouponMinSpendErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrCouponNotApplicable) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.CouponNotApplicableErrorCode, domain.ErrorCodeText(domain.CouponNotApplicableErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrCouponUsageLimit) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.CouponUsageLimitErrorCode, domain.ErrorCodeText(domain.CouponUsageLimitErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, money.ErrCurrencyMismatch) || errors.Is(err, money.ErrOverflow) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+			return
+		}
+
 		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), nil)
 		return
 	}
@@ -85,11 +147,68 @@ func (h *OrderHandler) OrderPayment() {
 	}
 
 	request.OrderID = h.Ctx.Input.Param(":order_id")
+	request.CustomerID = h.Ctx.Input.GetData("userID").(int)
 	res, err := h.UseCase.MakePayment(h.Ctx, request)
 	if err != nil {
+		if errors.Is(err, money.ErrCurrencyMismatch) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrOrderNotPayable) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.OrderNotPayableErrorCode, domain.ErrorCodeText(domain.OrderNotPayableErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrPaymentAmount) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.PaymentAmountErrorCode, domain.ErrorCodeText(domain.PaymentAmountErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
 		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), nil)
 		return
 	}
 
 	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
 }
+
+func (h *OrderHandler) OrderCancel() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	orderID, err := strconv.Atoi(h.Ctx.Input.Param(":order_id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	err = h.UseCase.CancelOrder(h.Ctx, orderID, h.Ctx.Input.GetData("userID").(int))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrOrderNotCancellable) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.OrderNotCancellableErrorCode, domain.ErrorCodeText(domain.OrderNotCancellableErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.updatedSuccess"), nil, nil)
+}
@@ -2,14 +2,28 @@ package order
 
 import (
 	"context"
+	"time"
+
 	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/money"
 	"gorm.io/gorm"
 )
 
 type Repository interface {
 	DB() *gorm.DB
+	GetProductPrice(ctx context.Context, tx *gorm.DB, productID int, variantID *int) (money.Money, error)
+	HasVariants(ctx context.Context, tx *gorm.DB, productID int) (bool, error)
 	InsertOrder(ctx context.Context, tx *gorm.DB, data domain.Order) (*domain.Order, error)
 	InsertOrderItem(ctx context.Context, tx *gorm.DB, data []domain.OrderItem) error
 	InsertPayment(ctx context.Context, tx *gorm.DB, data domain.Payment) (*domain.Payment, error)
-	UpdateOrder(ctx context.Context, tx *gorm.DB, paymentID, orderID int) error
+	GetOrderForUpdate(ctx context.Context, tx *gorm.DB, orderID int) (domain.Order, error)
+	UpdateOrder(ctx context.Context, tx *gorm.DB, paymentID, orderID, customerID int) (int64, error)
+	CancelOrder(ctx context.Context, tx *gorm.DB, orderID int, actor string) (int64, error)
+	GetExpiredOrderIDs(ctx context.Context, before time.Time, limit int) ([]int, error)
+	ReserveVariantStock(ctx context.Context, tx *gorm.DB, variantID, productID, quantity int) (*domain.ProductVariant, error)
+	ReserveProductStock(ctx context.Context, tx *gorm.DB, productID, quantity int) error
+	CommitReservedStock(ctx context.Context, tx *gorm.DB, orderID int, actor string) error
+	ReleaseReservedStock(ctx context.Context, tx *gorm.DB, orderID int, actor string) error
+	GetOrderProductIDs(ctx context.Context, tx *gorm.DB, orderID int) ([]int, error)
+	InsertOrderItemAllocations(ctx context.Context, tx *gorm.DB, data []domain.OrderItemAllocation) error
 }
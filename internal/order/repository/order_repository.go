@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"time"
+
 	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/online-store/internal/domain"
 	"github.com/online-store/internal/order"
+	"github.com/online-store/pkg/money"
 	"gorm.io/gorm"
 )
 
@@ -20,6 +23,42 @@ func (r *OrderRepository) DB() *gorm.DB {
 	return r.db
 }
 
+// GetProductPrice returns the current unit price of the product, of its variant when
+// variantID is set.
+func (r *OrderRepository) GetProductPrice(ctx context.Context, tx *gorm.DB, productID int, variantID *int) (money.Money, error) {
+	var data []struct {
+		Price money.Money `gorm:"column:price"`
+	}
+
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+	var err error
+	if variantID == nil {
+		err = db.Raw(`SELECT p.price FROM product p WHERE p.id = ? AND p.deleted_at IS NULL`, productID).Scan(&data).Error
+	} else {
+		err = db.Raw(`SELECT COALESCE(v.price, p.price) AS price 
+					FROM product_variant v 
+					JOIN product p ON p.id = v.product_id 
+					WHERE v.id = ? AND v.product_id = ? AND v.deleted_at IS NULL AND p.deleted_at IS NULL`, *variantID, productID).Scan(&data).Error
+	}
+	if err != nil {
+		return money.Money{}, err
+	}
+	if len(data) == 0 {
+		return money.Money{}, gorm.ErrRecordNotFound
+	}
+	return data[0].Price, nil
+}
+
+// HasVariants reports whether the product is sold by variant, its stock is then
+// held by its variants.
+func (r *OrderRepository) HasVariants(ctx context.Context, tx *gorm.DB, productID int) (bool, error) {
+	var count int64
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("product_variant").Where("product_id = ? AND deleted_at IS NULL", productID).Count(&count).Error
+	return count > 0, err
+}
+
 func (r *OrderRepository) InsertOrder(ctx context.Context, tx *gorm.DB, data domain.Order) (*domain.Order, error) {
 	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
 
@@ -40,10 +79,170 @@ func (r *OrderRepository) InsertPayment(ctx context.Context, tx *gorm.DB, data d
 	return &data, err
 }
 
-func (r *OrderRepository) UpdateOrder(ctx context.Context, tx *gorm.DB, paymentID, orderID int) error {
-	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
-		Table("order").Where("id = ?", orderID).
+// GetOrderForUpdate locks the order, it is paid once.
+func (r *OrderRepository) GetOrderForUpdate(ctx context.Context, tx *gorm.DB, orderID int) (domain.Order, error) {
+	var data domain.Order
+
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT * FROM "order" WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, orderID).Scan(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return data, gorm.ErrRecordNotFound
+	}
+	data.SetChargedCurrency()
+	return data, result.Error
+}
+
+// UpdateOrder marks a pending order of the customer as paid, it leaves any other
+// order, shipped or delivered ones included, as it is.
+func (r *OrderRepository) UpdateOrder(ctx context.Context, tx *gorm.DB, paymentID, orderID, customerID int) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("order").Where("id = ? AND customer_id = ? AND status = ? AND deleted_at IS NULL", orderID, customerID, domain.OrderStatusPending).
 		Updates(map[string]interface{}{
 			"payment_id": paymentID,
-		}).Error
+			"status":     domain.OrderStatusPaid,
+			"updated_at": time.Now(),
+			"updated_by": "System",
+		})
+	return result.RowsAffected, result.Error
+}
+
+// CancelOrder cancels the order while it is still pending.
+func (r *OrderRepository) CancelOrder(ctx context.Context, tx *gorm.DB, orderID int, actor string) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("order").Where("id = ? AND status = ? AND deleted_at IS NULL", orderID, domain.OrderStatusPending).
+		Updates(map[string]interface{}{
+			"status":     domain.OrderStatusCancelled,
+			"updated_at": time.Now(),
+			"updated_by": actor,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// GetExpiredOrderIDs returns up to limit orders still pending since before, oldest first.
+func (r *OrderRepository) GetExpiredOrderIDs(ctx context.Context, before time.Time, limit int) ([]int, error) {
+	var data []int
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("order").Where("status = ? AND created_at < ? AND deleted_at IS NULL", domain.OrderStatusPending, before).
+		Order("created_at, id").Limit(limit).Pluck("id", &data).Error
+	return data, err
+}
+
+// ReserveVariantStock reserves quantity of the SKU when that much is still available,
+// gorm.ErrRecordNotFound is returned otherwise.
+func (r *OrderRepository) ReserveVariantStock(ctx context.Context, tx *gorm.DB, variantID, productID, quantity int) (*domain.ProductVariant, error) {
+	var data []domain.ProductVariant
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`UPDATE product_variant 
+				SET reserved_stock = reserved_stock + ?, updated_at = now(), updated_by = 'System' 
+				WHERE id = ? AND product_id = ? AND deleted_at IS NULL AND stock - reserved_stock >= ? 
+				RETURNING *`, quantity, variantID, productID, quantity).Scan(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &data[0], nil
+}
+
+// ReserveProductStock reserves quantity of a product sold without variants.
+func (r *OrderRepository) ReserveProductStock(ctx context.Context, tx *gorm.DB, productID, quantity int) error {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Exec(`UPDATE product 
+				SET reserved_stock = reserved_stock + ?, updated_at = now(), updated_by = 'System' 
+				WHERE id = ? AND deleted_at IS NULL AND stock - reserved_stock >= ?`, quantity, productID, quantity)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CommitReservedStock turns the reservations of the order items into stock deductions once
+// the order is paid, in total and in the warehouses the items were allocated to, and records
+// them as sales in the inventory ledger.
+func (r *OrderRepository) CommitReservedStock(ctx context.Context, tx *gorm.DB, orderID int, actor string) error {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+
+	err := db.Exec(`UPDATE product_variant v 
+				SET stock = v.stock - oi.quantity, reserved_stock = v.reserved_stock - oi.quantity, updated_at = now(), updated_by = 'System' 
+				FROM (SELECT variant_id, SUM(quantity) AS quantity FROM order_item WHERE order_id = ? AND variant_id IS NOT NULL AND deleted_at IS NULL GROUP BY variant_id) oi 
+				WHERE v.id = oi.variant_id`, orderID).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE product p 
+				SET stock = p.stock - oi.quantity, reserved_stock = p.reserved_stock - oi.quantity, updated_at = now(), updated_by = 'System' 
+				FROM (SELECT product_id, SUM(quantity) AS quantity FROM order_item WHERE order_id = ? AND variant_id IS NULL AND deleted_at IS NULL GROUP BY product_id) oi 
+				WHERE p.id = oi.product_id`, orderID).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE warehouse_stock ws 
+				SET stock = ws.stock - a.quantity, reserved_stock = ws.reserved_stock - a.quantity, updated_at = now() 
+				FROM (SELECT warehouse_id, product_id, variant_id, SUM(quantity) AS quantity FROM order_item_allocation WHERE order_id = ? GROUP BY warehouse_id, product_id, variant_id) a 
+				WHERE ws.warehouse_id = a.warehouse_id AND ws.product_id = a.product_id AND ws.variant_id IS NOT DISTINCT FROM a.variant_id`, orderID).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`INSERT INTO inventory_movement (product_id, variant_id, warehouse_id, quantity, reserved_quantity, reason, reference, actor, created_at) 
+				SELECT product_id, variant_id, warehouse_id, -SUM(quantity), -SUM(quantity), ?, ?, ?, now() 
+				FROM order_item_allocation WHERE order_id = ? 
+				GROUP BY product_id, variant_id, warehouse_id`, domain.InventoryReasonSale, domain.OrderReference(orderID), actor, orderID).Error
+}
+
+// ReleaseReservedStock gives the stock reserved for the order items back, in total and in
+// the warehouses the items were allocated to, and records it in the inventory ledger. The
+// stock on hand is left as it is.
+func (r *OrderRepository) ReleaseReservedStock(ctx context.Context, tx *gorm.DB, orderID int, actor string) error {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+
+	err := db.Exec(`UPDATE product_variant v 
+				SET reserved_stock = v.reserved_stock - oi.quantity, updated_at = now(), updated_by = 'System' 
+				FROM (SELECT variant_id, SUM(quantity) AS quantity FROM order_item WHERE order_id = ? AND variant_id IS NOT NULL AND deleted_at IS NULL GROUP BY variant_id) oi 
+				WHERE v.id = oi.variant_id`, orderID).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE product p 
+				SET reserved_stock = p.reserved_stock - oi.quantity, updated_at = now(), updated_by = 'System' 
+				FROM (SELECT product_id, SUM(quantity) AS quantity FROM order_item WHERE order_id = ? AND variant_id IS NULL AND deleted_at IS NULL GROUP BY product_id) oi 
+				WHERE p.id = oi.product_id`, orderID).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE warehouse_stock ws 
+				SET reserved_stock = ws.reserved_stock - a.quantity, updated_at = now() 
+				FROM (SELECT warehouse_id, product_id, variant_id, SUM(quantity) AS quantity FROM order_item_allocation WHERE order_id = ? GROUP BY warehouse_id, product_id, variant_id) a 
+				WHERE ws.warehouse_id = a.warehouse_id AND ws.product_id = a.product_id AND ws.variant_id IS NOT DISTINCT FROM a.variant_id`, orderID).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`INSERT INTO inventory_movement (product_id, variant_id, warehouse_id, quantity, reserved_quantity, reason, reference, actor, created_at) 
+				SELECT product_id, variant_id, warehouse_id, 0, -SUM(quantity), ?, ?, ?, now() 
+				FROM order_item_allocation WHERE order_id = ? 
+				GROUP BY product_id, variant_id, warehouse_id`, domain.InventoryReasonCancellationRelease, domain.OrderReference(orderID), actor, orderID).Error
+}
+
+// GetOrderProductIDs returns the products ordered in the order.
+func (r *OrderRepository) GetOrderProductIDs(ctx context.Context, tx *gorm.DB, orderID int) ([]int, error) {
+	var data []int
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("order_item").Where("order_id = ? AND deleted_at IS NULL", orderID).Distinct().Pluck("product_id", &data).Error
+	return data, err
+}
+
+func (r *OrderRepository) InsertOrderItemAllocations(ctx context.Context, tx *gorm.DB, data []domain.OrderItemAllocation) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).CreateInBatches(&data, 100).Error
 }
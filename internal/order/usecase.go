@@ -1,6 +1,9 @@
 package order
 
 import (
+	"context"
+	"time"
+
 	beegoContext "github.com/beego/beego/v2/server/web/context"
 	"github.com/online-store/internal/domain"
 )
@@ -8,4 +11,7 @@ import (
 type UseCase interface {
 	CheckoutOrder(beegoCtx *beegoContext.Context, request domain.CreateOrderCheckoutRequest) (*domain.Order, error)
 	MakePayment(beegoCtx *beegoContext.Context, request domain.PaymentRequest) (*domain.Payment, error)
+	CancelOrder(beegoCtx *beegoContext.Context, orderID, customerID int) error
+	ExpireOrders(ctx context.Context, ttl time.Duration) error
+	Run(ctx context.Context, interval, ttl time.Duration)
 }
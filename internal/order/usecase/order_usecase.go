@@ -1,9 +1,22 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/coupon"
+	"github.com/online-store/internal/currency"
 	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/inventory"
+	"github.com/online-store/internal/invoice"
 	"github.com/online-store/internal/order"
+	"github.com/online-store/internal/promotion"
+	"github.com/online-store/internal/shipping"
+	"github.com/online-store/internal/stockalert"
+	"github.com/online-store/internal/tax"
+	"github.com/online-store/pkg/cache"
+	"github.com/online-store/pkg/money"
 	"github.com/online-store/pkg/zaplogger"
 	"gorm.io/gorm"
 	"strconv"
@@ -11,39 +24,185 @@ import (
 )
 
 type OrderUseCase struct {
-	orderRepo order.Repository
-	zapLogger zaplogger.Logger
+	orderRepo     order.Repository
+	inventoryRepo inventory.Repository
+	allocator     inventory.Allocator
+	couponUC      coupon.UseCase
+	promotionUC   promotion.UseCase
+	stockAlertUC  stockalert.UseCase
+	currencyUC    currency.UseCase
+	taxUC         tax.UseCase
+	shippingUC    shipping.UseCase
+	invoiceUC     invoice.UseCase
+	cacheRepo     cache.RedisRepository
+	zapLogger     zaplogger.Logger
 }
 
-func NewOrderUseCase(orderRepo order.Repository, zapLogger zaplogger.Logger) order.UseCase {
+func NewOrderUseCase(orderRepo order.Repository, inventoryRepo inventory.Repository, allocator inventory.Allocator, couponUC coupon.UseCase, promotionUC promotion.UseCase, stockAlertUC stockalert.UseCase, currencyUC currency.UseCase, taxUC tax.UseCase, shippingUC shipping.UseCase, invoiceUC invoice.UseCase, cacheRepo cache.RedisRepository, zapLogger zaplogger.Logger) order.UseCase {
 	return &OrderUseCase{
-		orderRepo: orderRepo,
-		zapLogger: zapLogger,
+		orderRepo:     orderRepo,
+		inventoryRepo: inventoryRepo,
+		allocator:     allocator,
+		couponUC:      couponUC,
+		promotionUC:   promotionUC,
+		stockAlertUC:  stockAlertUC,
+		currencyUC:    currencyUC,
+		taxUC:         taxUC,
+		shippingUC:    shippingUC,
+		invoiceUC:     invoiceUC,
+		cacheRepo:     cacheRepo,
+		zapLogger:     zapLogger,
 	}
 }
 
 func (u *OrderUseCase) CheckoutOrder(beegoCtx *beegoContext.Context, request domain.CreateOrderCheckoutRequest) (*domain.Order, error) {
 	var (
 		orderItem  []domain.OrderItem
+		lines      []domain.AllocationLine
+		productIDs []int
 		orderReq   domain.Order
-		totalPrice float64
+		subtotal   money.Money
 		orderData  *domain.Order
+		promotions *domain.PromotionResult
+		discount   *domain.CouponDiscount
+		taxes      *domain.TaxResult
+		shipment   *domain.ShippingQuote
 
 		err error
 	)
-	for _, v := range request.Order {
-		totalPrice += v.Price
+	//the order is charged in the asked currency, its amounts are kept in the base currency
+	rate, err := u.currencyUC.GetExchangeRate(beegoCtx.Request.Context(), request.Currency)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	//the order is taxed in the region it is shipped to unless told otherwise
+	if request.Region == "" && request.ShippingAddress != nil {
+		request.Region = request.ShippingAddress.Region
 	}
 
 	orderReq = domain.Order{
-		TotalPrice: totalPrice,
-		CustomerID: request.CustomerID,
-		CreatedAt:  time.Now(),
-		CreatedBy:  "System",
+		Status:       domain.OrderStatusPending,
+		ShippingCost: money.Zero(money.DefaultCurrency()),
+		Currency:     rate.Currency,
+		ExchangeRate: rate.Rate,
+		CustomerID:   request.CustomerID,
+		CreatedAt:    time.Now(),
+		CreatedBy:    "System",
+	}
+
+	//ship with the chosen method, it must ship to the address. It is quoted before the
+	//transaction as the carrier may take its time to answer
+	if request.ShippingMethodID != nil {
+		quoted, err := u.priceLines(beegoCtx.Request.Context(), u.orderRepo.DB(), request.Order)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, domain.ErrVariantRequired) {
+				beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			}
+			return nil, err
+		}
+
+		shippingLines := make([]domain.ShippingLine, 0, len(request.Order))
+		for _, v := range request.Order {
+			shippingLines = append(shippingLines, domain.ShippingLine{ProductID: v.ProductID, Quantity: v.Quantity})
+		}
+		shipment, err = u.shippingUC.QuoteMethod(beegoCtx.Request.Context(), u.orderRepo.DB(), *request.ShippingMethodID, *request.ShippingAddress, shippingLines, quoted)
+		if err != nil {
+			if !errors.Is(err, domain.ErrShippingUnavailable) {
+				beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			}
+			return nil, err
+		}
+		subtotal = quoted
 	}
 
 	//start transaction
 	errs := u.orderRepo.DB().Transaction(func(tx *gorm.DB) error {
+		//the lines are priced again in the transaction, the shipping quote must still hold
+		priced, err := u.priceLines(beegoCtx.Request.Context(), tx, request.Order)
+		if err != nil {
+			return err
+		}
+		if shipment != nil {
+			if cmp, err := priced.Cmp(subtotal); err != nil || cmp != 0 {
+				return domain.ErrPriceChanged
+			}
+		}
+		subtotal = priced
+		orderReq.Subtotal = subtotal
+		orderReq.TotalPrice = subtotal
+
+		//apply the running promotions
+		promotions, err = u.promotionUC.EvaluateOrder(beegoCtx.Request.Context(), tx, request.Order)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+		if orderReq.PromotionDiscount, err = money.Min(promotions.Discount, subtotal); err != nil {
+			return err
+		}
+		if orderReq.TotalPrice, err = subtotal.Sub(orderReq.PromotionDiscount); err != nil {
+			return err
+		}
+
+		//price the coupon on the lines left to pay after the promotions
+		if request.CouponCode != "" {
+			netOrder, err := promotions.NetOf(request.Order)
+			if err != nil {
+				return err
+			}
+			discount, err = u.couponUC.PriceOrder(beegoCtx.Request.Context(), tx, request.CouponCode, netOrder)
+			if err != nil {
+				return err
+			}
+			if discount.Discount, err = money.Min(discount.Discount, orderReq.TotalPrice); err != nil {
+				return err
+			}
+			if orderReq.TotalPrice, err = orderReq.TotalPrice.Sub(discount.Discount); err != nil {
+				return err
+			}
+			orderReq.Discount = discount.Discount
+			orderReq.CouponID = &discount.CouponID
+		}
+
+		//tax the lines after the discounts, the tax is added on top in the exclusive mode
+		var orderDiscount money.Money
+		if orderDiscount, err = orderReq.PromotionDiscount.Add(orderReq.Discount); err != nil {
+			return err
+		}
+		taxes, err = u.taxUC.CalculateOrder(beegoCtx.Request.Context(), tx, request.Region, request.Order, orderDiscount)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+		orderReq.TaxMode = taxes.Mode
+		orderReq.TaxTotal = taxes.Total
+		if taxes.Mode == domain.TaxExclusive {
+			if orderReq.TotalPrice, err = orderReq.TotalPrice.Add(taxes.Total); err != nil {
+				return err
+			}
+		}
+		if request.Region != "" {
+			orderReq.Region = &request.Region
+		}
+
+		//add the shipping quoted before the transaction
+		if shipment != nil {
+			if orderReq.TotalPrice, err = orderReq.TotalPrice.Add(shipment.Cost); err != nil {
+				return err
+			}
+			orderReq.ShippingMethodID = &shipment.MethodID
+			orderReq.ShippingMethod = &shipment.Name
+			orderReq.ShippingCost = shipment.Cost
+			orderReq.ShippingAddress = &request.ShippingAddress.Address
+			orderReq.ShippingCity = &request.ShippingAddress.City
+			orderReq.ShippingPostCode = &request.ShippingAddress.PostalCode
+		}
+		if orderReq.ChargedTotal, err = rate.Convert(orderReq.TotalPrice); err != nil {
+			return err
+		}
+
 		//insert orderReq
 		orderData, err = u.orderRepo.InsertOrder(beegoCtx.Request.Context(), tx, orderReq)
 		if err != nil {
@@ -51,16 +210,62 @@ func (u *OrderUseCase) CheckoutOrder(beegoCtx *beegoContext.Context, request dom
 			return err
 		}
 
-		for _, v := range request.Order {
-			//get product
-			orderItem = append(orderItem, domain.OrderItem{
+		err = u.promotionUC.RecordOrder(beegoCtx.Request.Context(), tx, orderData.ID, *promotions)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+
+		err = u.taxUC.RecordOrder(beegoCtx.Request.Context(), tx, orderData.ID, *taxes)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+		orderData.Taxes = taxes.Taxes
+
+		//count the use of the coupon, the usage limits are enforced here
+		if discount != nil {
+			if err := u.couponUC.Redeem(beegoCtx.Request.Context(), tx, *discount, request.CustomerID, orderData.ID); err != nil {
+				return err
+			}
+		}
+
+		reference := domain.OrderReference(orderData.ID)
+		for i, v := range request.Order {
+			item := domain.OrderItem{
 				ProductID: v.ProductID,
+				VariantID: v.VariantID,
 				Price:     v.Price,
+				Discount:  taxes.Lines[i].Discount,
+				TaxRateID: taxes.Lines[i].TaxRateID,
+				TaxRate:   taxes.Lines[i].Rate,
+				TaxAmount: taxes.Lines[i].Tax,
 				Quantity:  v.Quantity,
 				OrderID:   orderData.ID,
 				CreatedAt: time.Now(),
 				CreatedBy: "System",
-			})
+			}
+
+			//reserve stock, at the SKU level when a variant is ordered
+			if v.VariantID != nil {
+				variant, err := u.orderRepo.ReserveVariantStock(beegoCtx.Request.Context(), tx, *v.VariantID, v.ProductID, v.Quantity)
+				if err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return domain.ErrInsufficientStock
+					}
+					return err
+				}
+				item.SKU = &variant.SKU
+			} else if err := u.orderRepo.ReserveProductStock(beegoCtx.Request.Context(), tx, v.ProductID, v.Quantity); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return domain.ErrInsufficientStock
+				}
+				return err
+			}
+
+			orderItem = append(orderItem, item)
+			lines = append(lines, domain.AllocationLine{ProductID: v.ProductID, VariantID: v.VariantID, Quantity: v.Quantity})
+			productIDs = append(productIDs, v.ProductID)
 		}
 
 		//insert orderReq item
@@ -70,6 +275,66 @@ func (u *OrderUseCase) CheckoutOrder(beegoCtx *beegoContext.Context, request dom
 			return err
 		}
 
+		//pick the warehouses fulfilling the order and reserve their stock
+		stocks, err := u.inventoryRepo.LockWarehouseStocks(beegoCtx.Request.Context(), tx, productIDs)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+
+		allocations, err := u.allocator.Allocate(lines, stocks)
+		if err != nil {
+			return err
+		}
+
+		var (
+			itemAllocations []domain.OrderItemAllocation
+			movements       []domain.InventoryMovement
+		)
+		for _, v := range allocations {
+			affected, err := u.inventoryRepo.ReserveWarehouseStock(beegoCtx.Request.Context(), tx, v)
+			if err != nil {
+				beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+				return err
+			}
+			if affected == 0 {
+				return domain.ErrInsufficientStock
+			}
+
+			warehouseID := v.WarehouseID
+			itemAllocations = append(itemAllocations, domain.OrderItemAllocation{
+				OrderID:     orderData.ID,
+				ProductID:   v.ProductID,
+				VariantID:   v.VariantID,
+				WarehouseID: v.WarehouseID,
+				Quantity:    v.Quantity,
+				CreatedAt:   time.Now(),
+			})
+			movements = append(movements, domain.InventoryMovement{
+				ProductID:        v.ProductID,
+				VariantID:        v.VariantID,
+				WarehouseID:      &warehouseID,
+				ReservedQuantity: v.Quantity,
+				Reason:           domain.InventoryReasonCheckoutReservation,
+				Reference:        &reference,
+				Actor:            domain.CustomerActor(request.CustomerID),
+				CreatedAt:        time.Now(),
+			})
+		}
+
+		err = u.orderRepo.InsertOrderItemAllocations(beegoCtx.Request.Context(), tx, itemAllocations)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+
+		//record the reservations in the inventory ledger
+		err = u.inventoryRepo.InsertMovements(beegoCtx.Request.Context(), tx, movements)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+
 		return nil
 	})
 
@@ -78,9 +343,45 @@ func (u *OrderUseCase) CheckoutOrder(beegoCtx *beegoContext.Context, request dom
 		return nil, errs
 	}
 
+	//the reservations may leave products low in stock
+	u.stockAlertUC.CheckStockInBackground(productIDs)
+
+	//the listed products show their available stock
+	u.deleteProductListCache(beegoCtx.Request.Context())
+
 	return orderData, nil
 }
 
+// priceLines prices the lines from the catalog, in the base currency, the prices
+// sent are not trusted. A product sold by variant must be ordered by variant. It
+// returns the subtotal of the lines.
+func (u *OrderUseCase) priceLines(ctx context.Context, tx *gorm.DB, lines []domain.OrderRequest) (money.Money, error) {
+	subtotal := money.Zero(money.DefaultCurrency())
+	for i, v := range lines {
+		if v.VariantID == nil {
+			hasVariants, err := u.orderRepo.HasVariants(ctx, tx, v.ProductID)
+			if err != nil {
+				return money.Money{}, err
+			}
+			if hasVariants {
+				return money.Money{}, domain.ErrVariantRequired
+			}
+		}
+
+		price, err := u.orderRepo.GetProductPrice(ctx, tx, v.ProductID, v.VariantID)
+		if err != nil {
+			return money.Money{}, err
+		}
+		if lines[i].Price, err = price.Mul(int64(v.Quantity)); err != nil {
+			return money.Money{}, err
+		}
+		if subtotal, err = subtotal.Add(lines[i].Price); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return subtotal, nil
+}
+
 func (u *OrderUseCase) MakePayment(beegoCtx *beegoContext.Context, request domain.PaymentRequest) (*domain.Payment, error) {
 	var (
 		data *domain.Payment
@@ -92,11 +393,33 @@ func (u *OrderUseCase) MakePayment(beegoCtx *beegoContext.Context, request domai
 		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
 		return nil, err
 	}
+
+	//start transaction
 	errs := u.orderRepo.DB().Transaction(func(tx *gorm.DB) error {
+		//only a pending order of the customer is paid, for its whole total in the
+		//currency it was charged in
+		orderData, err := u.orderRepo.GetOrderForUpdate(beegoCtx.Request.Context(), tx, orderID)
+		if err != nil {
+			return err
+		}
+		if orderData.CustomerID != request.CustomerID {
+			return gorm.ErrRecordNotFound
+		}
+		if orderData.Status != domain.OrderStatusPending {
+			return domain.ErrOrderNotPayable
+		}
+		if request.Amount.Currency != orderData.ChargedTotal.Currency {
+			return money.ErrCurrencyMismatch
+		}
+		if cmp, err := request.Amount.Cmp(orderData.ChargedTotal); err != nil || cmp != 0 {
+			return domain.ErrPaymentAmount
+		}
+
 		//insert payment
 		data, err = u.orderRepo.InsertPayment(beegoCtx.Request.Context(), tx, domain.Payment{
 			Method:    request.Method,
 			Amount:    request.Amount,
+			Currency:  request.Amount.Currency,
 			Status:    "Success",
 			CreatedAt: time.Now(),
 			CreatedBy: "System",
@@ -107,7 +430,24 @@ func (u *OrderUseCase) MakePayment(beegoCtx *beegoContext.Context, request domai
 		}
 
 		//update order
-		err = u.orderRepo.UpdateOrder(beegoCtx.Request.Context(), tx, data.ID, orderID)
+		rowsAffected, err := u.orderRepo.UpdateOrder(beegoCtx.Request.Context(), tx, data.ID, orderID, request.CustomerID)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+		if rowsAffected == 0 {
+			return domain.ErrOrderNotPayable
+		}
+
+		//deduct the stock reserved at checkout
+		err = u.orderRepo.CommitReservedStock(beegoCtx.Request.Context(), tx, orderID, domain.InventoryActorSystem)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+
+		//invoice the paid order, a failed payment gives its invoice number back
+		_, err = u.invoiceUC.IssueInvoice(beegoCtx.Request.Context(), tx, orderID)
 		if err != nil {
 			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
 			return err
@@ -117,9 +457,144 @@ func (u *OrderUseCase) MakePayment(beegoCtx *beegoContext.Context, request domai
 	})
 
 	if errs != nil {
-		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		if !errors.Is(errs, gorm.ErrRecordNotFound) && !errors.Is(errs, domain.ErrOrderNotPayable) && !errors.Is(errs, domain.ErrPaymentAmount) && !errors.Is(errs, money.ErrCurrencyMismatch) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		}
 		return nil, errs
 	}
 
 	return data, nil
 }
+
+// CancelOrder cancels a pending order of the customer and gives the stock reserved
+// for it back.
+func (u *OrderUseCase) CancelOrder(beegoCtx *beegoContext.Context, orderID, customerID int) error {
+	productIDs, err := u.cancelOrder(beegoCtx.Request.Context(), orderID, customerID, domain.InventoryActorCustomer)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, domain.ErrOrderNotCancellable) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return err
+	}
+
+	//the released stock may bring products back in stock
+	u.stockAlertUC.CheckStockInBackground(productIDs)
+	u.deleteProductListCache(beegoCtx.Request.Context())
+
+	return nil
+}
+
+// ExpireOrders cancels the orders left unpaid for longer than ttl and gives the
+// stock reserved for them back.
+func (u *OrderUseCase) ExpireOrders(ctx context.Context, ttl time.Duration) error {
+	const batchSize = 100
+
+	for {
+		orderIDs, err := u.orderRepo.GetExpiredOrderIDs(ctx, time.Now().Add(-ttl), batchSize)
+		if err != nil {
+			return err
+		}
+
+		var productIDs []int
+		for _, orderID := range orderIDs {
+			ids, err := u.cancelOrder(ctx, orderID, 0, domain.InventoryActorSystem)
+			if errors.Is(err, domain.ErrOrderNotCancellable) || errors.Is(err, gorm.ErrRecordNotFound) {
+				//paid or cancelled meanwhile
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			productIDs = append(productIDs, ids...)
+		}
+		if len(productIDs) > 0 {
+			u.stockAlertUC.CheckStock(ctx, productIDs)
+			u.deleteProductListCache(ctx)
+		}
+
+		if len(orderIDs) < batchSize {
+			return nil
+		}
+	}
+}
+
+// Run expires the unpaid orders now and then every interval until ctx is done. It
+// runs in the background, so errors are only logged and a panicking run is tried
+// again at the next tick. The interval must be positive.
+func (u *OrderUseCase) Run(ctx context.Context, interval, ttl time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := u.safeExpireOrders(ctx, ttl); err != nil && ctx.Err() == nil {
+			u.zapLogger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// safeExpireOrders expires the unpaid orders, returning a panic as an error.
+func (u *OrderUseCase) safeExpireOrders(ctx context.Context, ttl time.Duration) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("order expiry panicked: %v", r)
+		}
+	}()
+	return u.ExpireOrders(ctx, ttl)
+}
+
+// cancelOrder cancels the pending order, of the customer unless customerID is 0, and
+// releases its reservations and its coupon uses. It returns the products of the order.
+func (u *OrderUseCase) cancelOrder(ctx context.Context, orderID, customerID int, actor string) ([]int, error) {
+	var productIDs []int
+
+	//start transaction
+	err := u.orderRepo.DB().Transaction(func(tx *gorm.DB) error {
+		orderData, err := u.orderRepo.GetOrderForUpdate(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+		if customerID != 0 && orderData.CustomerID != customerID {
+			return gorm.ErrRecordNotFound
+		}
+		if orderData.Status != domain.OrderStatusPending {
+			return domain.ErrOrderNotCancellable
+		}
+
+		rowsAffected, err := u.orderRepo.CancelOrder(ctx, tx, orderID, actor)
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return domain.ErrOrderNotCancellable
+		}
+
+		//give the stock reserved at checkout back
+		if err = u.orderRepo.ReleaseReservedStock(ctx, tx, orderID, actor); err != nil {
+			return err
+		}
+
+		//the coupon can be used again
+		if err = u.couponUC.Release(ctx, tx, orderID); err != nil {
+			return err
+		}
+
+		productIDs, err = u.orderRepo.GetOrderProductIDs(ctx, tx, orderID)
+		return err
+	})
+
+	return productIDs, err
+}
+
+// deleteProductListCache drops the cached product pages once the stock changed.
+// The order is already stored, so a failure is only logged.
+func (u *OrderUseCase) deleteProductListCache(ctx context.Context) {
+	if err := u.cacheRepo.Deletes(ctx, []string{domain.ProductListKeyCache}); err != nil {
+		u.zapLogger.Error(err)
+	}
+}
@@ -6,10 +6,16 @@ import (
 	"github.com/online-store/internal/domain"
 	"github.com/online-store/internal/product"
 	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
 	paging "github.com/online-store/pkg/paging"
 	"github.com/online-store/pkg/response"
 	"github.com/online-store/pkg/validator"
+	"gorm.io/gorm"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	beego "github.com/beego/beego/v2/server/web"
@@ -32,6 +38,13 @@ func NewProductHandler(useCase product.UseCase, executionTimeout time.Duration,
 	}
 
 	beego.Router("/api/v1/products", handler, "get:GetListProduct")
+	beego.Router("/partner/v1/products", handler, "get:GetListProduct")
+	beego.Router("/api/v1/products/:id", handler, "get:GetProductDetail")
+	beego.Router("/partner/v1/products/:id", handler, "get:GetProductDetail")
+	beego.Router("/admin/v1/products/:id/variants", handler, "post:CreateProductVariant")
+	beego.Router("/admin/v1/products/import", handler, "post:ImportProducts")
+	beego.Router("/admin/v1/products/import/:job_id", handler, "get:GetImportJob")
+	beego.Router("/admin/v1/products/export", handler, "get:ExportProducts")
 }
 
 func (h *ProductHandler) Prepare() {
@@ -47,12 +60,19 @@ func (h *ProductHandler) GetListProduct() {
 
 	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
 
-	var request domain.GetProductListRequest
-	request.ProductCategory = h.Ctx.Input.Query("product_category")
-	request.Search = h.Ctx.Input.Query("search")
+	request, err := h.parseProductListRequest(pkg.GetCurrency(h.Ctx))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidUrlQueryParam) {
+			h.ResponseError(
+				h.Ctx,
+				http.StatusBadRequest,
+				domain.InvalidUrlQueryParamErrorCode,
+				domain.ErrorCodeText(domain.InvalidUrlQueryParamErrorCode, h.Locale.Lang),
+				domain.ErrInvalidUrlQueryParam,
+			)
+			return
+		}
 
-	//validate request
-	if err := validator.Validate.ValidateStruct(&request); err != nil {
 		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
 		return
 	}
@@ -69,8 +89,22 @@ func (h *ProductHandler) GetListProduct() {
 		return
 	}
 
+	cursorMode, err := paging.CursorValidation(h.Ctx.Input.Query("pagination"), h.Ctx.Input.Query("cursor"))
+	if err != nil {
+		h.ResponseError(
+			h.Ctx,
+			http.StatusBadRequest,
+			domain.InvalidUrlQueryParamErrorCode,
+			domain.ErrorCodeText(domain.InvalidUrlQueryParamErrorCode, h.Locale.Lang),
+			domain.ErrInvalidUrlQueryParam,
+		)
+		return
+	}
+
 	request.Limit = limit
 	request.Page = page
+	request.CursorMode = cursorMode
+	request.Cursor = h.Ctx.Input.Query("cursor")
 
 	//call use case
 	res, err := h.UseCase.GetListProduct(h.Ctx, request)
@@ -80,9 +114,259 @@ func (h *ProductHandler) GetListProduct() {
 			return
 		}
 
+		if errors.Is(err, database.ErrInvalidCursor) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlQueryParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlQueryParamErrorCode, h.Locale.Lang), domain.ErrInvalidUrlQueryParam)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUnsupportedCurrency) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.UnsupportedCurrencyErrorCode, domain.ErrorCodeText(domain.UnsupportedCurrencyErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
 		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
 		return
 	}
 
 	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
 }
+
+func (h *ProductHandler) GetProductDetail() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	if _, err := strconv.Atoi(h.Ctx.Input.Param(":id")); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	res, err := h.UseCase.GetProductDetail(h.Ctx, h.Ctx.Input.Param(":id"), pkg.GetCurrency(h.Ctx))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUnsupportedCurrency) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.UnsupportedCurrencyErrorCode, domain.ErrorCodeText(domain.UnsupportedCurrencyErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *ProductHandler) CreateProductVariant() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	productID, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlParamErrorCode, h.Locale.Lang), nil)
+		return
+	}
+
+	var request domain.CreateProductVariantRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	request.ProductID = productID
+	res, err := h.UseCase.CreateProductVariant(h.Ctx, request)
+	if err != nil {
+		if errors.Is(err, domain.ErrForeignKeyConstraint) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ForeignKeyConstraintErrorCode, domain.ErrorCodeText(domain.ForeignKeyConstraintErrorCode, h.Locale.Lang, "Data product"), nil)
+			return
+		}
+
+		if errors.Is(err, domain.ErrUniqueConstraint) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.DataAlreadyExist, domain.ErrorCodeText(domain.DataAlreadyExist, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.insertedSuccess"), res, nil)
+}
+
+func (h *ProductHandler) ImportProducts() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	file, header, err := h.GetFile("file")
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	res, err := h.UseCase.ImportProducts(h.Ctx, domain.ImportProductRequest{
+		FileName: header.Filename,
+		Content:  content,
+		Lang:     h.Locale.Lang,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidImportFile) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidImportFileErrorCode, domain.ErrorCodeText(domain.InvalidImportFileErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *ProductHandler) GetImportJob() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	res, err := h.UseCase.GetImportJob(h.Ctx, h.Ctx.Input.Param(":job_id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, domain.DataNotFoundErrorCode, domain.ErrorCodeText(domain.DataNotFoundErrorCode, h.Locale.Lang), nil)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ok(h.Ctx, h.Tr("message.success"), res, nil)
+}
+
+func (h *ProductHandler) ExportProducts() {
+	ctx, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.Duration)
+	defer cancel()
+
+	h.Ctx.Request = h.Ctx.Request.WithContext(ctx)
+
+	request, err := h.parseProductListRequest("")
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidUrlQueryParam) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, domain.InvalidUrlQueryParamErrorCode, domain.ErrorCodeText(domain.InvalidUrlQueryParamErrorCode, h.Locale.Lang), domain.ErrInvalidUrlQueryParam)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusBadRequest, domain.ApiValidationErrorCode, domain.ErrorCodeText(domain.ApiValidationErrorCode, h.Locale.Lang), err)
+		return
+	}
+
+	h.Ctx.Output.Header("Content-Type", "text/csv; charset=utf-8")
+	h.Ctx.Output.Header("Content-Disposition", `attachment; filename="products.csv"`)
+
+	if err := h.UseCase.ExportProducts(h.Ctx, request, h.Ctx.ResponseWriter); err != nil {
+		// once rows are streamed the status is sent, the export just ends early
+		if h.Ctx.ResponseWriter.Started {
+			return
+		}
+
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, domain.RequestTimeoutErrorCode, domain.ErrorCodeText(domain.RequestTimeoutErrorCode, h.Locale.Lang), err)
+			return
+		}
+
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, domain.ServerErrorCode, domain.ErrorCodeText(domain.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+}
+
+// parseProductListRequest reads the filters shared by the product list and export.
+// The price filters are in currency, the base currency when empty.
+func (h *ProductHandler) parseProductListRequest(currency string) (domain.GetProductListRequest, error) {
+	var request domain.GetProductListRequest
+	request.Currency = currency
+	request.Search = h.Ctx.Input.Query("search")
+	request.Sort = h.Ctx.Input.Query("sort")
+	for _, v := range strings.Split(h.Ctx.Input.Query("product_category"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			request.ProductCategories = append(request.ProductCategories, v)
+		}
+	}
+
+	minPrice, maxPrice, inStock, err := parseProductFilterQuery(h.Ctx.Input.Query("min_price"), h.Ctx.Input.Query("max_price"), h.Ctx.Input.Query("in_stock"), currency)
+	if err != nil {
+		return request, err
+	}
+	request.MinPrice = minPrice
+	request.MaxPrice = maxPrice
+	request.InStock = inStock
+
+	//validate request
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+func parseProductFilterQuery(minPriceQuery, maxPriceQuery, inStockQuery, currency string) (minPrice *money.Money, maxPrice *money.Money, inStock bool, err error) {
+	if currency == "" {
+		currency = money.DefaultCurrency()
+	}
+
+	if minPriceQuery != "" {
+		parse, err := money.Parse(minPriceQuery, currency)
+		if err != nil {
+			return nil, nil, false, domain.ErrInvalidUrlQueryParam
+		}
+		minPrice = &parse
+	}
+
+	if maxPriceQuery != "" {
+		parse, err := money.Parse(maxPriceQuery, currency)
+		if err != nil {
+			return nil, nil, false, domain.ErrInvalidUrlQueryParam
+		}
+		maxPrice = &parse
+	}
+
+	if minPrice != nil && maxPrice != nil && minPrice.Amount > maxPrice.Amount {
+		return nil, nil, false, domain.ErrInvalidUrlQueryParam
+	}
+
+	if inStockQuery != "" {
+		inStock, err = strconv.ParseBool(inStockQuery)
+		if err != nil {
+			return nil, nil, false, domain.ErrInvalidUrlQueryParam
+		}
+	}
+
+	return minPrice, maxPrice, inStock, nil
+}
@@ -2,9 +2,26 @@ package product
 
 import (
 	"context"
+	"database/sql"
+	"github.com/online-store/internal/domain"
 	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
 )
 
 type Repository interface {
+	DB() *gorm.DB
 	FetchWithFilterAndPaginationAndOrderBy(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+	FetchWithFilterAndCursor(ctx context.Context, cursor string, pageSize int, query string, keys []database.CursorKey, model interface{}, args ...interface{}) (*database.Paginator, error)
+	FetchFacets(ctx context.Context, query string, model interface{}, args ...interface{}) error
+	GetProductByID(ctx context.Context, productID int) (domain.Product, error)
+	GetVariantsByProductID(ctx context.Context, productID int) ([]domain.ProductVariant, error)
+	GetVariantOptions(ctx context.Context, variantIDs []int) ([]domain.VariantOption, error)
+	InsertVariant(ctx context.Context, tx *gorm.DB, data domain.ProductVariant) (*domain.ProductVariant, error)
+	InsertVariantOptions(ctx context.Context, tx *gorm.DB, data []domain.VariantOption) error
+	GetCategories(ctx context.Context) ([]domain.Category, error)
+	UpsertProducts(ctx context.Context, tx *gorm.DB, data []domain.ProductImportRow) ([]domain.ProductUpsertResult, error)
+	GetWarehouseAvailability(ctx context.Context, productID int) ([]domain.WarehouseAvailability, error)
+	GetWarehouseStocks(ctx context.Context) ([]domain.WarehouseStock, error)
+	FetchProductRows(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ScanRows(rows *sql.Rows, dest interface{}) error
 }
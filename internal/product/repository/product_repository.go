@@ -2,11 +2,15 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"github.com/ahmetb/go-linq/v3"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
 	"github.com/online-store/internal/product"
 	"github.com/online-store/pkg/database"
 	"gorm.io/gorm"
 	"reflect"
+	"strings"
 )
 
 type ProductRepository struct {
@@ -17,6 +21,10 @@ func NewProductRepository(db *gorm.DB) product.Repository {
 	return &ProductRepository{db: db}
 }
 
+func (r ProductRepository) DB() *gorm.DB {
+	return r.db
+}
+
 func (r ProductRepository) FetchWithFilterAndPaginationAndOrderBy(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error) {
 	linq.From(args).Where(func(item interface{}) bool {
 		if reflect.TypeOf(item).Kind() == reflect.Slice {
@@ -31,3 +39,131 @@ func (r ProductRepository) FetchWithFilterAndPaginationAndOrderBy(ctx context.Co
 	}
 	return paginate, nil
 }
+
+func (r ProductRepository) FetchWithFilterAndCursor(ctx context.Context, cursor string, pageSize int, query string, keys []database.CursorKey, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	linq.From(args).Where(func(item interface{}) bool {
+		if reflect.TypeOf(item).Kind() == reflect.Slice {
+			return len(item.([]string)) != 0
+		}
+		return item != ""
+	}).ToSlice(&args)
+	paginate := database.NewPaginator(r.db, 0, pageSize, model).Raw(query, args, "", nil).Cursor(cursor, keys...)
+
+	if err := paginate.Find(ctx).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
+
+func (r ProductRepository) FetchFacets(ctx context.Context, query string, model interface{}, args ...interface{}) error {
+	return r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(query, args...).Scan(model).Error
+}
+
+func (r ProductRepository) GetProductByID(ctx context.Context, productID int) (domain.Product, error) {
+	var data domain.Product
+
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT 
+					p.id, p.sku, p."name", p.description, p.category_id, c."name" AS category_name, p.price, p.weight, p.length, p.width, p.height, p.stock, `+domain.ProductAvailableStockColumn+`, p.rating_average, p.rating_count, p.created_at, p.created_by, p.updated_at, p.updated_by, p.deleted_at, p.deleted_by 
+				FROM product p
+				JOIN category c ON p.category_id = c.id
+				WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL AND p.id = ?`, productID).Scan(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return data, gorm.ErrRecordNotFound
+	}
+	return data, result.Error
+}
+
+func (r ProductRepository) GetVariantsByProductID(ctx context.Context, productID int) ([]domain.ProductVariant, error) {
+	var data []domain.ProductVariant
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("product_id = ? AND deleted_at IS NULL", productID).Order("id").Find(&data).Error
+	return data, err
+}
+
+func (r ProductRepository) GetVariantOptions(ctx context.Context, variantIDs []int) ([]domain.VariantOption, error) {
+	var data []domain.VariantOption
+	if len(variantIDs) == 0 {
+		return data, nil
+	}
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("variant_id IN ?", variantIDs).Order("variant_id, name").Find(&data).Error
+	return data, err
+}
+
+func (r ProductRepository) InsertVariant(ctx context.Context, tx *gorm.DB, data domain.ProductVariant) (*domain.ProductVariant, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("ReservedStock", "UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r ProductRepository) InsertVariantOptions(ctx context.Context, tx *gorm.DB, data []domain.VariantOption) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).CreateInBatches(&data, 20).Error
+}
+
+func (r ProductRepository) GetCategories(ctx context.Context) ([]domain.Category, error) {
+	var data []domain.Category
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Table("category").Where("deleted_at IS NULL").Order("id").Find(&data).Error
+	return data, err
+}
+
+// UpsertProducts inserts the rows or updates the product with the same SKU,
+// reviving it when it was deleted. The stock is kept, it moves per warehouse.
+func (r ProductRepository) UpsertProducts(ctx context.Context, tx *gorm.DB, data []domain.ProductImportRow) ([]domain.ProductUpsertResult, error) {
+	var result []domain.ProductUpsertResult
+	if len(data) == 0 {
+		return result, nil
+	}
+
+	values := make([]string, 0, len(data))
+	args := make([]interface{}, 0, len(data)*9)
+	for _, v := range data {
+		values = append(values, `(?, ?, ?, ?, ?, ?, ?, ?, ?, 0, now(), 'System')`)
+		args = append(args, v.SKU, v.Name, v.Description, v.CategoryID, v.Price, v.Weight, v.Length, v.Width, v.Height)
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`INSERT INTO product (sku, "name", description, category_id, price, weight, length, width, height, stock, created_at, created_by)
+				VALUES `+strings.Join(values, ", ")+`
+				ON CONFLICT (sku) DO UPDATE SET
+					"name" = EXCLUDED."name", description = EXCLUDED.description, category_id = EXCLUDED.category_id, price = EXCLUDED.price,
+					weight = EXCLUDED.weight, length = EXCLUDED.length, width = EXCLUDED.width, height = EXCLUDED.height,
+					updated_at = now(), updated_by = 'System', deleted_at = NULL, deleted_by = NULL
+				RETURNING id, sku, (xmax = 0) AS inserted`, args...).Scan(&result).Error
+	return result, err
+}
+
+// GetWarehouseAvailability returns what can still be ordered of the product and
+// its variants in each active warehouse.
+func (r ProductRepository) GetWarehouseAvailability(ctx context.Context, productID int) ([]domain.WarehouseAvailability, error) {
+	var data []domain.WarehouseAvailability
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT 
+					w.id AS warehouse_id, w.code AS warehouse_code, w."name" AS warehouse_name, ws.variant_id, ws.stock - ws.reserved_stock AS available 
+				FROM warehouse_stock ws 
+				JOIN warehouse w ON w.id = ws.warehouse_id 
+				WHERE w.is_active AND w.deleted_at IS NULL AND ws.product_id = ? 
+				ORDER BY w.priority, w.id, ws.variant_id NULLS FIRST`, productID).Scan(&data).Error
+	return data, err
+}
+
+// GetWarehouseStocks returns the stock of every product sold without variants in
+// each warehouse.
+func (r ProductRepository) GetWarehouseStocks(ctx context.Context) ([]domain.WarehouseStock, error) {
+	var data []domain.WarehouseStock
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT 
+					ws.warehouse_id, ws.product_id, ws.variant_id, ws.stock, ws.reserved_stock, ws.updated_at, w.priority, w.code AS warehouse_code 
+				FROM warehouse_stock ws 
+				JOIN warehouse w ON w.id = ws.warehouse_id 
+				WHERE w.deleted_at IS NULL AND ws.variant_id IS NULL 
+				ORDER BY ws.product_id, w.priority, w.id`).Scan(&data).Error
+	return data, err
+}
+
+func (r ProductRepository) FetchProductRows(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(query, args...).Rows()
+}
+
+func (r ProductRepository) ScanRows(rows *sql.Rows, dest interface{}) error {
+	return r.db.ScanRows(rows, dest)
+}
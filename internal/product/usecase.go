@@ -1,11 +1,17 @@
 package product
 
 import (
+	"io"
+
 	beegoContext "github.com/beego/beego/v2/server/web/context"
 	"github.com/online-store/internal/domain"
-	"github.com/online-store/pkg/database"
 )
 
 type UseCase interface {
-	GetListProduct(beegoCtx *beegoContext.Context, req domain.GetProductListRequest) (*database.Paginator, error)
+	GetListProduct(beegoCtx *beegoContext.Context, req domain.GetProductListRequest) (*domain.ProductListResponse, error)
+	GetProductDetail(beegoCtx *beegoContext.Context, productIDReq string, currency string) (*domain.ProductDetail, error)
+	CreateProductVariant(beegoCtx *beegoContext.Context, req domain.CreateProductVariantRequest) (*domain.ProductVariant, error)
+	ImportProducts(beegoCtx *beegoContext.Context, req domain.ImportProductRequest) (*domain.ProductImportJob, error)
+	GetImportJob(beegoCtx *beegoContext.Context, jobID string) (*domain.ProductImportJob, error)
+	ExportProducts(beegoCtx *beegoContext.Context, req domain.GetProductListRequest, w io.Writer) error
 }
@@ -0,0 +1,519 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/beego/i18n"
+	validatorGo "github.com/go-playground/validator/v10"
+	"github.com/iancoleman/strcase"
+	"github.com/jackc/pgconn"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/money"
+	"github.com/online-store/pkg/spreadsheet"
+	"github.com/online-store/pkg/validator"
+	"gorm.io/gorm"
+)
+
+// ImportProducts validates every row of the file and upserts the valid ones by SKU.
+// Files larger than one batch are processed in the background, the returned job
+// can then be polled with GetImportJob.
+func (u ProductUseCase) ImportProducts(beegoCtx *beegoContext.Context, req domain.ImportProductRequest) (*domain.ProductImportJob, error) {
+	records, err := spreadsheet.Read(req.FileName, req.Content)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, domain.ErrInvalidImportFile
+	}
+
+	categories, err := u.productRepo.GetCategories(beegoCtx.Request.Context())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	warehouses, err := u.inventoryRepo.GetWarehouses(beegoCtx.Request.Context())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	rows, rowErrors, err := parseImportRecords(records, categories, warehouses, req.Lang)
+	if err != nil {
+		return nil, err
+	}
+
+	jobID, err := newImportJobID()
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	job := &domain.ProductImportJob{
+		ID:            jobID,
+		Status:        domain.ProductImportStatusRunning,
+		TotalRows:     len(rows) + len(rowErrors),
+		ProcessedRows: len(rowErrors),
+		Failed:        len(rowErrors),
+		Errors:        rowErrors,
+		CreatedAt:     time.Now(),
+	}
+
+	if len(rows) <= domain.ProductImportBatchSize {
+		u.runImport(beegoCtx.Request.Context(), job, rows, req.Lang)
+		return job, nil
+	}
+
+	u.saveImportJob(beegoCtx.Request.Context(), job)
+
+	// the request context ends with the response, the job outlives it
+	jobCopy := *job
+	jobCopy.Errors = append([]domain.ProductImportRowError{}, job.Errors...)
+	go u.runImport(context.Background(), &jobCopy, rows, req.Lang)
+
+	return job, nil
+}
+
+func (u ProductUseCase) GetImportJob(beegoCtx *beegoContext.Context, jobID string) (*domain.ProductImportJob, error) {
+	redisResult, err := u.cacheRepo.Fetch(beegoCtx.Request.Context(), fmt.Sprintf("%s:%s", domain.ProductImportJobKeyCache, jobID))
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var job = new(domain.ProductImportJob)
+	if err := jsoniter.UnmarshalFromString(*redisResult, job); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ExportProducts writes the products matching the list filters to w as CSV, in
+// the columns accepted by ImportProducts, one row per warehouse stocking the
+// product. Rows are streamed from the database.
+func (u ProductUseCase) ExportProducts(beegoCtx *beegoContext.Context, req domain.GetProductListRequest, w io.Writer) error {
+	stocks, err := u.productRepo.GetWarehouseStocks(beegoCtx.Request.Context())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	stocksByProduct := make(map[int][]domain.WarehouseStock)
+	for _, v := range stocks {
+		stocksByProduct[v.ProductID] = append(stocksByProduct[v.ProductID], v)
+	}
+
+	query, args, _, sort := productListQuery(req)
+
+	rows, err := u.productRepo.FetchProductRows(beegoCtx.Request.Context(), query+" "+productSortOrderBy[sort], args...)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+	defer rows.Close()
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(domain.ProductImportColumns); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var data domain.Product
+		if err := u.productRepo.ScanRows(rows, &data); err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return err
+		}
+
+		sku := ""
+		if data.SKU != nil {
+			sku = *data.SKU
+		}
+
+		// a product no warehouse stocks yet is exported with no stock
+		productStocks := stocksByProduct[data.ID]
+		if len(productStocks) == 0 {
+			productStocks = []domain.WarehouseStock{{}}
+		}
+
+		for _, v := range productStocks {
+			if err := writer.Write([]string{
+				sku,
+				data.Name,
+				data.Description,
+				data.CategoryName,
+				data.Price.Decimal(),
+				strconv.Itoa(v.Stock),
+				v.WarehouseCode,
+				strconv.Itoa(data.Weight),
+				strconv.Itoa(data.Length),
+				strconv.Itoa(data.Width),
+				strconv.Itoa(data.Height),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// runImport upserts the rows batch by batch and saves the progress of the job
+// after each one. A failing batch is retried row by row so the report points at
+// the rows that could not be saved. A panic marks the job failed instead of
+// leaving it running.
+func (u ProductUseCase) runImport(ctx context.Context, job *domain.ProductImportJob, rows []domain.ProductImportRow, lang string) {
+	defer func() {
+		if r := recover(); r != nil {
+			u.zapLogger.Error(fmt.Errorf("product import %s: %v", job.ID, r))
+
+			finishedAt := time.Now()
+			job.Status = domain.ProductImportStatusFailed
+			job.FinishedAt = &finishedAt
+			u.saveImportJob(ctx, job)
+		}
+	}()
+
+	for start := 0; start < len(rows); start += domain.ProductImportBatchSize {
+		end := start + domain.ProductImportBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batch := rows[start:end]
+
+		results, err := u.upsertProducts(ctx, batch)
+		if err != nil {
+			u.zapLogger.Error(err)
+
+			results = nil
+			for _, row := range batch {
+				result, err := u.upsertProducts(ctx, []domain.ProductImportRow{row})
+				if err != nil {
+					job.Failed++
+					job.Errors = append(job.Errors, domain.ProductImportRowError{
+						Row:    row.Row,
+						SKU:    row.SKU,
+						Errors: []domain.ImportFieldError{{Field: "row", Description: importSaveErrorText(err, lang)}},
+					})
+					continue
+				}
+				results = append(results, result...)
+			}
+		}
+
+		productIDs := make([]int, 0, len(results))
+		for _, v := range results {
+			if v.Inserted {
+				job.Inserted++
+			} else {
+				job.Updated++
+			}
+			productIDs = append(productIDs, v.ID)
+		}
+		u.stockAlertUC.CheckStock(ctx, productIDs)
+
+		//the listed products changed
+		if err := u.cacheRepo.Deletes(ctx, []string{domain.ProductListKeyCache}); err != nil {
+			u.zapLogger.Error(err)
+		}
+
+		job.ProcessedRows += len(batch)
+		if end < len(rows) {
+			u.saveImportJob(ctx, job)
+		}
+	}
+
+	sort.Slice(job.Errors, func(i, j int) bool {
+		return job.Errors[i].Row < job.Errors[j].Row
+	})
+
+	finishedAt := time.Now()
+	job.Status = domain.ProductImportStatusCompleted
+	job.FinishedAt = &finishedAt
+	u.saveImportJob(ctx, job)
+}
+
+// upsertProducts upserts the products of the rows and sets the stock of each row
+// in its warehouse. The result holds one entry per row, a SKU stocked in several
+// warehouses only counts as inserted once.
+func (u ProductUseCase) upsertProducts(ctx context.Context, rows []domain.ProductImportRow) ([]domain.ProductUpsertResult, error) {
+	var results []domain.ProductUpsertResult
+
+	// a product can't be upserted twice by the same statement
+	var products []domain.ProductImportRow
+	seenSKU := make(map[string]bool, len(rows))
+	for _, v := range rows {
+		if !seenSKU[v.SKU] {
+			seenSKU[v.SKU] = true
+			products = append(products, v)
+		}
+	}
+
+	//start transaction
+	errs := u.productRepo.DB().Transaction(func(tx *gorm.DB) error {
+		upserted, err := u.productRepo.UpsertProducts(ctx, tx, products)
+		if err != nil {
+			return err
+		}
+
+		bySKU := make(map[string]domain.ProductUpsertResult, len(upserted))
+		for _, v := range upserted {
+			bySKU[v.SKU] = v
+		}
+
+		var movements []domain.InventoryMovement
+		results = make([]domain.ProductUpsertResult, 0, len(rows))
+		for _, row := range rows {
+			result := bySKU[row.SKU]
+			results = append(results, result)
+
+			// later rows of the SKU update the product inserted by the first one
+			result.Inserted = false
+			bySKU[row.SKU] = result
+
+			current, err := u.inventoryRepo.GetWarehouseStock(ctx, tx, row.WarehouseID, result.ID, nil)
+			if err != nil {
+				return err
+			}
+
+			delta := row.Stock - current.Stock
+			if delta == 0 {
+				continue
+			}
+
+			affected, err := u.inventoryRepo.AddWarehouseStock(ctx, tx, row.WarehouseID, result.ID, nil, delta)
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return domain.ErrInsufficientStock
+			}
+
+			//record the stock changes in the inventory ledger
+			warehouseID := row.WarehouseID
+			movements = append(movements, domain.InventoryMovement{
+				ProductID:   result.ID,
+				WarehouseID: &warehouseID,
+				Quantity:    delta,
+				Reason:      domain.InventoryReasonImport,
+				Actor:       domain.InventoryActorAdmin,
+				CreatedAt:   time.Now(),
+			})
+		}
+
+		return u.inventoryRepo.InsertMovements(ctx, tx, movements)
+	})
+
+	return results, errs
+}
+
+func (u ProductUseCase) saveImportJob(ctx context.Context, job *domain.ProductImportJob) {
+	key := fmt.Sprintf("%s:%s", domain.ProductImportJobKeyCache, job.ID)
+	if err := u.cacheRepo.Save(ctx, key, *job, domain.ProductImportJobExpiration); err != nil {
+		u.zapLogger.Error(err)
+	}
+}
+
+// parseImportRecords maps the records to rows using the header, and validates
+// each of them. Rows with errors are reported instead of returned, a header
+// missing required columns makes the whole file invalid.
+func parseImportRecords(records [][]string, categories []domain.Category, warehouses []domain.Warehouse, lang string) ([]domain.ProductImportRow, []domain.ProductImportRowError, error) {
+	if len(records) < 2 {
+		return nil, nil, domain.ErrInvalidImportFile
+	}
+
+	columns := make(map[string]int)
+	for i, v := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(v))] = i
+	}
+	for _, v := range domain.ProductImportColumns {
+		if _, ok := columns[v]; !ok && !domain.IsOptionalImportColumn(v) {
+			return nil, nil, domain.ErrInvalidImportFile
+		}
+	}
+
+	categoryIDs := make(map[string]int, len(categories))
+	for _, v := range categories {
+		categoryIDs[strings.ToLower(v.Name)] = v.ID
+	}
+
+	// rows without a warehouse stock the most preferred active one
+	warehouseIDs := make(map[string]int, len(warehouses))
+	for _, v := range warehouses {
+		warehouseIDs[strings.ToLower(v.Code)] = v.ID
+		if _, ok := warehouseIDs[""]; !ok && v.IsActive {
+			warehouseIDs[""] = v.ID
+		}
+	}
+
+	var (
+		rows      []domain.ProductImportRow
+		rowErrors []domain.ProductImportRowError
+		skuRows   = make(map[string]int)
+	)
+	for i, record := range records[1:] {
+		if isEmptyRecord(record) {
+			continue
+		}
+
+		value := func(column string) string {
+			index, ok := columns[column]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		// row numbers follow the spreadsheet, the header is row 1
+		row := domain.ProductImportRow{
+			Row:         i + 2,
+			SKU:         value("sku"),
+			Name:        value("name"),
+			Description: value("description"),
+			Category:    value("category"),
+			Warehouse:   value("warehouse"),
+		}
+
+		var fieldErrors []domain.ImportFieldError
+
+		price, err := money.Parse(value("price"), money.DefaultCurrency())
+		if err != nil {
+			fieldErrors = append(fieldErrors, domain.ImportFieldError{Field: "price", Description: i18n.Tr(lang, "message.importNotNumber", "price")})
+		}
+		row.Price = price
+
+		stock, err := parseImportInteger(value("stock"))
+		if err != nil {
+			fieldErrors = append(fieldErrors, domain.ImportFieldError{Field: "stock", Description: i18n.Tr(lang, "message.importNotInteger", "stock")})
+		}
+		row.Stock = stock
+
+		//the weight and the dimensions are optional, left empty they are zero
+		for _, v := range []struct {
+			column string
+			target *int
+		}{{"weight", &row.Weight}, {"length", &row.Length}, {"width", &row.Width}, {"height", &row.Height}} {
+			if value(v.column) == "" {
+				continue
+			}
+			number, err := parseImportInteger(value(v.column))
+			if err != nil {
+				fieldErrors = append(fieldErrors, domain.ImportFieldError{Field: v.column, Description: i18n.Tr(lang, "message.importNotInteger", v.column)})
+			}
+			*v.target = number
+		}
+
+		fieldErrors = append(fieldErrors, validationErrors(validator.Validate.ValidateStruct(&row), lang)...)
+
+		if row.Category != "" {
+			categoryID, ok := categoryIDs[strings.ToLower(row.Category)]
+			if !ok {
+				fieldErrors = append(fieldErrors, domain.ImportFieldError{Field: "category", Description: i18n.Tr(lang, "message.importUnknownCategory", row.Category)})
+			}
+			row.CategoryID = categoryID
+		}
+
+		warehouseID, ok := warehouseIDs[strings.ToLower(row.Warehouse)]
+		if !ok {
+			fieldErrors = append(fieldErrors, domain.ImportFieldError{Field: "warehouse", Description: i18n.Tr(lang, "message.importUnknownWarehouse", row.Warehouse)})
+		}
+		row.WarehouseID = warehouseID
+
+		if row.SKU != "" {
+			key := fmt.Sprintf("%s|%d", row.SKU, row.WarehouseID)
+			if previous, ok := skuRows[key]; ok {
+				fieldErrors = append(fieldErrors, domain.ImportFieldError{Field: "sku", Description: i18n.Tr(lang, "message.importDuplicateSku", previous)})
+			} else {
+				skuRows[key] = row.Row
+			}
+		}
+
+		if len(fieldErrors) > 0 {
+			rowErrors = append(rowErrors, domain.ProductImportRowError{Row: row.Row, SKU: row.SKU, Errors: fieldErrors})
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 && len(rowErrors) == 0 {
+		return nil, nil, domain.ErrInvalidImportFile
+	}
+
+	return rows, rowErrors, nil
+}
+
+// validationErrors translates validator errors the same way API responses do.
+func validationErrors(err error, lang string) []domain.ImportFieldError {
+	fields, ok := err.(validatorGo.ValidationErrors)
+	if !ok {
+		return nil
+	}
+
+	var result []domain.ImportFieldError
+	trans, _ := validator.Validate.GetTranslator(lang)
+	for _, v := range fields {
+		fieldName := strcase.ToSnake(v.Field())
+		description := v.Error()
+		if trans != nil {
+			description = strings.ReplaceAll(v.Translate(trans), v.Field(), fieldName)
+		}
+		result = append(result, domain.ImportFieldError{Field: fieldName, Description: description})
+	}
+	return result
+}
+
+func importSaveErrorText(err error, lang string) string {
+	if errors.Is(err, domain.ErrInsufficientStock) {
+		return i18n.Tr(lang, "message.errorInsufficientStock")
+	}
+	if pgerr, ok := err.(*pgconn.PgError); ok {
+		return i18n.Tr(lang, "message.importRowNotSaved") + " " + pgerr.Message
+	}
+	return i18n.Tr(lang, "message.importRowNotSaved")
+}
+
+// parseImportInteger also accepts whole numbers written as decimals ("10.0"),
+// which is how spreadsheets often store them.
+func parseImportInteger(value string) (int, error) {
+	if v, err := strconv.Atoi(value); err == nil {
+		return v, nil
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v != float64(int(v)) {
+		return 0, strconv.ErrSyntax
+	}
+	return int(v), nil
+}
+
+func isEmptyRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func newImportJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
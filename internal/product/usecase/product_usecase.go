@@ -4,79 +4,549 @@ import (
 	"context"
 	"fmt"
 	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/online-store/internal/currency"
 	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/inventory"
+	"github.com/online-store/internal/media"
 	"github.com/online-store/internal/product"
+	"github.com/online-store/internal/recommendation"
+	"github.com/online-store/internal/stockalert"
 	"github.com/online-store/pkg/cache"
 	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
 	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
+// productSortOrderBy whitelists the ORDER BY clauses the sort query param can select.
+var productSortOrderBy = map[string]string{
+	domain.ProductSortPriceAsc:  "ORDER BY p.price ASC, p.id",
+	domain.ProductSortPriceDesc: "ORDER BY p.price DESC, p.id",
+	domain.ProductSortNewest:    "ORDER BY p.created_at DESC, p.id",
+	domain.ProductSortName:      `ORDER BY p."name" ASC, p.id`,
+	domain.ProductSortRelevance: "ORDER BY rank DESC, p.created_at DESC, p.id",
+}
+
+// productSortCursorKeys are the keysets matching productSortOrderBy in cursor mode.
+var productSortCursorKeys = map[string][]database.CursorKey{
+	domain.ProductSortPriceAsc:  {{Column: "price", Field: "Price"}, {Column: "id", Field: "ID"}},
+	domain.ProductSortPriceDesc: {{Column: "price", Field: "Price", Desc: true}, {Column: "id", Field: "ID"}},
+	domain.ProductSortNewest:    {{Column: "created_at", Field: "CreatedAt", Desc: true}, {Column: "id", Field: "ID"}},
+	domain.ProductSortName:      {{Column: "name", Field: "Name"}, {Column: "id", Field: "ID"}},
+	domain.ProductSortRelevance: {{Column: "rank", Field: "Rank", Desc: true}, {Column: "created_at", Field: "CreatedAt", Desc: true}, {Column: "id", Field: "ID"}},
+}
+
 type ProductUseCase struct {
-	productRepo product.Repository
-	cacheRepo   cache.RedisRepository
-	zapLogger   zaplogger.Logger
+	productRepo      product.Repository
+	inventoryRepo    inventory.Repository
+	mediaUseCase     media.UseCase
+	stockAlertUC     stockalert.UseCase
+	currencyUC       currency.UseCase
+	recommendationUC recommendation.UseCase
+	cacheRepo        cache.RedisRepository
+	zapLogger        zaplogger.Logger
 }
 
-func NewProductUseCase(productRepo product.Repository, cacheRepo cache.RedisRepository, zapLogger zaplogger.Logger) product.UseCase {
+func NewProductUseCase(productRepo product.Repository, inventoryRepo inventory.Repository, mediaUseCase media.UseCase, stockAlertUC stockalert.UseCase, currencyUC currency.UseCase, recommendationUC recommendation.UseCase, cacheRepo cache.RedisRepository, zapLogger zaplogger.Logger) product.UseCase {
 	return &ProductUseCase{
-		productRepo: productRepo,
-		cacheRepo:   cacheRepo,
-		zapLogger:   zapLogger,
+		productRepo:      productRepo,
+		inventoryRepo:    inventoryRepo,
+		mediaUseCase:     mediaUseCase,
+		stockAlertUC:     stockAlertUC,
+		currencyUC:       currencyUC,
+		recommendationUC: recommendationUC,
+		cacheRepo:        cacheRepo,
+		zapLogger:        zapLogger,
 	}
 }
 
-func (u ProductUseCase) GetListProduct(beegoCtx *beegoContext.Context, req domain.GetProductListRequest) (*database.Paginator, error) {
+func (u ProductUseCase) GetListProduct(beegoCtx *beegoContext.Context, req domain.GetProductListRequest) (*domain.ProductListResponse, error) {
 	var entities []domain.Product
-	cacheKey := fmt.Sprintf("%s:%s", domain.ProductKeyCache, fmt.Sprintf("%s|%d|%d|%s|%s", "ALL", req.Page, req.Limit, req.ProductCategory, req.Search))
+
+	//the list is cached in the base currency and converted for the response
+	rate, err := u.currencyUC.GetExchangeRate(beegoCtx.Request.Context(), req.Currency)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	if req.MinPrice != nil {
+		if req.MinPrice, err = basePrice(rate, *req.MinPrice); err != nil {
+			return nil, err
+		}
+	}
+	if req.MaxPrice != nil {
+		if req.MaxPrice, err = basePrice(rate, *req.MaxPrice); err != nil {
+			return nil, err
+		}
+	}
+
+	cacheKey := domain.ProductListKeyCache + fmt.Sprintf("%d|%d|%t|%s|%s|%s|%s|%s|%t|%s", req.Page, req.Limit, req.CursorMode, req.Cursor,
+		strings.Join(req.ProductCategories, ","), req.Search, formatPrice(req.MinPrice), formatPrice(req.MaxPrice), req.InStock, req.Sort)
 
 	//check cache
 	redisResult, err := u.cacheRepo.Fetch(beegoCtx.Request.Context(), cacheKey)
 	if err != nil {
-		query := `SELECT 
-					p.id, p."name", p.description, p.category_id, c."name" AS category_name, p.price, p.stock, p.created_at, p.created_by, p.updated_at, p.updated_by, p.deleted_at, p.deleted_by 
-				FROM product p
-				JOIN category c ON p.category_id = c.id
-				WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL`
-		countQuery := `SELECT COUNT(*) from product WHERE 1=1`
+		query, args, tsQuery, sort := productListQuery(req)
+		countQuery := `SELECT COUNT(*) FROM (` + query + `) AS t`
 
-		if req.Search != "" {
-			query += ` AND p."name" LIKE = ?`
-			countQuery += ` AND p."name" LIKE = ?`
+		var data *database.Paginator
+		if req.CursorMode {
+			data, err = u.productRepo.FetchWithFilterAndCursor(
+				beegoCtx.Request.Context(),
+				req.Cursor,
+				req.Limit,
+				query,
+				productSortCursorKeys[sort],
+				&entities,
+				args...,
+			)
+		} else {
+			data, err = u.productRepo.FetchWithFilterAndPaginationAndOrderBy(
+				beegoCtx.Request.Context(),
+				req.Page,
+				req.Limit,
+				query,
+				countQuery,
+				productSortOrderBy[sort],
+				&entities,
+				args...,
+			)
 		}
 
-		if req.ProductCategory != "" {
-			query += ` AND c."name" LIKE = ?`
-			countQuery += ` AND c."name" LIKE = ?`
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return nil, err
 		}
 
-		data, err := u.productRepo.FetchWithFilterAndPaginationAndOrderBy(
-			context.Background(),
-			req.Page,
-			req.Limit,
-			query,
-			countQuery,
-			"",
-			&entities,
-		)
+		productIDs := make([]int, 0, len(entities))
+		for _, v := range entities {
+			productIDs = append(productIDs, v.ID)
+		}
 
+		images, err := u.mediaUseCase.GetProductImages(beegoCtx.Request.Context(), productIDs)
 		if err != nil {
 			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
 			return nil, err
 		}
+		for i := range entities {
+			entities[i].Images = images[entities[i].ID]
+		}
 
-		err = u.cacheRepo.Save(beegoCtx.Request.Context(), cacheKey, *data, domain.HalfCacheExpiration)
+		facets, err := u.getProductFacets(beegoCtx.Request.Context(), req, tsQuery)
 		if err != nil {
 			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+			return nil, err
+		}
+
+		result := &domain.ProductListResponse{
+			Paginator: *data,
+			Facets:    *facets,
+		}
+
+		err = u.cacheRepo.Save(beegoCtx.Request.Context(), cacheKey, *result, domain.HalfCacheExpiration)
+		if err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+
+		if err := convertProductList(result, rate); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	var result = new(domain.ProductListResponse)
+	result.Records = &entities
+	if err := jsoniter.UnmarshalFromString(*redisResult, result); err != nil {
+		return nil, err
+	}
+	if err := convertProductList(result, rate); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (u ProductUseCase) GetProductDetail(beegoCtx *beegoContext.Context, productIDReq string, currency string) (*domain.ProductDetail, error) {
+	productID, err := strconv.Atoi(productIDReq)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	rate, err := u.currencyUC.GetExchangeRate(beegoCtx.Request.Context(), currency)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	data, err := u.productRepo.GetProductByID(beegoCtx.Request.Context(), productID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	images, err := u.mediaUseCase.GetProductImages(beegoCtx.Request.Context(), []int{productID})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	data.Images = images[productID]
+
+	variants, err := u.productRepo.GetVariantsByProductID(beegoCtx.Request.Context(), productID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	variantIDs := make([]int, 0, len(variants))
+	for _, v := range variants {
+		variantIDs = append(variantIDs, v.ID)
+	}
+
+	options, err := u.productRepo.GetVariantOptions(beegoCtx.Request.Context(), variantIDs)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	optionsByVariant := make(map[int][]domain.VariantOption)
+	for _, v := range options {
+		optionsByVariant[v.VariantID] = append(optionsByVariant[v.VariantID], v)
+	}
+
+	availability, err := u.productRepo.GetWarehouseAvailability(beegoCtx.Request.Context(), productID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	related, err := u.recommendationUC.GetRelatedProducts(beegoCtx.Request.Context(), []int{productID})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	for i, v := range related {
+		if related[i].Price, err = rate.Convert(v.Price); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &domain.ProductDetail{
+		Product:      data,
+		Options:      []domain.ProductOption{},
+		Variants:     []domain.ProductVariant{},
+		Availability: append([]domain.WarehouseAvailability{}, availability...),
+		Related:      append([]domain.RelatedProduct{}, related...),
+	}
+
+	// options only list the values of variants that can still be ordered
+	optionIndex := make(map[string]int)
+	seenValue := make(map[string]bool)
+	if data.Price, err = rate.Convert(data.Price); err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		if v.Price, err = rate.ConvertPtr(v.Price); err != nil {
+			return nil, err
+		}
+		v.EffectivePrice = data.Price
+		if v.Price != nil {
+			v.EffectivePrice = *v.Price
+		}
+		v.Options = optionsByVariant[v.ID]
+		result.Variants = append(result.Variants, v)
+
+		if v.AvailableStock() <= 0 {
+			continue
+		}
+		for _, option := range v.Options {
+			i, ok := optionIndex[option.Name]
+			if !ok {
+				i = len(result.Options)
+				optionIndex[option.Name] = i
+				result.Options = append(result.Options, domain.ProductOption{Name: option.Name})
+			}
+			if key := option.Name + "|" + option.Value; !seenValue[key] {
+				seenValue[key] = true
+				result.Options[i].Values = append(result.Options[i].Values, option.Value)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (u ProductUseCase) CreateProductVariant(beegoCtx *beegoContext.Context, req domain.CreateProductVariantRequest) (*domain.ProductVariant, error) {
+	var (
+		data *domain.ProductVariant
+		err  error
+	)
+
+	//start transaction
+	errs := u.productRepo.DB().Transaction(func(tx *gorm.DB) error {
+		data, err = u.productRepo.InsertVariant(beegoCtx.Request.Context(), tx, domain.ProductVariant{
+			ProductID: req.ProductID,
+			SKU:       req.SKU,
+			Price:     req.Price,
+			Barcode:   req.Barcode,
+			CreatedAt: time.Now(),
+			CreatedBy: "System",
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range req.Options {
+			req.Options[i].VariantID = data.ID
 		}
+		if err = u.productRepo.InsertVariantOptions(beegoCtx.Request.Context(), tx, req.Options); err != nil {
+			return err
+		}
+		data.Options = req.Options
+
+		//the initial stock of the SKU is stored in a warehouse and opens its inventory ledger
+		if req.Stock != 0 {
+			warehouseID := 0
+			if req.WarehouseID != nil {
+				warehouseID = *req.WarehouseID
+			} else if warehouseID, err = u.inventoryRepo.GetDefaultWarehouseID(beegoCtx.Request.Context(), tx); err != nil {
+				return err
+			}
+
+			if _, err = u.inventoryRepo.AddWarehouseStock(beegoCtx.Request.Context(), tx, warehouseID, data.ProductID, &data.ID, req.Stock); err != nil {
+				return err
+			}
+			data.Stock = req.Stock
 
-		return data, nil
+			_, err = u.inventoryRepo.InsertMovement(beegoCtx.Request.Context(), tx, domain.InventoryMovement{
+				ProductID:   data.ProductID,
+				VariantID:   &data.ID,
+				WarehouseID: &warehouseID,
+				Quantity:    data.Stock,
+				Reason:      domain.InventoryReasonOpeningBalance,
+				Actor:       domain.InventoryActorAdmin,
+				CreatedAt:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if errs != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		pgerr, ok := errs.(*pgconn.PgError)
+		if !ok {
+			return nil, errs
+		}
+		switch pgerr.Code {
+		case domain.PgCodeForeignKeyConstraint:
+			return nil, domain.ErrForeignKeyConstraint
+		case domain.PgCodeUniqueConstraint:
+			return nil, domain.ErrUniqueConstraint
+		default:
+			return nil, errs
+		}
 	}
 
-	var paginator = new(database.Paginator)
-	if err := jsoniter.UnmarshalFromString(*redisResult, paginator); err != nil {
+	//the new SKU may bring the product back in stock
+	u.stockAlertUC.CheckStockInBackground([]int{data.ProductID})
+
+	return data, nil
+}
+
+// getProductFacets counts products per category and per price bucket. Each facet
+// ignores its own filter so the client can still see the alternatives it may pick.
+func (u ProductUseCase) getProductFacets(ctx context.Context, req domain.GetProductListRequest, tsQuery string) (*domain.ProductFacets, error) {
+	facets := &domain.ProductFacets{
+		Categories:   []domain.CategoryFacet{},
+		PriceBuckets: []domain.PriceBucketFacet{},
+	}
+
+	filter, args := productFilter(req, tsQuery, false, true)
+	err := u.productRepo.FetchFacets(
+		ctx,
+		`SELECT c."name" AS category_name, COUNT(*) AS count
+				FROM product p
+				JOIN category c ON p.category_id = c.id
+				WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL`+filter+`
+				GROUP BY c."name"
+				ORDER BY c."name"`,
+		&facets.Categories,
+		args...,
+	)
+	if err != nil {
 		return nil, err
 	}
 
-	return paginator, nil
+	bucket := "CASE"
+	for i := len(domain.ProductPriceBuckets) - 1; i >= 0; i-- {
+		bucket += fmt.Sprintf(" WHEN p.price >= %d THEN %d", money.FromMajor(domain.ProductPriceBuckets[i], money.DefaultCurrency()).Amount, i)
+	}
+	bucket += " END"
+
+	var counts []domain.PriceBucketFacet
+	filter, args = productFilter(req, tsQuery, true, false)
+	err = u.productRepo.FetchFacets(
+		ctx,
+		`SELECT `+bucket+` AS bucket, COUNT(*) AS count
+				FROM product p
+				JOIN category c ON p.category_id = c.id
+				WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL`+filter+`
+				GROUP BY bucket`,
+		&counts,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	countByBucket := make(map[int]int64)
+	for _, v := range counts {
+		countByBucket[v.Bucket] = v.Count
+	}
+	for i, min := range domain.ProductPriceBuckets {
+		facet := domain.PriceBucketFacet{Bucket: i, Min: money.FromMajor(min, money.DefaultCurrency()), Count: countByBucket[i]}
+		if i+1 < len(domain.ProductPriceBuckets) {
+			max := money.FromMajor(domain.ProductPriceBuckets[i+1], money.DefaultCurrency())
+			facet.Max = &max
+		}
+		facets.PriceBuckets = append(facets.PriceBuckets, facet)
+	}
+
+	return facets, nil
+}
+
+// productListQuery builds the product list query for the filters of req, along
+// with the prefix tsquery of the search and the sort to apply.
+func productListQuery(req domain.GetProductListRequest) (string, []interface{}, string, string) {
+	var args []interface{}
+
+	rank := `0 AS rank`
+	tsQuery := buildPrefixTsQuery(req.Search)
+	if tsQuery != "" {
+		rank = `ts_rank(p.search_vector, to_tsquery('simple', ?)) + similarity(p."name", ?) AS rank`
+		args = append(args, tsQuery, req.Search)
+	}
+
+	filter, filterArgs := productFilter(req, tsQuery, true, true)
+	args = append(args, filterArgs...)
+
+	query := `SELECT 
+				p.id, p.sku, p."name", p.description, p.category_id, c."name" AS category_name, p.price, p.weight, p.length, p.width, p.height, p.stock, ` + domain.ProductAvailableStockColumn + `, p.rating_average, p.rating_count, p.created_at, p.created_by, p.updated_at, p.updated_by, p.deleted_at, p.deleted_by, ` + rank + ` 
+			FROM product p
+			JOIN category c ON p.category_id = c.id
+			WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL` + filter
+
+	sort := req.Sort
+	if sort == "" {
+		sort = domain.ProductSortNewest
+		if tsQuery != "" {
+			sort = domain.ProductSortRelevance
+		}
+	}
+
+	return query, args, tsQuery, sort
+}
+
+// productFilter builds the WHERE conditions of the product list, withCategory and
+// withPrice let the facet queries leave out their own filter.
+func productFilter(req domain.GetProductListRequest, tsQuery string, withCategory, withPrice bool) (string, []interface{}) {
+	var (
+		filter string
+		args   []interface{}
+	)
+
+	if tsQuery != "" {
+		// full-text match with prefix matching, trigram similarity on the name tolerates typos
+		filter += ` AND (p.search_vector @@ to_tsquery('simple', ?) OR p."name" % ?)`
+		args = append(args, tsQuery, req.Search)
+	}
+
+	if withCategory && len(req.ProductCategories) > 0 {
+		categories := make([]string, 0, len(req.ProductCategories))
+		for _, v := range req.ProductCategories {
+			categories = append(categories, strings.ToLower(v))
+		}
+		filter += ` AND LOWER(c."name") IN ?`
+		args = append(args, categories)
+	}
+
+	if withPrice && req.MinPrice != nil {
+		filter += ` AND p.price >= ?`
+		args = append(args, *req.MinPrice)
+	}
+
+	if withPrice && req.MaxPrice != nil {
+		filter += ` AND p.price <= ?`
+		args = append(args, *req.MaxPrice)
+	}
+
+	if req.InStock {
+		filter += ` AND p.stock > 0`
+	}
+
+	return filter, args
+}
+
+// convertProductList converts the prices of the products and the price facets of
+// the list from the base currency to the currency of rate.
+func convertProductList(result *domain.ProductListResponse, rate domain.ExchangeRate) error {
+	var err error
+	if products, ok := result.Records.(*[]domain.Product); ok {
+		for i := range *products {
+			if (*products)[i].Price, err = rate.Convert((*products)[i].Price); err != nil {
+				return err
+			}
+		}
+	}
+	for i, v := range result.Facets.PriceBuckets {
+		if result.Facets.PriceBuckets[i].Min, err = rate.Convert(v.Min); err != nil {
+			return err
+		}
+		if result.Facets.PriceBuckets[i].Max, err = rate.ConvertPtr(v.Max); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// basePrice converts a price filter given in the currency of rate to the base
+// currency the prices are stored in.
+func basePrice(rate domain.ExchangeRate, price money.Money) (*money.Money, error) {
+	if price.Currency == rate.Currency {
+		var err error
+		if price, err = rate.ToBase(price); err != nil {
+			return nil, err
+		}
+	}
+	return &price, nil
+}
+
+func formatPrice(price *money.Money) string {
+	if price == nil {
+		return ""
+	}
+	return price.Decimal()
+}
+
+// buildPrefixTsQuery turns free text into a to_tsquery expression where every
+// word must match, and the words may be prefixes ("lap mou" -> "lap:* & mou:*").
+func buildPrefixTsQuery(search string) string {
+	words := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i := range words {
+		words[i] += ":*"
+	}
+	return strings.Join(words, " & ")
 }
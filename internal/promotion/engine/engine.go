@@ -0,0 +1,281 @@
+// Package engine evaluates the automatic promotions over cart and order lines.
+// It only computes, loading the promotions and the lines is up to the caller.
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/money"
+)
+
+// Evaluate applies the promotions to the lines by ascending priority. A promotion
+// that is not stackable is applied only when nothing else is, and nothing else is
+// applied after it. Units discounted by a buy x get y or a bundle promotion are not
+// discounted again by the next promotions, and the total never goes below zero.
+// The lines must all be priced in the same currency.
+func Evaluate(promotions []domain.Promotion, lines []domain.PromotionLine) (domain.PromotionResult, error) {
+	sorted := append([]domain.Promotion{}, promotions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority < sorted[j].Priority
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	// free holds the units of each line no promotion has discounted yet
+	free := make([]int, len(lines))
+	subtotal := money.Money{}
+	for i, v := range lines {
+		free[i] = v.Quantity
+		amount, err := v.UnitPrice.Mul(int64(v.Quantity))
+		if err != nil {
+			return domain.PromotionResult{}, err
+		}
+		if subtotal, err = subtotal.Add(amount); err != nil {
+			return domain.PromotionResult{}, err
+		}
+	}
+	currency := subtotal.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency()
+	}
+
+	result := domain.PromotionResult{
+		Subtotal: money.New(subtotal.Amount, currency),
+		Discount: money.Zero(currency),
+		Applied:  []domain.AppliedPromotion{},
+		Lines:    make([]money.Money, len(lines)),
+	}
+	for i := range result.Lines {
+		result.Lines[i] = money.Zero(currency)
+	}
+
+	exclusive := false
+	for _, p := range sorted {
+		if exclusive || (!p.Stackable && len(result.Applied) > 0) {
+			continue
+		}
+
+		var (
+			discount int64
+			used     []int
+			err      error
+		)
+		switch p.RuleType {
+		case domain.PromotionBuyXGetY:
+			discount, used = buyXGetY(p, lines, free)
+		case domain.PromotionBundle:
+			discount, used = bundle(p, lines, free)
+		case domain.PromotionTiered:
+			discount, used, err = tiered(p, lines, free, currency)
+		}
+		if err != nil {
+			return domain.PromotionResult{}, err
+		}
+
+		if remaining := result.Subtotal.Amount - result.Discount.Amount; discount > remaining {
+			discount = remaining
+		}
+		if discount <= 0 {
+			continue
+		}
+
+		// the discount is split over the units it was taken on, the used ones or the
+		// free targeted ones for a tiered promotion
+		var productIDs []int
+		weights := make([]int64, len(lines))
+		for i, v := range used {
+			if v == 0 {
+				continue
+			}
+			weights[i] = lines[i].UnitPrice.Amount * int64(v)
+			free[i] -= v
+			productIDs = appendUnique(productIDs, lines[i].ProductID)
+		}
+		if productIDs == nil {
+			for i, v := range lines {
+				if p.Targets(v) && free[i] > 0 {
+					weights[i] = v.UnitPrice.Amount * int64(free[i])
+					productIDs = appendUnique(productIDs, v.ProductID)
+				}
+			}
+		}
+		parts, err := money.New(discount, currency).Allocate(weights)
+		if err != nil {
+			return domain.PromotionResult{}, err
+		}
+		for i, v := range parts {
+			result.Lines[i].Amount += v.Amount
+		}
+
+		result.Discount.Amount += discount
+		result.Applied = append(result.Applied, domain.AppliedPromotion{
+			PromotionID: p.ID,
+			Name:        p.Name,
+			RuleType:    p.RuleType,
+			Discount:    money.New(discount, currency),
+			ProductIDs:  productIDs,
+		})
+		exclusive = !p.Stackable
+	}
+
+	result.Total = money.New(result.Subtotal.Amount-result.Discount.Amount, currency)
+	return result, nil
+}
+
+// run is the units of a line still free for a promotion, all at the same price.
+type run struct {
+	line     int
+	price    int64
+	quantity int
+}
+
+// runs returns the free units of the lines matching, the most expensive first.
+func runs(lines []domain.PromotionLine, free []int, match func(domain.PromotionLine) bool) []run {
+	var data []run
+	for i, v := range lines {
+		if free[i] > 0 && match(v) {
+			data = append(data, run{line: i, price: v.UnitPrice.Amount, quantity: free[i]})
+		}
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].price > data[j].price
+	})
+	return data
+}
+
+// buyXGetY groups the targeted units by BuyQuantity + GetQuantity from the most
+// expensive one, the GetQuantity cheapest units of each group are free.
+func buyXGetY(p domain.Promotion, lines []domain.PromotionLine, free []int) (int64, []int) {
+	if p.BuyQuantity == nil || p.GetQuantity == nil || *p.BuyQuantity < 1 || *p.GetQuantity < 1 {
+		return 0, nil
+	}
+
+	targeted := runs(lines, free, p.Targets)
+	var units int
+	for _, v := range targeted {
+		units += v.quantity
+	}
+
+	size := *p.BuyQuantity + *p.GetQuantity
+	grouped := units / size * size
+	if grouped == 0 {
+		return 0, nil
+	}
+
+	// freeBefore counts the free units among the first n units of the groups
+	freeBefore := func(n int) int {
+		rest := n%size - *p.BuyQuantity
+		if rest < 0 {
+			rest = 0
+		}
+		return n/size*(*p.GetQuantity) + rest
+	}
+
+	var discount int64
+	used := make([]int, len(lines))
+	position := 0
+	for _, v := range targeted {
+		if position == grouped {
+			break
+		}
+		take := v.quantity
+		if position+take > grouped {
+			take = grouped - position
+		}
+		used[v.line] += take
+		discount += v.price * int64(freeBefore(position+take)-freeBefore(position))
+		position += take
+	}
+
+	return discount, used
+}
+
+// bundle sells one unit of each of the product targets for BundlePrice, as many
+// times as every product of the bundle is in the lines.
+func bundle(p domain.Promotion, lines []domain.PromotionLine, free []int) (int64, []int) {
+	if p.BundlePrice == nil || len(p.ProductIDs) == 0 {
+		return 0, nil
+	}
+
+	bundles := math.MaxInt
+	for _, productID := range p.ProductIDs {
+		var available int
+		for i, v := range lines {
+			if v.ProductID == productID {
+				available += free[i]
+			}
+		}
+		if available < bundles {
+			bundles = available
+		}
+	}
+	if bundles == 0 {
+		return 0, nil
+	}
+
+	// the most expensive units of each product make the bundles
+	var regular int64
+	used := make([]int, len(lines))
+	for _, productID := range p.ProductIDs {
+		productID := productID
+		left := bundles
+		for _, v := range runs(lines, free, func(l domain.PromotionLine) bool { return l.ProductID == productID }) {
+			take := v.quantity
+			if take > left {
+				take = left
+			}
+			regular += v.price * int64(take)
+			used[v.line] += take
+			if left -= take; left == 0 {
+				break
+			}
+		}
+	}
+
+	discount := regular - int64(bundles)*p.BundlePrice.Amount
+	if discount <= 0 {
+		return 0, nil
+	}
+	return discount, used
+}
+
+// tiered takes the discount of the highest tier the targeted lines reach. Only the
+// units no other promotion discounted are discounted, and none is used up.
+func tiered(p domain.Promotion, lines []domain.PromotionLine, free []int, currency string) (int64, []int, error) {
+	var total, base int64
+	for i, v := range lines {
+		if !p.Targets(v) {
+			continue
+		}
+		total += v.UnitPrice.Amount * int64(v.Quantity)
+		base += v.UnitPrice.Amount * int64(free[i])
+	}
+
+	var tier *domain.PromotionTier
+	for i, v := range p.Tiers {
+		if total >= v.MinTotal.Amount && (tier == nil || v.MinTotal.Amount > tier.MinTotal.Amount) {
+			tier = &p.Tiers[i]
+		}
+	}
+	if tier == nil {
+		return 0, nil, nil
+	}
+
+	discount, err := tier.Discount(money.New(base, currency))
+	if err != nil {
+		return 0, nil, err
+	}
+	return discount.Amount, nil, nil
+}
+
+func appendUnique(ids []int, id int) []int {
+	for _, v := range ids {
+		if v == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
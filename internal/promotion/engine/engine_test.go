@@ -0,0 +1,348 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/money"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func usd(amount int64) money.Money {
+	return money.New(amount, "USD")
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func usdPtr(amount int64) *money.Money {
+	v := usd(amount)
+	return &v
+}
+
+func line(productID int, price int64, quantity int) domain.PromotionLine {
+	return domain.PromotionLine{ProductID: productID, CategoryID: 1, UnitPrice: usd(price), Quantity: quantity}
+}
+
+func buyXGetYPromotion(id, priority int, stackable bool, buy, get int, productIDs ...int) domain.Promotion {
+	return domain.Promotion{ID: id, RuleType: domain.PromotionBuyXGetY, Priority: priority, Stackable: stackable, BuyQuantity: intPtr(buy), GetQuantity: intPtr(get), ProductIDs: productIDs}
+}
+
+func bundlePromotion(id, priority int, stackable bool, price int64, productIDs ...int) domain.Promotion {
+	bundlePrice := usd(price)
+	return domain.Promotion{ID: id, RuleType: domain.PromotionBundle, Priority: priority, Stackable: stackable, BundlePrice: &bundlePrice, ProductIDs: productIDs}
+}
+
+func tieredPromotion(id, priority int, stackable bool, tiers ...domain.PromotionTier) domain.Promotion {
+	return domain.Promotion{ID: id, RuleType: domain.PromotionTiered, Priority: priority, Stackable: stackable, Tiers: tiers}
+}
+
+func percentTier(minTotal int64, percent float64) domain.PromotionTier {
+	return domain.PromotionTier{MinTotal: usd(minTotal), DiscountType: domain.CouponDiscountPercentage, DiscountValue: floatPtr(percent)}
+}
+
+func fixedTier(minTotal, amount int64) domain.PromotionTier {
+	return domain.PromotionTier{MinTotal: usd(minTotal), DiscountType: domain.CouponDiscountFixed, DiscountAmount: usdPtr(amount)}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name       string
+		promotions []domain.Promotion
+		lines      []domain.PromotionLine
+		discount   int64
+		total      int64
+		applied    []int
+	}{
+		{
+			name:     "no promotion",
+			lines:    []domain.PromotionLine{line(1, 1000, 2)},
+			discount: 0,
+			total:    2000,
+			applied:  nil,
+		},
+		{
+			name:       "buy x get y frees the cheapest unit of each group across lines",
+			promotions: []domain.Promotion{buyXGetYPromotion(1, 0, true, 2, 1)},
+			lines:      []domain.PromotionLine{line(1, 1000, 2), line(2, 500, 1), line(3, 300, 4)},
+			discount:   800,
+			total:      2900,
+			applied:    []int{1},
+		},
+		{
+			name:       "buy x get y takes large quantities per line",
+			promotions: []domain.Promotion{buyXGetYPromotion(1, 0, true, 1, 1)},
+			lines:      []domain.PromotionLine{line(1, 100, 1000000)},
+			discount:   50000000,
+			total:      50000000,
+			applied:    []int{1},
+		},
+		{
+			name:       "buy x get y needs a whole group",
+			promotions: []domain.Promotion{buyXGetYPromotion(1, 0, true, 2, 1)},
+			lines:      []domain.PromotionLine{line(1, 1000, 2)},
+			discount:   0,
+			total:      2000,
+			applied:    nil,
+		},
+		{
+			name:       "bundle uses the most expensive units of each product",
+			promotions: []domain.Promotion{bundlePromotion(1, 0, true, 1000, 1, 2)},
+			lines:      []domain.PromotionLine{line(1, 700, 1), line(1, 600, 3), line(2, 500, 2)},
+			discount:   300,
+			total:      3200,
+			applied:    []int{1},
+		},
+		{
+			name:       "bundle costing more than its products is not applied",
+			promotions: []domain.Promotion{bundlePromotion(1, 0, true, 2000, 1, 2)},
+			lines:      []domain.PromotionLine{line(1, 700, 1), line(2, 500, 1)},
+			discount:   0,
+			total:      1200,
+			applied:    nil,
+		},
+		{
+			name: "lower priority first uses up the units",
+			promotions: []domain.Promotion{
+				buyXGetYPromotion(1, 2, true, 1, 1, 1),
+				bundlePromotion(2, 1, true, 1000, 1, 2),
+			},
+			lines:    []domain.PromotionLine{line(1, 700, 2), line(2, 500, 1)},
+			discount: 200,
+			total:    1700,
+			applied:  []int{2},
+		},
+		{
+			name: "same priority is ordered by id",
+			promotions: []domain.Promotion{
+				bundlePromotion(2, 1, true, 1000, 1, 2),
+				buyXGetYPromotion(1, 1, true, 1, 1, 1),
+			},
+			lines:    []domain.PromotionLine{line(1, 700, 2), line(2, 500, 1)},
+			discount: 700,
+			total:    1200,
+			applied:  []int{1},
+		},
+		{
+			name: "stackable promotions add up",
+			promotions: []domain.Promotion{
+				buyXGetYPromotion(1, 1, true, 1, 1, 1),
+				tieredPromotion(2, 2, true, percentTier(0, 10)),
+			},
+			lines:    []domain.PromotionLine{line(1, 1000, 2), line(2, 500, 2)},
+			discount: 1100,
+			total:    1900,
+			applied:  []int{1, 2},
+		},
+		{
+			name: "a promotion that is not stackable is skipped after another one",
+			promotions: []domain.Promotion{
+				buyXGetYPromotion(1, 1, true, 1, 1),
+				tieredPromotion(2, 2, false, percentTier(0, 50)),
+			},
+			lines:    []domain.PromotionLine{line(1, 1000, 2)},
+			discount: 1000,
+			total:    1000,
+			applied:  []int{1},
+		},
+		{
+			name: "a promotion that is not stackable excludes the next ones",
+			promotions: []domain.Promotion{
+				tieredPromotion(1, 1, false, percentTier(0, 10)),
+				buyXGetYPromotion(2, 2, true, 1, 1),
+			},
+			lines:    []domain.PromotionLine{line(1, 1000, 2)},
+			discount: 200,
+			total:    1800,
+			applied:  []int{1},
+		},
+		{
+			name: "a promotion giving nothing doesn't exclude the next ones",
+			promotions: []domain.Promotion{
+				buyXGetYPromotion(1, 1, false, 5, 1),
+				buyXGetYPromotion(2, 2, true, 1, 1),
+			},
+			lines:    []domain.PromotionLine{line(1, 1000, 2)},
+			discount: 1000,
+			total:    1000,
+			applied:  []int{2},
+		},
+		{
+			name: "tiered takes the highest tier reached",
+			promotions: []domain.Promotion{tieredPromotion(1, 0, true,
+				percentTier(1000, 10),
+				percentTier(5000, 20),
+				percentTier(10000, 30),
+			)},
+			lines:    []domain.PromotionLine{line(1, 3000, 2)},
+			discount: 1200,
+			total:    4800,
+			applied:  []int{1},
+		},
+		{
+			name: "tiered below the lowest tier",
+			promotions: []domain.Promotion{tieredPromotion(1, 0, true,
+				percentTier(5000, 20),
+			)},
+			lines:    []domain.PromotionLine{line(1, 1000, 2)},
+			discount: 0,
+			total:    2000,
+			applied:  nil,
+		},
+		{
+			name: "a fixed discount is in minor units",
+			promotions: []domain.Promotion{tieredPromotion(1, 0, true,
+				fixedTier(0, 250),
+			)},
+			lines:    []domain.PromotionLine{line(1, 1000, 2)},
+			discount: 250,
+			total:    1750,
+			applied:  []int{1},
+		},
+		{
+			name: "a fixed discount never goes below zero",
+			promotions: []domain.Promotion{tieredPromotion(1, 0, true,
+				fixedTier(0, 10000),
+			)},
+			lines:    []domain.PromotionLine{line(1, 1000, 2)},
+			discount: 2000,
+			total:    0,
+			applied:  []int{1},
+		},
+		{
+			name: "tiered only discounts the units the other promotions left",
+			promotions: []domain.Promotion{
+				buyXGetYPromotion(1, 1, true, 1, 1),
+				tieredPromotion(2, 2, true, fixedTier(0, 10000)),
+			},
+			lines:    []domain.PromotionLine{line(1, 1000, 2), line(2, 500, 1)},
+			discount: 1500,
+			total:    1000,
+			applied:  []int{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(tt.promotions, tt.lines)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got.Discount.Amount != tt.discount {
+				t.Errorf("Discount = %d, want %d", got.Discount.Amount, tt.discount)
+			}
+			if got.Total.Amount != tt.total {
+				t.Errorf("Total = %d, want %d", got.Total.Amount, tt.total)
+			}
+			if got.Total.Amount < 0 {
+				t.Errorf("Total = %d, below zero", got.Total.Amount)
+			}
+
+			var split int64
+			for i, v := range got.Lines {
+				split += v.Amount
+				if amount := tt.lines[i].UnitPrice.Amount * int64(tt.lines[i].Quantity); v.Amount < 0 || v.Amount > amount {
+					t.Errorf("Lines[%d] = %d, want between 0 and %d", i, v.Amount, amount)
+				}
+			}
+			if split != got.Discount.Amount {
+				t.Errorf("Lines add up to %d, want %d", split, got.Discount.Amount)
+			}
+
+			var applied []int
+			for _, v := range got.Applied {
+				applied = append(applied, v.PromotionID)
+			}
+			if !reflect.DeepEqual(applied, tt.applied) {
+				t.Errorf("Applied = %v, want %v", applied, tt.applied)
+			}
+		})
+	}
+}
+
+func TestEvaluateWithCoupon(t *testing.T) {
+	halfOff := domain.Coupon{IsActive: true, DiscountType: domain.CouponDiscountPercentage, DiscountValue: floatPtr(50)}
+	fixed := domain.Coupon{IsActive: true, DiscountType: domain.CouponDiscountFixed, DiscountAmount: usdPtr(5000)}
+	targeted := domain.Coupon{IsActive: true, DiscountType: domain.CouponDiscountPercentage, DiscountValue: floatPtr(50), ProductIDs: []int{2}}
+
+	tests := []struct {
+		name       string
+		promotions []domain.Promotion
+		coupon     domain.Coupon
+		lines      []domain.PromotionLine
+		discount   int64
+		total      int64
+	}{
+		{
+			name:       "the coupon doesn't discount the free unit again",
+			promotions: []domain.Promotion{buyXGetYPromotion(1, 0, true, 1, 1)},
+			coupon:     halfOff,
+			lines:      []domain.PromotionLine{line(1, 1000, 2)},
+			discount:   500,
+			total:      500,
+		},
+		{
+			name:       "a fixed coupon is capped by what is left to pay",
+			promotions: []domain.Promotion{buyXGetYPromotion(1, 0, true, 1, 1)},
+			coupon:     fixed,
+			lines:      []domain.PromotionLine{line(1, 1000, 2)},
+			discount:   1000,
+			total:      0,
+		},
+		{
+			name:       "the coupon discounts its lines net of the promotions",
+			promotions: []domain.Promotion{tieredPromotion(1, 0, true, percentTier(0, 10))},
+			coupon:     targeted,
+			lines:      []domain.PromotionLine{line(1, 1000, 1), line(2, 2000, 1)},
+			discount:   900,
+			total:      1800,
+		},
+		{
+			name:     "without promotions the coupon takes the line totals",
+			coupon:   halfOff,
+			lines:    []domain.PromotionLine{line(1, 1000, 2)},
+			discount: 1000,
+			total:    1000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Evaluate(tt.promotions, tt.lines)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+
+			order := make([]domain.OrderRequest, 0, len(tt.lines))
+			for _, v := range tt.lines {
+				price, _ := v.UnitPrice.Mul(int64(v.Quantity))
+				order = append(order, domain.OrderRequest{ProductID: v.ProductID, Quantity: v.Quantity, Price: price})
+			}
+			net, err := result.NetOf(order)
+			if err != nil {
+				t.Fatalf("NetOf() error = %v", err)
+			}
+
+			couponLines := make([]domain.CouponLine, 0, len(net))
+			for _, v := range net {
+				couponLines = append(couponLines, domain.CouponLine{ProductID: v.ProductID, CategoryID: 1, Amount: v.Price})
+			}
+			discount, err := tt.coupon.Discount(time.Now(), couponLines)
+			if err != nil {
+				t.Fatalf("Discount() error = %v", err)
+			}
+			if discount.Amount != tt.discount {
+				t.Errorf("coupon discount = %d, want %d", discount.Amount, tt.discount)
+			}
+			if total := result.Total.Amount - discount.Amount; total != tt.total {
+				t.Errorf("total = %d, want %d", total, tt.total)
+			}
+		})
+	}
+}
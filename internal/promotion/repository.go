@@ -0,0 +1,23 @@
+package promotion
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	InsertPromotion(ctx context.Context, tx *gorm.DB, data domain.Promotion) (*domain.Promotion, error)
+	InsertTargets(ctx context.Context, tx *gorm.DB, data []domain.PromotionTarget) error
+	InsertTiers(ctx context.Context, tx *gorm.DB, data []domain.PromotionTier) error
+	FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+	GetPromotionByID(ctx context.Context, promotionID int) (domain.Promotion, error)
+	GetActivePromotions(ctx context.Context, tx *gorm.DB) ([]domain.Promotion, error)
+	GetTargets(ctx context.Context, tx *gorm.DB, promotionIDs []int) ([]domain.PromotionTarget, error)
+	GetTiers(ctx context.Context, tx *gorm.DB, promotionIDs []int) ([]domain.PromotionTier, error)
+	UpdatePromotion(ctx context.Context, promotionID int, data map[string]interface{}) (int64, error)
+	GetProductCategories(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.PromotionLine, error)
+	InsertOrderPromotions(ctx context.Context, tx *gorm.DB, data []domain.OrderPromotion) error
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/promotion"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type PromotionRepository struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) promotion.Repository {
+	return &PromotionRepository{db}
+}
+
+func (r *PromotionRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *PromotionRepository) InsertPromotion(ctx context.Context, tx *gorm.DB, data domain.Promotion) (*domain.Promotion, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *PromotionRepository) InsertTargets(ctx context.Context, tx *gorm.DB, data []domain.PromotionTarget) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).CreateInBatches(&data, 100).Error
+}
+
+func (r *PromotionRepository) InsertTiers(ctx context.Context, tx *gorm.DB, data []domain.PromotionTier) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).CreateInBatches(&data, 100).Error
+}
+
+func (r *PromotionRepository) FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	paginate := database.NewPaginator(r.db, page, pageSize, model).Raw(query, args, countQuery, args)
+
+	if err := paginate.FindWithOrderBy(ctx, orderBy).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
+
+func (r *PromotionRepository) GetPromotionByID(ctx context.Context, promotionID int) (domain.Promotion, error) {
+	var data domain.Promotion
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", promotionID).First(&data).Error
+	return data, err
+}
+
+// GetActivePromotions returns the promotions running now.
+func (r *PromotionRepository) GetActivePromotions(ctx context.Context, tx *gorm.DB) ([]domain.Promotion, error) {
+	var data []domain.Promotion
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Where("is_active AND deleted_at IS NULL AND (starts_at IS NULL OR starts_at <= now()) AND (ends_at IS NULL OR ends_at > now())").
+		Order("priority, id").
+		Find(&data).Error
+	return data, err
+}
+
+func (r *PromotionRepository) GetTargets(ctx context.Context, tx *gorm.DB, promotionIDs []int) ([]domain.PromotionTarget, error) {
+	var data []domain.PromotionTarget
+	if len(promotionIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("promotion_id IN ?", promotionIDs).Find(&data).Error
+	return data, err
+}
+
+func (r *PromotionRepository) GetTiers(ctx context.Context, tx *gorm.DB, promotionIDs []int) ([]domain.PromotionTier, error) {
+	var data []domain.PromotionTier
+	if len(promotionIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("promotion_id IN ?", promotionIDs).Order("min_total").Find(&data).Error
+	return data, err
+}
+
+func (r *PromotionRepository) UpdatePromotion(ctx context.Context, promotionID int, data map[string]interface{}) (int64, error) {
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("promotion").Where("id = ? AND deleted_at IS NULL", promotionID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
+
+func (r *PromotionRepository) GetProductCategories(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.PromotionLine, error) {
+	var data []domain.PromotionLine
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT id AS product_id, category_id FROM product WHERE id IN ?`, productIDs).Scan(&data).Error
+	return data, err
+}
+
+func (r *PromotionRepository) InsertOrderPromotions(ctx context.Context, tx *gorm.DB, data []domain.OrderPromotion) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).CreateInBatches(&data, 100).Error
+}
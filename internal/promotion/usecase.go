@@ -0,0 +1,19 @@
+package promotion
+
+import (
+	"context"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type UseCase interface {
+	CreatePromotion(beegoCtx *beegoContext.Context, req domain.CreatePromotionRequest) (*domain.Promotion, error)
+	GetPromotions(beegoCtx *beegoContext.Context, req domain.GetPromotionListRequest) (*database.Paginator, error)
+	UpdatePromotion(beegoCtx *beegoContext.Context, req domain.UpdatePromotionRequest) (*domain.Promotion, error)
+	Evaluate(ctx context.Context, tx *gorm.DB, lines []domain.PromotionLine) (*domain.PromotionResult, error)
+	EvaluateOrder(ctx context.Context, tx *gorm.DB, order []domain.OrderRequest) (*domain.PromotionResult, error)
+	RecordOrder(ctx context.Context, tx *gorm.DB, orderID int, result domain.PromotionResult) error
+}
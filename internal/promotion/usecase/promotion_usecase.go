@@ -0,0 +1,277 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/promotion"
+	"github.com/online-store/internal/promotion/engine"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type PromotionUseCase struct {
+	promotionRepo promotion.Repository
+	zapLogger     zaplogger.Logger
+}
+
+func NewPromotionUseCase(promotionRepo promotion.Repository, zapLogger zaplogger.Logger) promotion.UseCase {
+	return &PromotionUseCase{
+		promotionRepo: promotionRepo,
+		zapLogger:     zapLogger,
+	}
+}
+
+func (u *PromotionUseCase) CreatePromotion(beegoCtx *beegoContext.Context, req domain.CreatePromotionRequest) (*domain.Promotion, error) {
+	for _, v := range req.Tiers {
+		if !domain.ValidDiscount(v.DiscountType, v.DiscountValue, v.DiscountAmount) {
+			return nil, domain.ErrInvalidDiscountValue
+		}
+	}
+
+	var (
+		data *domain.Promotion
+		err  error
+	)
+
+	//start transaction
+	errs := u.promotionRepo.DB().Transaction(func(tx *gorm.DB) error {
+		data, err = u.promotionRepo.InsertPromotion(beegoCtx.Request.Context(), tx, domain.Promotion{
+			Name:        req.Name,
+			RuleType:    req.RuleType,
+			Priority:    req.Priority,
+			Stackable:   req.Stackable,
+			BuyQuantity: req.BuyQuantity,
+			GetQuantity: req.GetQuantity,
+			BundlePrice: req.BundlePrice,
+			StartsAt:    req.StartsAt,
+			EndsAt:      req.EndsAt,
+			IsActive:    true,
+			CreatedAt:   time.Now(),
+			CreatedBy:   "System",
+		})
+		if err != nil {
+			return err
+		}
+
+		var targets []domain.PromotionTarget
+		for i := range req.ProductIDs {
+			targets = append(targets, domain.PromotionTarget{PromotionID: data.ID, ProductID: &req.ProductIDs[i]})
+		}
+		for i := range req.CategoryIDs {
+			targets = append(targets, domain.PromotionTarget{PromotionID: data.ID, CategoryID: &req.CategoryIDs[i]})
+		}
+		if err := u.promotionRepo.InsertTargets(beegoCtx.Request.Context(), tx, targets); err != nil {
+			return err
+		}
+
+		tiers := make([]domain.PromotionTier, 0, len(req.Tiers))
+		for _, v := range req.Tiers {
+			v.PromotionID = data.ID
+			tiers = append(tiers, v)
+		}
+		data.ProductIDs, data.CategoryIDs, data.Tiers = req.ProductIDs, req.CategoryIDs, tiers
+
+		return u.promotionRepo.InsertTiers(beegoCtx.Request.Context(), tx, tiers)
+	})
+
+	if errs != nil {
+		if pgErr, ok := errs.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeForeignKeyConstraint {
+			return nil, domain.ErrForeignKeyConstraint
+		}
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		return nil, errs
+	}
+
+	return data, nil
+}
+
+func (u *PromotionUseCase) GetPromotions(beegoCtx *beegoContext.Context, req domain.GetPromotionListRequest) (*database.Paginator, error) {
+	var entities []domain.Promotion
+
+	query := `SELECT * FROM promotion WHERE deleted_at IS NULL`
+	countQuery := `SELECT COUNT(*) FROM promotion WHERE deleted_at IS NULL`
+
+	data, err := u.promotionRepo.FetchWithFilterAndPagination(
+		beegoCtx.Request.Context(),
+		req.Page,
+		req.Limit,
+		query,
+		countQuery,
+		"ORDER BY priority, id",
+		&entities,
+	)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	if err := u.loadRules(beegoCtx.Request.Context(), u.promotionRepo.DB(), entities); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// UpdatePromotion changes the given fields only, the rule itself is kept as it was.
+func (u *PromotionUseCase) UpdatePromotion(beegoCtx *beegoContext.Context, req domain.UpdatePromotionRequest) (*domain.Promotion, error) {
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+		"updated_by": "System",
+	}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Priority != nil {
+		updates["priority"] = *req.Priority
+	}
+	if req.Stackable != nil {
+		updates["stackable"] = *req.Stackable
+	}
+	if req.StartsAt != nil {
+		updates["starts_at"] = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		updates["ends_at"] = *req.EndsAt
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	affected, err := u.promotionRepo.UpdatePromotion(beegoCtx.Request.Context(), req.ID, updates)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	data, err := u.promotionRepo.GetPromotionByID(beegoCtx.Request.Context(), req.ID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	entities := []domain.Promotion{data}
+	if err := u.loadRules(beegoCtx.Request.Context(), u.promotionRepo.DB(), entities); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return &entities[0], nil
+}
+
+// Evaluate applies the running promotions to the lines.
+func (u *PromotionUseCase) Evaluate(ctx context.Context, tx *gorm.DB, lines []domain.PromotionLine) (*domain.PromotionResult, error) {
+	promotions, err := u.promotionRepo.GetActivePromotions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.loadRules(ctx, tx, promotions); err != nil {
+		return nil, err
+	}
+
+	result, err := engine.Evaluate(promotions, lines)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// EvaluateOrder applies the running promotions to the checkout lines, the price of
+// an order line is the price of all its units. The unit prices are rounded to the
+// minor unit, so only the discount of the result is meant for the order.
+func (u *PromotionUseCase) EvaluateOrder(ctx context.Context, tx *gorm.DB, order []domain.OrderRequest) (*domain.PromotionResult, error) {
+	productIDs := make([]int, 0, len(order))
+	for _, v := range order {
+		productIDs = append(productIDs, v.ProductID)
+	}
+	categories, err := u.promotionRepo.GetProductCategories(ctx, tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	categoryByProduct := make(map[int]int, len(categories))
+	for _, v := range categories {
+		categoryByProduct[v.ProductID] = v.CategoryID
+	}
+
+	lines := make([]domain.PromotionLine, 0, len(order))
+	for _, v := range order {
+		unitPrice, err := v.Price.Div(int64(v.Quantity))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, domain.PromotionLine{
+			ProductID:  v.ProductID,
+			VariantID:  v.VariantID,
+			CategoryID: categoryByProduct[v.ProductID],
+			UnitPrice:  unitPrice,
+			Quantity:   v.Quantity,
+		})
+	}
+
+	return u.Evaluate(ctx, tx, lines)
+}
+
+// RecordOrder keeps the promotions applied to the order.
+func (u *PromotionUseCase) RecordOrder(ctx context.Context, tx *gorm.DB, orderID int, result domain.PromotionResult) error {
+	data := make([]domain.OrderPromotion, 0, len(result.Applied))
+	for _, v := range result.Applied {
+		data = append(data, domain.OrderPromotion{
+			OrderID:     orderID,
+			PromotionID: v.PromotionID,
+			Name:        v.Name,
+			Discount:    v.Discount,
+			CreatedAt:   time.Now(),
+		})
+	}
+
+	return u.promotionRepo.InsertOrderPromotions(ctx, tx, data)
+}
+
+// loadRules fills the targets and the tiers of the promotions.
+func (u *PromotionUseCase) loadRules(ctx context.Context, tx *gorm.DB, promotions []domain.Promotion) error {
+	promotionIDs := make([]int, 0, len(promotions))
+	for _, v := range promotions {
+		promotionIDs = append(promotionIDs, v.ID)
+	}
+
+	targets, err := u.promotionRepo.GetTargets(ctx, tx, promotionIDs)
+	if err != nil {
+		return err
+	}
+	tiers, err := u.promotionRepo.GetTiers(ctx, tx, promotionIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range promotions {
+		p := &promotions[i]
+		p.ProductIDs, p.CategoryIDs, p.Tiers = []int{}, []int{}, []domain.PromotionTier{}
+		for _, v := range targets {
+			if v.PromotionID != p.ID {
+				continue
+			}
+			if v.ProductID != nil {
+				p.ProductIDs = append(p.ProductIDs, *v.ProductID)
+			}
+			if v.CategoryID != nil {
+				p.CategoryIDs = append(p.CategoryIDs, *v.CategoryID)
+			}
+		}
+		for _, v := range tiers {
+			if v.PromotionID == p.ID {
+				p.Tiers = append(p.Tiers, v)
+			}
+		}
+	}
+
+	return nil
+}
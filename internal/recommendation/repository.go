@@ -0,0 +1,16 @@
+package recommendation
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	TryLockRefresh(ctx context.Context, tx *gorm.DB) (bool, error)
+	RefreshRelatedProducts(ctx context.Context, tx *gorm.DB, minOrders, limit int) (int64, error)
+	RefreshCategoryBestsellers(ctx context.Context, tx *gorm.DB, limit int) (int64, error)
+	GetRelatedProducts(ctx context.Context, productIDs []int, limit int) ([]domain.RelatedProduct, error)
+	GetCategoryBestsellers(ctx context.Context, productIDs, excludeIDs []int, limit int) ([]domain.RelatedProduct, error)
+}
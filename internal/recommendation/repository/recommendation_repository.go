@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/recommendation"
+	"gorm.io/gorm"
+)
+
+// relatedProductColumns selects a recommended product, p being the product.
+const relatedProductColumns = `p.id AS product_id, p."name", p.price, ` + domain.ProductAvailableStockColumn + `, p.rating_average, p.rating_count`
+
+type RecommendationRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationRepository(db *gorm.DB) recommendation.Repository {
+	return &RecommendationRepository{db}
+}
+
+func (r *RecommendationRepository) DB() *gorm.DB {
+	return r.db
+}
+
+// TryLockRefresh takes the refresh lock until tx ends, false when another refresh
+// holds it.
+func (r *RecommendationRepository) TryLockRefresh(ctx context.Context, tx *gorm.DB) (bool, error) {
+	var locked bool
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT pg_try_advisory_xact_lock(?)`, domain.RecommendationLockKey).Scan(&locked).Error
+	return locked, err
+}
+
+// RefreshRelatedProducts replaces the related products with the products bought in
+// the same paid orders, the limit most frequent of each product that are in at
+// least minOrders orders with it.
+func (r *RecommendationRepository) RefreshRelatedProducts(ctx context.Context, tx *gorm.DB, minOrders, limit int) (int64, error) {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+
+	if err := db.Exec(`DELETE FROM product_related`).Error; err != nil {
+		return 0, err
+	}
+	result := db.Exec(`INSERT INTO product_related (product_id, related_product_id, score, updated_at) 
+				SELECT product_id, related_product_id, score, now() FROM ( 
+					SELECT a.product_id, b.product_id AS related_product_id, COUNT(DISTINCT a.order_id) AS score, 
+						ROW_NUMBER() OVER (PARTITION BY a.product_id ORDER BY COUNT(DISTINCT a.order_id) DESC, b.product_id) AS position 
+					FROM order_item a 
+					JOIN order_item b ON b.order_id = a.order_id AND b.product_id <> a.product_id AND b.deleted_at IS NULL 
+					JOIN "order" o ON o.id = a.order_id 
+					WHERE a.deleted_at IS NULL AND o.deleted_at IS NULL AND o.status IN ? 
+					GROUP BY a.product_id, b.product_id 
+					HAVING COUNT(DISTINCT a.order_id) >= ? 
+				) pairs 
+				WHERE position <= ?`, domain.OrderPaidStatuses, minOrders, limit)
+	return result.RowsAffected, result.Error
+}
+
+// RefreshCategoryBestsellers replaces the bestsellers with the limit products of
+// each category sold the most in paid orders.
+func (r *RecommendationRepository) RefreshCategoryBestsellers(ctx context.Context, tx *gorm.DB, limit int) (int64, error) {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+
+	if err := db.Exec(`DELETE FROM category_bestseller`).Error; err != nil {
+		return 0, err
+	}
+	result := db.Exec(`INSERT INTO category_bestseller (category_id, product_id, sold, updated_at) 
+				SELECT category_id, product_id, sold, now() FROM ( 
+					SELECT p.category_id, oi.product_id, SUM(oi.quantity) AS sold, 
+						ROW_NUMBER() OVER (PARTITION BY p.category_id ORDER BY SUM(oi.quantity) DESC, oi.product_id) AS position 
+					FROM order_item oi 
+					JOIN "order" o ON o.id = oi.order_id 
+					JOIN product p ON p.id = oi.product_id 
+					WHERE oi.deleted_at IS NULL AND o.deleted_at IS NULL AND o.status IN ? AND p.deleted_at IS NULL AND p.category_id IS NOT NULL 
+					GROUP BY p.category_id, oi.product_id 
+				) sales 
+				WHERE position <= ?`, domain.OrderPaidStatuses, limit)
+	return result.RowsAffected, result.Error
+}
+
+// GetRelatedProducts returns the products bought most often with any of the
+// products, the products themselves left out.
+func (r *RecommendationRepository) GetRelatedProducts(ctx context.Context, productIDs []int, limit int) ([]domain.RelatedProduct, error) {
+	var data []domain.RelatedProduct
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT `+relatedProductColumns+` 
+				FROM (SELECT related_product_id, SUM(score) AS score FROM product_related 
+					WHERE product_id IN ? AND related_product_id NOT IN ? 
+					GROUP BY related_product_id) pr 
+				JOIN product p ON p.id = pr.related_product_id 
+				WHERE p.deleted_at IS NULL 
+				ORDER BY pr.score DESC, p.id 
+				LIMIT ?`, productIDs, productIDs, limit).Scan(&data).Error
+	return data, err
+}
+
+// GetCategoryBestsellers returns the bestsellers of the categories of the products,
+// but the excluded ones.
+func (r *RecommendationRepository) GetCategoryBestsellers(ctx context.Context, productIDs, excludeIDs []int, limit int) ([]domain.RelatedProduct, error) {
+	var data []domain.RelatedProduct
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT `+relatedProductColumns+` 
+				FROM category_bestseller cb 
+				JOIN product p ON p.id = cb.product_id 
+				WHERE cb.category_id IN (SELECT category_id FROM product WHERE id IN ?) AND cb.product_id NOT IN ? AND p.deleted_at IS NULL 
+				ORDER BY cb.sold DESC, p.id 
+				LIMIT ?`, productIDs, excludeIDs, limit).Scan(&data).Error
+	return data, err
+}
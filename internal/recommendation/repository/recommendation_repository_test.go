@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/online-store/internal/domain"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDSNEnv names the connection string of the Postgres the tests run against,
+// usually the one of docker-compose.yml. The tests are skipped without it.
+const testDSNEnv = "TEST_DATABASE_URL"
+
+// openTestDatabase opens a transaction on the test database with temporary
+// tables shadowing the ones the refresh reads and writes, rolled back once the
+// test ends.
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	tx := conn.Begin()
+	if tx.Error != nil {
+		t.Fatalf("Begin() error = %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	for _, v := range []string{
+		`CREATE TEMPORARY TABLE product (id int8, category_id int8, deleted_at timestamp) ON COMMIT DROP`,
+		`CREATE TEMPORARY TABLE "order" (id int8, status varchar, deleted_at timestamp) ON COMMIT DROP`,
+		`CREATE TEMPORARY TABLE order_item (order_id int8, product_id int8, quantity int8, deleted_at timestamp) ON COMMIT DROP`,
+		`CREATE TEMPORARY TABLE product_related (product_id int8, related_product_id int8, score int8, updated_at timestamp) ON COMMIT DROP`,
+		`CREATE TEMPORARY TABLE category_bestseller (category_id int8, product_id int8, sold int8, updated_at timestamp) ON COMMIT DROP`,
+	} {
+		if err := tx.Exec(v).Error; err != nil {
+			t.Fatalf("create table error = %v", err)
+		}
+	}
+	return tx
+}
+
+// seedOrders puts the products 1 and 2 together in an order of every status, and
+// the product 3 with a lot of units next to the product 1 in the pending and
+// cancelled orders only.
+func seedOrders(t *testing.T, tx *gorm.DB) {
+	t.Helper()
+
+	if err := tx.Exec(`INSERT INTO product (id, category_id) VALUES (1, 10), (2, 10), (3, 10)`).Error; err != nil {
+		t.Fatalf("insert product error = %v", err)
+	}
+
+	statuses := append([]string{domain.OrderStatusPending, domain.OrderStatusCancelled}, domain.OrderPaidStatuses...)
+	for i, status := range statuses {
+		orderID := i + 1
+		if err := tx.Exec(`INSERT INTO "order" (id, status) VALUES (?, ?)`, orderID, status).Error; err != nil {
+			t.Fatalf("insert order error = %v", err)
+		}
+		if err := tx.Exec(`INSERT INTO order_item (order_id, product_id, quantity) VALUES (?, 1, 1), (?, 2, 1)`, orderID, orderID).Error; err != nil {
+			t.Fatalf("insert order_item error = %v", err)
+		}
+		if domain.IsPaidOrderStatus(status) {
+			continue
+		}
+		if err := tx.Exec(`INSERT INTO order_item (order_id, product_id, quantity) VALUES (?, 3, 100)`, orderID).Error; err != nil {
+			t.Fatalf("insert order_item error = %v", err)
+		}
+	}
+}
+
+// selectRows returns the rows of a query selecting three numbers.
+func selectRows(t *testing.T, tx *gorm.DB, query string) [][3]int64 {
+	t.Helper()
+
+	rows, err := tx.Raw(query).Rows()
+	if err != nil {
+		t.Fatalf("Raw() error = %v", err)
+	}
+	defer rows.Close()
+
+	var data [][3]int64
+	for rows.Next() {
+		var v [3]int64
+		if err := rows.Scan(&v[0], &v[1], &v[2]); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		data = append(data, v)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Rows() error = %v", err)
+	}
+	return data
+}
+
+func TestRefreshCountsPaidOrdersOnly(t *testing.T) {
+	tx := openTestDatabase(t)
+	seedOrders(t, tx)
+
+	r := NewRecommendationRepository(tx)
+	paid := int64(len(domain.OrderPaidStatuses))
+
+	t.Run("related products", func(t *testing.T) {
+		if _, err := r.RefreshRelatedProducts(context.Background(), tx, 1, 5); err != nil {
+			t.Fatalf("RefreshRelatedProducts() error = %v", err)
+		}
+
+		got := selectRows(t, tx, `SELECT product_id, related_product_id, score FROM product_related ORDER BY product_id, related_product_id`)
+		want := [][3]int64{{1, 2, paid}, {2, 1, paid}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("product_related = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("category bestsellers", func(t *testing.T) {
+		if _, err := r.RefreshCategoryBestsellers(context.Background(), tx, 5); err != nil {
+			t.Fatalf("RefreshCategoryBestsellers() error = %v", err)
+		}
+
+		got := selectRows(t, tx, `SELECT category_id, product_id, sold FROM category_bestseller ORDER BY category_id, product_id`)
+		want := [][3]int64{{10, 1, paid}, {10, 2, paid}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("category_bestseller = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,13 @@
+package recommendation
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"time"
+)
+
+type UseCase interface {
+	Run(ctx context.Context, interval time.Duration)
+	Refresh(ctx context.Context) error
+	GetRelatedProducts(ctx context.Context, productIDs []int) ([]domain.RelatedProduct, error)
+}
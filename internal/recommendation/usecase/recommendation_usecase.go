@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/recommendation"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type RecommendationUseCase struct {
+	recommendationRepo recommendation.Repository
+	limit              int
+	minOrders          int
+	zapLogger          zaplogger.Logger
+}
+
+// NewRecommendationUseCase recommends up to limit products. Products are related
+// once they are in minOrders paid orders together.
+func NewRecommendationUseCase(recommendationRepo recommendation.Repository, limit, minOrders int, zapLogger zaplogger.Logger) recommendation.UseCase {
+	return &RecommendationUseCase{
+		recommendationRepo: recommendationRepo,
+		limit:              limit,
+		minOrders:          minOrders,
+		zapLogger:          zapLogger,
+	}
+}
+
+// Run refreshes the recommendations now and then every interval until ctx is done.
+// It runs in the background, so errors are only logged and a panicking refresh is
+// tried again at the next tick. The interval must be positive.
+func (u *RecommendationUseCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := u.safeRefresh(ctx); err != nil && ctx.Err() == nil {
+			u.zapLogger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// safeRefresh refreshes the recommendations, returning a panic as an error.
+func (u *RecommendationUseCase) safeRefresh(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recommendation refresh panicked: %v", r)
+		}
+	}()
+	return u.Refresh(ctx)
+}
+
+// Refresh computes the related products and the category bestsellers again from
+// the paid orders. It does nothing while another instance is refreshing them.
+func (u *RecommendationUseCase) Refresh(ctx context.Context) error {
+	//start transaction
+	return u.recommendationRepo.DB().Transaction(func(tx *gorm.DB) error {
+		locked, err := u.recommendationRepo.TryLockRefresh(ctx, tx)
+		if err != nil || !locked {
+			return err
+		}
+
+		if _, err := u.recommendationRepo.RefreshRelatedProducts(ctx, tx, u.minOrders, u.limit); err != nil {
+			return err
+		}
+		_, err = u.recommendationRepo.RefreshCategoryBestsellers(ctx, tx, u.limit)
+		return err
+	})
+}
+
+// GetRelatedProducts recommends products to buy with the products, those bought
+// together with them first, then the bestsellers of their categories when there
+// are not enough. Prices are in the base currency.
+func (u *RecommendationUseCase) GetRelatedProducts(ctx context.Context, productIDs []int) ([]domain.RelatedProduct, error) {
+	data, err := u.recommendationRepo.GetRelatedProducts(ctx, productIDs, u.limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range data {
+		data[i].Source = domain.RecommendationBoughtTogether
+	}
+	if len(data) >= u.limit {
+		return data, nil
+	}
+
+	excludeIDs := append([]int{}, productIDs...)
+	for _, v := range data {
+		excludeIDs = append(excludeIDs, v.ProductID)
+	}
+	bestsellers, err := u.recommendationRepo.GetCategoryBestsellers(ctx, productIDs, excludeIDs, u.limit-len(data))
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range bestsellers {
+		v.Source = domain.RecommendationCategoryBestseller
+		data = append(data, v)
+	}
+
+	return data, nil
+}
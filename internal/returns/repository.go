@@ -0,0 +1,27 @@
+package returns
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	GetOrderForUpdate(ctx context.Context, tx *gorm.DB, orderID int) (domain.Order, error)
+	GetOrderItems(ctx context.Context, tx *gorm.DB, orderID int) ([]domain.OrderItem, error)
+	GetReturnableItems(ctx context.Context, tx *gorm.DB, orderID int) ([]domain.ReturnableItem, error)
+	InsertReturn(ctx context.Context, tx *gorm.DB, data domain.Return) (*domain.Return, error)
+	InsertReturnItems(ctx context.Context, tx *gorm.DB, data []domain.ReturnItem) error
+	InsertReturnEvent(ctx context.Context, tx *gorm.DB, data domain.ReturnEvent) error
+	GetReturnByID(ctx context.Context, returnID int) (domain.Return, error)
+	GetReturnForUpdate(ctx context.Context, tx *gorm.DB, returnID int) (domain.Return, error)
+	GetReturnItems(ctx context.Context, tx *gorm.DB, returnIDs []int) ([]domain.ReturnItem, error)
+	GetReturnEvents(ctx context.Context, returnID int) ([]domain.ReturnEvent, error)
+	UpdateReturn(ctx context.Context, tx *gorm.DB, returnID int, data map[string]interface{}) (int64, error)
+	UpdateReturnItem(ctx context.Context, tx *gorm.DB, returnID, orderItemID int, data map[string]interface{}) (int64, error)
+	InsertRefund(ctx context.Context, tx *gorm.DB, data domain.Refund) (*domain.Refund, error)
+	GetRefundByReturnID(ctx context.Context, returnID int) (*domain.Refund, error)
+	FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+}
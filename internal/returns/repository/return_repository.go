@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/returns"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type ReturnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) returns.Repository {
+	return &ReturnRepository{db}
+}
+
+func (r *ReturnRepository) DB() *gorm.DB {
+	return r.db
+}
+
+// GetOrderForUpdate locks the order, its returns are requested one at a time.
+func (r *ReturnRepository) GetOrderForUpdate(ctx context.Context, tx *gorm.DB, orderID int) (domain.Order, error) {
+	var data domain.Order
+
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT * FROM "order" WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, orderID).Scan(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return data, gorm.ErrRecordNotFound
+	}
+	data.SetChargedCurrency()
+	return data, result.Error
+}
+
+func (r *ReturnRepository) GetOrderItems(ctx context.Context, tx *gorm.DB, orderID int) ([]domain.OrderItem, error) {
+	var data []domain.OrderItem
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("order_id = ? AND deleted_at IS NULL", orderID).Order("id").Find(&data).Error
+	return data, err
+}
+
+// GetReturnableItems sums, for each item of the order, the quantity delivered and
+// the quantity in returns not rejected.
+func (r *ReturnRepository) GetReturnableItems(ctx context.Context, tx *gorm.DB, orderID int) ([]domain.ReturnableItem, error) {
+	var data []domain.ReturnableItem
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT oi.id AS order_item_id, 
+					COALESCE((SELECT SUM(si.quantity) FROM shipment_item si JOIN shipment s ON s.id = si.shipment_id 
+						WHERE si.order_item_id = oi.id AND s.status = ? AND s.deleted_at IS NULL), 0) AS delivered, 
+					COALESCE((SELECT SUM(ri.quantity) FROM return_item ri JOIN return_request rr ON rr.id = ri.return_id 
+						WHERE ri.order_item_id = oi.id AND rr.status <> ? AND rr.deleted_at IS NULL), 0) AS returned 
+				FROM order_item oi 
+				WHERE oi.order_id = ? AND oi.deleted_at IS NULL 
+				ORDER BY oi.id`, domain.ShipmentStatusDelivered, domain.ReturnStatusRejected, orderID).Scan(&data).Error
+	return data, err
+}
+
+func (r *ReturnRepository) InsertReturn(ctx context.Context, tx *gorm.DB, data domain.Return) (*domain.Return, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *ReturnRepository) InsertReturnItems(ctx context.Context, tx *gorm.DB, data []domain.ReturnItem) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).CreateInBatches(&data, 100).Error
+}
+
+func (r *ReturnRepository) InsertReturnEvent(ctx context.Context, tx *gorm.DB, data domain.ReturnEvent) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Create(&data).Error
+}
+
+func (r *ReturnRepository) GetReturnByID(ctx context.Context, returnID int) (domain.Return, error) {
+	var data domain.Return
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", returnID).First(&data).Error
+	return data, err
+}
+
+// GetReturnForUpdate locks the return, it moves along its statuses one step at a time.
+func (r *ReturnRepository) GetReturnForUpdate(ctx context.Context, tx *gorm.DB, returnID int) (domain.Return, error) {
+	var data domain.Return
+
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT * FROM return_request WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, returnID).Scan(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return data, gorm.ErrRecordNotFound
+	}
+	return data, result.Error
+}
+
+func (r *ReturnRepository) GetReturnItems(ctx context.Context, tx *gorm.DB, returnIDs []int) ([]domain.ReturnItem, error) {
+	var data []domain.ReturnItem
+	if len(returnIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT ri.return_id, ri.order_item_id, oi.product_id, oi.variant_id, ri.quantity, ri.reason, ri.note, ri.refund_amount, ri.outcome, ri.warehouse_id 
+				FROM return_item ri 
+				JOIN order_item oi ON oi.id = ri.order_item_id 
+				WHERE ri.return_id IN ? 
+				ORDER BY ri.return_id, ri.order_item_id`, returnIDs).Scan(&data).Error
+	return data, err
+}
+
+func (r *ReturnRepository) GetReturnEvents(ctx context.Context, returnID int) ([]domain.ReturnEvent, error) {
+	var data []domain.ReturnEvent
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("return_id = ?", returnID).Order("created_at, id").Find(&data).Error
+	return data, err
+}
+
+func (r *ReturnRepository) UpdateReturn(ctx context.Context, tx *gorm.DB, returnID int, data map[string]interface{}) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("return_request").Where("id = ? AND deleted_at IS NULL", returnID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
+
+func (r *ReturnRepository) UpdateReturnItem(ctx context.Context, tx *gorm.DB, returnID, orderItemID int, data map[string]interface{}) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("return_item").Where("return_id = ? AND order_item_id = ?", returnID, orderItemID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
+
+func (r *ReturnRepository) InsertRefund(ctx context.Context, tx *gorm.DB, data domain.Refund) (*domain.Refund, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Create(&data).Error
+
+	return &data, err
+}
+
+// GetRefundByReturnID returns nil when the return is not refunded.
+func (r *ReturnRepository) GetRefundByReturnID(ctx context.Context, returnID int) (*domain.Refund, error) {
+	var data []domain.Refund
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("return_id = ?", returnID).Limit(1).Find(&data).Error
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	data[0].SetChargedCurrency()
+	return &data[0], nil
+}
+
+func (r *ReturnRepository) FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	paginate := database.NewPaginator(r.db, page, pageSize, model).Raw(query, args, countQuery, args)
+
+	if err := paginate.FindWithOrderBy(ctx, orderBy).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
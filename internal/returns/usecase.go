@@ -0,0 +1,15 @@
+package returns
+
+import (
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+)
+
+type UseCase interface {
+	CreateReturn(beegoCtx *beegoContext.Context, req domain.CreateReturnRequest) (*domain.Return, error)
+	GetReturns(beegoCtx *beegoContext.Context, req domain.GetReturnListRequest) (*database.Paginator, error)
+	GetReturn(beegoCtx *beegoContext.Context, returnID int) (*domain.Return, error)
+	ReviewReturn(beegoCtx *beegoContext.Context, req domain.ReviewReturnRequest) (*domain.Return, error)
+	InspectReturn(beegoCtx *beegoContext.Context, req domain.InspectReturnRequest) (*domain.Return, error)
+}
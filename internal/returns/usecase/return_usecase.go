@@ -0,0 +1,397 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/inventory"
+	"github.com/online-store/internal/returns"
+	"github.com/online-store/internal/stockalert"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type ReturnUseCase struct {
+	returnRepo    returns.Repository
+	inventoryRepo inventory.Repository
+	stockAlertUC  stockalert.UseCase
+	zapLogger     zaplogger.Logger
+}
+
+func NewReturnUseCase(returnRepo returns.Repository, inventoryRepo inventory.Repository, stockAlertUC stockalert.UseCase, zapLogger zaplogger.Logger) returns.UseCase {
+	return &ReturnUseCase{
+		returnRepo:    returnRepo,
+		inventoryRepo: inventoryRepo,
+		stockAlertUC:  stockAlertUC,
+		zapLogger:     zapLogger,
+	}
+}
+
+// CreateReturn asks to return delivered items of an order of the customer, no more
+// of an item than was delivered and is not in another return yet.
+func (u *ReturnUseCase) CreateReturn(beegoCtx *beegoContext.Context, req domain.CreateReturnRequest) (*domain.Return, error) {
+	var data *domain.Return
+
+	//start transaction
+	errs := u.returnRepo.DB().Transaction(func(tx *gorm.DB) error {
+		order, err := u.returnRepo.GetOrderForUpdate(beegoCtx.Request.Context(), tx, req.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.CustomerID != req.CustomerID {
+			return gorm.ErrRecordNotFound
+		}
+
+		items, err := u.returnRepo.GetOrderItems(beegoCtx.Request.Context(), tx, req.OrderID)
+		if err != nil {
+			return err
+		}
+		returnable, err := u.returnRepo.GetReturnableItems(beegoCtx.Request.Context(), tx, req.OrderID)
+		if err != nil {
+			return err
+		}
+
+		//what is left to return of each item
+		left := make(map[int]int, len(returnable))
+		for _, v := range returnable {
+			left[v.OrderItemID] = v.Delivered - v.Returned
+		}
+		itemByID := make(map[int]domain.OrderItem, len(items))
+		for _, v := range items {
+			itemByID[v.ID] = v
+		}
+
+		now := time.Now()
+		returnReq := domain.Return{
+			OrderID:      req.OrderID,
+			CustomerID:   req.CustomerID,
+			Status:       domain.ReturnStatusRequested,
+			RefundAmount: money.Zero(money.DefaultCurrency()),
+			CreatedAt:    now,
+			CreatedBy:    "System",
+		}
+
+		returnItems := make([]domain.ReturnItem, 0, len(req.Items))
+		for _, v := range req.Items {
+			if v.Quantity > left[v.OrderItemID] {
+				return domain.ErrReturnQuantity
+			}
+			left[v.OrderItemID] -= v.Quantity
+
+			refund, err := itemByID[v.OrderItemID].RefundAmount(v.Quantity, order.TaxMode)
+			if err != nil {
+				return err
+			}
+			if returnReq.RefundAmount, err = returnReq.RefundAmount.Add(refund); err != nil {
+				return err
+			}
+			returnItems = append(returnItems, domain.ReturnItem{OrderItemID: v.OrderItemID, Quantity: v.Quantity, Reason: v.Reason, Note: v.Note, RefundAmount: refund})
+		}
+
+		data, err = u.returnRepo.InsertReturn(beegoCtx.Request.Context(), tx, returnReq)
+		if err != nil {
+			return err
+		}
+
+		for i := range returnItems {
+			returnItems[i].ReturnID = data.ID
+		}
+		if err := u.returnRepo.InsertReturnItems(beegoCtx.Request.Context(), tx, returnItems); err != nil {
+			return err
+		}
+
+		return u.returnRepo.InsertReturnEvent(beegoCtx.Request.Context(), tx, domain.ReturnEvent{
+			ReturnID:  data.ID,
+			Status:    domain.ReturnStatusRequested,
+			Actor:     domain.CustomerActor(req.CustomerID),
+			CreatedAt: now,
+		})
+	})
+
+	if errs != nil {
+		if !errors.Is(errs, gorm.ErrRecordNotFound) && !errors.Is(errs, domain.ErrReturnQuantity) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		}
+		return nil, errs
+	}
+
+	return u.GetReturn(beegoCtx, data.ID)
+}
+
+// GetReturns lists the returns, of a customer only when CustomerID is set.
+func (u *ReturnUseCase) GetReturns(beegoCtx *beegoContext.Context, req domain.GetReturnListRequest) (*database.Paginator, error) {
+	var entities []domain.Return
+
+	filter := ` WHERE deleted_at IS NULL`
+	var args []interface{}
+	if req.CustomerID != 0 {
+		filter += ` AND customer_id = ?`
+		args = append(args, req.CustomerID)
+	}
+	if req.Status != "" {
+		filter += ` AND status = ?`
+		args = append(args, req.Status)
+	}
+
+	query := `SELECT * FROM return_request` + filter
+	countQuery := `SELECT COUNT(*) FROM return_request` + filter
+
+	data, err := u.returnRepo.FetchWithFilterAndPagination(
+		beegoCtx.Request.Context(),
+		req.Page,
+		req.Limit,
+		query,
+		countQuery,
+		"ORDER BY id DESC",
+		&entities,
+		args...,
+	)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	if err := u.fillItems(beegoCtx.Request.Context(), u.returnRepo.DB(), entities); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetReturn returns the return with its items, its steps and its refund.
+func (u *ReturnUseCase) GetReturn(beegoCtx *beegoContext.Context, returnID int) (*domain.Return, error) {
+	data, err := u.returnRepo.GetReturnByID(beegoCtx.Request.Context(), returnID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return nil, err
+	}
+
+	entities := []domain.Return{data}
+	if err := u.fillItems(beegoCtx.Request.Context(), u.returnRepo.DB(), entities); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	if entities[0].Events, err = u.returnRepo.GetReturnEvents(beegoCtx.Request.Context(), returnID); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	if entities[0].Refund, err = u.returnRepo.GetRefundByReturnID(beegoCtx.Request.Context(), returnID); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return &entities[0], nil
+}
+
+// ReviewReturn approves, rejects or receives a return. Approving it refunds the
+// customer in the currency the order was charged in.
+func (u *ReturnUseCase) ReviewReturn(beegoCtx *beegoContext.Context, req domain.ReviewReturnRequest) (*domain.Return, error) {
+	//start transaction
+	errs := u.returnRepo.DB().Transaction(func(tx *gorm.DB) error {
+		data, err := u.returnRepo.GetReturnForUpdate(beegoCtx.Request.Context(), tx, req.ID)
+		if err != nil {
+			return err
+		}
+		if req.Status == domain.ReturnStatusCompleted || !data.CanMoveTo(req.Status) {
+			return domain.ErrReturnStatus
+		}
+
+		now := time.Now()
+		if err := u.moveReturn(beegoCtx.Request.Context(), tx, data.ID, req.Status, req.Note, now); err != nil {
+			return err
+		}
+
+		if req.Status != domain.ReturnStatusApproved {
+			return nil
+		}
+
+		order, err := u.returnRepo.GetOrderForUpdate(beegoCtx.Request.Context(), tx, data.OrderID)
+		if err != nil {
+			return err
+		}
+
+		refund := domain.Refund{
+			ReturnID:      data.ID,
+			OrderID:       data.OrderID,
+			PaymentID:     order.PaymentID,
+			Amount:        data.RefundAmount,
+			Currency:      data.RefundAmount.Currency,
+			ChargedAmount: data.RefundAmount,
+			Status:        domain.RefundStatusSuccess,
+			CreatedAt:     now,
+			CreatedBy:     domain.InventoryActorAdmin,
+		}
+		if order.Currency != "" {
+			refund.Currency = order.Currency
+			if refund.ChargedAmount, err = (domain.ExchangeRate{Currency: order.Currency, Rate: order.ExchangeRate}).Convert(data.RefundAmount); err != nil {
+				return err
+			}
+		}
+		_, err = u.returnRepo.InsertRefund(beegoCtx.Request.Context(), tx, refund)
+		return err
+	})
+
+	if errs != nil {
+		if !errors.Is(errs, gorm.ErrRecordNotFound) && !errors.Is(errs, domain.ErrReturnStatus) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		}
+		return nil, errs
+	}
+
+	return u.GetReturn(beegoCtx, req.ID)
+}
+
+// InspectReturn completes a received return. Each item is restocked, in the
+// default warehouse unless one is given, or written off, which leaves the stock
+// as it is.
+func (u *ReturnUseCase) InspectReturn(beegoCtx *beegoContext.Context, req domain.InspectReturnRequest) (*domain.Return, error) {
+	var productIDs []int
+
+	//start transaction
+	errs := u.returnRepo.DB().Transaction(func(tx *gorm.DB) error {
+		data, err := u.returnRepo.GetReturnForUpdate(beegoCtx.Request.Context(), tx, req.ID)
+		if err != nil {
+			return err
+		}
+		if !data.CanMoveTo(domain.ReturnStatusCompleted) {
+			return domain.ErrReturnStatus
+		}
+
+		items, err := u.returnRepo.GetReturnItems(beegoCtx.Request.Context(), tx, []int{data.ID})
+		if err != nil {
+			return err
+		}
+
+		//every item is inspected once
+		inspections := make(map[int]domain.ReturnInspectionRequest, len(req.Items))
+		for _, v := range req.Items {
+			if _, ok := inspections[v.OrderItemID]; ok {
+				return domain.ErrReturnInspection
+			}
+			inspections[v.OrderItemID] = v
+		}
+		if len(inspections) != len(items) {
+			return domain.ErrReturnInspection
+		}
+
+		now := time.Now()
+		reference := domain.ReturnReference(data.ID)
+		var movements []domain.InventoryMovement
+		for _, v := range items {
+			inspection, ok := inspections[v.OrderItemID]
+			if !ok {
+				return domain.ErrReturnInspection
+			}
+
+			updates := map[string]interface{}{"outcome": inspection.Outcome}
+			if inspection.Outcome == domain.ReturnOutcomeRestock {
+				warehouseID := inspection.WarehouseID
+				if warehouseID == nil {
+					defaultID, err := u.inventoryRepo.GetDefaultWarehouseID(beegoCtx.Request.Context(), tx)
+					if err != nil {
+						return err
+					}
+					warehouseID = &defaultID
+				}
+
+				if _, err := u.inventoryRepo.AddWarehouseStock(beegoCtx.Request.Context(), tx, *warehouseID, v.ProductID, v.VariantID, v.Quantity); err != nil {
+					if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeForeignKeyConstraint {
+						return gorm.ErrRecordNotFound
+					}
+					return err
+				}
+				movements = append(movements, domain.InventoryMovement{
+					ProductID:   v.ProductID,
+					VariantID:   v.VariantID,
+					WarehouseID: warehouseID,
+					Quantity:    v.Quantity,
+					Reason:      domain.InventoryReasonRefundRestock,
+					Reference:   &reference,
+					Note:        req.Note,
+					Actor:       domain.InventoryActorAdmin,
+					CreatedAt:   now,
+				})
+				productIDs = append(productIDs, v.ProductID)
+				updates["warehouse_id"] = *warehouseID
+			}
+
+			if _, err := u.returnRepo.UpdateReturnItem(beegoCtx.Request.Context(), tx, data.ID, v.OrderItemID, updates); err != nil {
+				return err
+			}
+		}
+
+		if err := u.inventoryRepo.InsertMovements(beegoCtx.Request.Context(), tx, movements); err != nil {
+			return err
+		}
+
+		return u.moveReturn(beegoCtx.Request.Context(), tx, data.ID, domain.ReturnStatusCompleted, req.Note, now)
+	})
+
+	if errs != nil {
+		if !errors.Is(errs, gorm.ErrRecordNotFound) && !errors.Is(errs, domain.ErrReturnStatus) && !errors.Is(errs, domain.ErrReturnInspection) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		}
+		return nil, errs
+	}
+
+	if len(productIDs) > 0 {
+		u.stockAlertUC.CheckStockInBackground(productIDs)
+	}
+
+	return u.GetReturn(beegoCtx, req.ID)
+}
+
+// moveReturn moves the return to status and records the step, the admin takes
+// every step but the request.
+func (u *ReturnUseCase) moveReturn(ctx context.Context, tx *gorm.DB, returnID int, status string, note *string, now time.Time) error {
+	affected, err := u.returnRepo.UpdateReturn(ctx, tx, returnID, map[string]interface{}{
+		"status":     status,
+		"updated_at": now,
+		"updated_by": "System",
+	})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return u.returnRepo.InsertReturnEvent(ctx, tx, domain.ReturnEvent{
+		ReturnID:  returnID,
+		Status:    status,
+		Actor:     domain.InventoryActorAdmin,
+		Note:      note,
+		CreatedAt: now,
+	})
+}
+
+func (u *ReturnUseCase) fillItems(ctx context.Context, tx *gorm.DB, entities []domain.Return) error {
+	returnIDs := make([]int, 0, len(entities))
+	for _, v := range entities {
+		returnIDs = append(returnIDs, v.ID)
+	}
+	items, err := u.returnRepo.GetReturnItems(ctx, tx, returnIDs)
+	if err != nil {
+		return err
+	}
+
+	itemsByReturn := make(map[int][]domain.ReturnItem)
+	for _, v := range items {
+		itemsByReturn[v.ReturnID] = append(itemsByReturn[v.ReturnID], v)
+	}
+	for i := range entities {
+		entities[i].Items = itemsByReturn[entities[i].ID]
+	}
+	return nil
+}
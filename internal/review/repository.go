@@ -0,0 +1,21 @@
+package review
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	HasDeliveredProduct(ctx context.Context, customerID, productID int) (bool, error)
+	InsertReview(ctx context.Context, data domain.Review) (*domain.Review, error)
+	GetReviewByID(ctx context.Context, reviewID int) (domain.Review, error)
+	GetReviewForUpdate(ctx context.Context, tx *gorm.DB, reviewID int) (domain.Review, error)
+	UpdateReview(ctx context.Context, tx *gorm.DB, reviewID int, data map[string]interface{}) (int64, error)
+	UpdateProductRating(ctx context.Context, tx *gorm.DB, productID int) error
+	InsertReviewVote(ctx context.Context, tx *gorm.DB, data domain.ReviewVote) error
+	IncrementHelpfulCount(ctx context.Context, tx *gorm.DB, reviewID int) (int64, error)
+	FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+}
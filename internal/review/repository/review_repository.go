@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/review"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type ReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) review.Repository {
+	return &ReviewRepository{db}
+}
+
+func (r *ReviewRepository) DB() *gorm.DB {
+	return r.db
+}
+
+// HasDeliveredProduct tells whether a shipment delivered the product to the customer.
+func (r *ReviewRepository) HasDeliveredProduct(ctx context.Context, customerID, productID int) (bool, error) {
+	var delivered bool
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT EXISTS (SELECT 1 FROM shipment_item si 
+					JOIN shipment s ON s.id = si.shipment_id 
+					JOIN order_item oi ON oi.id = si.order_item_id 
+					JOIN "order" o ON o.id = oi.order_id 
+					WHERE o.customer_id = ? AND oi.product_id = ? AND s.status = ? AND s.deleted_at IS NULL AND o.deleted_at IS NULL)`,
+		customerID, productID, domain.ShipmentStatusDelivered).Scan(&delivered).Error
+	return delivered, err
+}
+
+func (r *ReviewRepository) InsertReview(ctx context.Context, data domain.Review) (*domain.Review, error) {
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *ReviewRepository) GetReviewByID(ctx context.Context, reviewID int) (domain.Review, error) {
+	var data domain.Review
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", reviewID).First(&data).Error
+	return data, err
+}
+
+// GetReviewForUpdate locks the review, it is moderated once at a time.
+func (r *ReviewRepository) GetReviewForUpdate(ctx context.Context, tx *gorm.DB, reviewID int) (domain.Review, error) {
+	var data domain.Review
+
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT * FROM review WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, reviewID).Scan(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return data, gorm.ErrRecordNotFound
+	}
+	return data, result.Error
+}
+
+func (r *ReviewRepository) UpdateReview(ctx context.Context, tx *gorm.DB, reviewID int, data map[string]interface{}) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("review").Where("id = ? AND deleted_at IS NULL", reviewID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
+
+// UpdateProductRating recounts the approved reviews of the product into its rating.
+// The product is locked first so the recount sees the reviews moderated before it.
+func (r *ReviewRepository) UpdateProductRating(ctx context.Context, tx *gorm.DB, productID int) error {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+
+	if err := db.Exec(`SELECT id FROM product WHERE id = ? FOR UPDATE`, productID).Error; err != nil {
+		return err
+	}
+	return db.Exec(`UPDATE product SET 
+					rating_average = COALESCE((SELECT ROUND(AVG(rating), 2) FROM review WHERE product_id = ? AND status = ? AND deleted_at IS NULL), 0), 
+					rating_count = (SELECT COUNT(*) FROM review WHERE product_id = ? AND status = ? AND deleted_at IS NULL) 
+				WHERE id = ?`, productID, domain.ReviewStatusApproved, productID, domain.ReviewStatusApproved, productID).Error
+}
+
+func (r *ReviewRepository) InsertReviewVote(ctx context.Context, tx *gorm.DB, data domain.ReviewVote) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Create(&data).Error
+}
+
+func (r *ReviewRepository) IncrementHelpfulCount(ctx context.Context, tx *gorm.DB, reviewID int) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("review").Where("id = ? AND deleted_at IS NULL", reviewID).
+		Update("helpful_count", gorm.Expr("helpful_count + 1"))
+	return result.RowsAffected, result.Error
+}
+
+func (r *ReviewRepository) FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	paginate := database.NewPaginator(r.db, page, pageSize, model).Raw(query, args, countQuery, args)
+
+	if err := paginate.FindWithOrderBy(ctx, orderBy).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
@@ -0,0 +1,14 @@
+package review
+
+import (
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+)
+
+type UseCase interface {
+	CreateReview(beegoCtx *beegoContext.Context, req domain.CreateReviewRequest) (*domain.Review, error)
+	GetReviews(beegoCtx *beegoContext.Context, req domain.GetReviewListRequest) (*database.Paginator, error)
+	ModerateReview(beegoCtx *beegoContext.Context, req domain.ModerateReviewRequest) (*domain.Review, error)
+	MarkHelpful(beegoCtx *beegoContext.Context, reviewID, customerID int) (*domain.Review, error)
+}
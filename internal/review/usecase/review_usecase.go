@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/review"
+	"github.com/online-store/pkg/cache"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type ReviewUseCase struct {
+	reviewRepo review.Repository
+	cacheRepo  cache.RedisRepository
+	zapLogger  zaplogger.Logger
+}
+
+func NewReviewUseCase(reviewRepo review.Repository, cacheRepo cache.RedisRepository, zapLogger zaplogger.Logger) review.UseCase {
+	return &ReviewUseCase{
+		reviewRepo: reviewRepo,
+		cacheRepo:  cacheRepo,
+		zapLogger:  zapLogger,
+	}
+}
+
+// CreateReview reviews a product delivered to the customer, once per product. The
+// review waits for an admin to approve it.
+func (u *ReviewUseCase) CreateReview(beegoCtx *beegoContext.Context, req domain.CreateReviewRequest) (*domain.Review, error) {
+	delivered, err := u.reviewRepo.HasDeliveredProduct(beegoCtx.Request.Context(), req.CustomerID, req.ProductID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	if !delivered {
+		return nil, domain.ErrReviewNotAllowed
+	}
+
+	data, err := u.reviewRepo.InsertReview(beegoCtx.Request.Context(), domain.Review{
+		ProductID:  req.ProductID,
+		CustomerID: req.CustomerID,
+		Rating:     req.Rating,
+		Title:      req.Title,
+		Body:       req.Body,
+		Status:     domain.ReviewStatusPending,
+		CreatedAt:  time.Now(),
+		CreatedBy:  domain.CustomerActor(req.CustomerID),
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeUniqueConstraint {
+			return nil, domain.ErrUniqueConstraint
+		}
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetReviews lists the reviews, of a product only when ProductID is set, the newest
+// first or the most helpful first.
+func (u *ReviewUseCase) GetReviews(beegoCtx *beegoContext.Context, req domain.GetReviewListRequest) (*database.Paginator, error) {
+	var entities []domain.Review
+
+	filter := ` WHERE r.deleted_at IS NULL`
+	var args []interface{}
+	if req.ProductID != 0 {
+		filter += ` AND r.product_id = ?`
+		args = append(args, req.ProductID)
+	}
+	if req.Status != "" {
+		filter += ` AND r.status = ?`
+		args = append(args, req.Status)
+	}
+
+	query := `SELECT r.*, COALESCE(c.first_name, '') AS customer_name FROM review r JOIN customer c ON c.customer_id = r.customer_id` + filter
+	countQuery := `SELECT COUNT(*) FROM review r` + filter
+
+	orderBy := "ORDER BY r.created_at DESC, r.id DESC"
+	if req.Sort == domain.ReviewSortHelpful {
+		orderBy = "ORDER BY r.helpful_count DESC, r.created_at DESC, r.id DESC"
+	}
+
+	data, err := u.reviewRepo.FetchWithFilterAndPagination(
+		beegoCtx.Request.Context(),
+		req.Page,
+		req.Limit,
+		query,
+		countQuery,
+		orderBy,
+		&entities,
+		args...,
+	)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ModerateReview approves or rejects a review. The rating of the product is counted
+// again whenever an approved review comes in or goes out of it.
+func (u *ReviewUseCase) ModerateReview(beegoCtx *beegoContext.Context, req domain.ModerateReviewRequest) (*domain.Review, error) {
+	var (
+		data  domain.Review
+		rated bool
+	)
+
+	//start transaction
+	errs := u.reviewRepo.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		data, err = u.reviewRepo.GetReviewForUpdate(beegoCtx.Request.Context(), tx, req.ID)
+		if err != nil {
+			return err
+		}
+		if data.Status == req.Status {
+			return nil
+		}
+		previous := data.Status
+
+		now := time.Now()
+		updatedBy := domain.InventoryActorAdmin
+		if _, err := u.reviewRepo.UpdateReview(beegoCtx.Request.Context(), tx, req.ID, map[string]interface{}{
+			"status":     req.Status,
+			"updated_at": now,
+			"updated_by": updatedBy,
+		}); err != nil {
+			return err
+		}
+		data.Status = req.Status
+		data.UpdatedAt = &now
+		data.UpdatedBy = &updatedBy
+
+		if previous != domain.ReviewStatusApproved && req.Status != domain.ReviewStatusApproved {
+			return nil
+		}
+		rated = true
+		return u.reviewRepo.UpdateProductRating(beegoCtx.Request.Context(), tx, data.ProductID)
+	})
+
+	if errs != nil {
+		if !errors.Is(errs, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		}
+		return nil, errs
+	}
+
+	//the listed products show their rating
+	if rated {
+		if err := u.cacheRepo.Deletes(beegoCtx.Request.Context(), []string{domain.ProductListKeyCache}); err != nil {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+	}
+
+	return &data, nil
+}
+
+// MarkHelpful records the customer finding an approved review helpful, once per
+// review.
+func (u *ReviewUseCase) MarkHelpful(beegoCtx *beegoContext.Context, reviewID, customerID int) (*domain.Review, error) {
+	data, err := u.reviewRepo.GetReviewByID(beegoCtx.Request.Context(), reviewID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return nil, err
+	}
+	if data.Status != domain.ReviewStatusApproved {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	//start transaction
+	errs := u.reviewRepo.DB().Transaction(func(tx *gorm.DB) error {
+		if err := u.reviewRepo.InsertReviewVote(beegoCtx.Request.Context(), tx, domain.ReviewVote{
+			ReviewID:   reviewID,
+			CustomerID: customerID,
+			CreatedAt:  time.Now(),
+		}); err != nil {
+			return err
+		}
+		_, err := u.reviewRepo.IncrementHelpfulCount(beegoCtx.Request.Context(), tx, reviewID)
+		return err
+	})
+
+	if errs != nil {
+		if pgErr, ok := errs.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeUniqueConstraint {
+			return nil, domain.ErrUniqueConstraint
+		}
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		return nil, errs
+	}
+
+	data.HelpfulCount++
+	return &data, nil
+}
@@ -2,12 +2,21 @@ package internal
 
 import (
 	beego "github.com/beego/beego/v2/server/web"
+	"github.com/online-store/internal/apikey"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/cache"
 	"github.com/online-store/pkg/httpclient"
 	"github.com/online-store/pkg/middleware"
 	"github.com/online-store/pkg/response"
 	"github.com/online-store/pkg/zaplogger"
 )
 
-func InitRouterFilters(restyHttpClient *httpclient.RestyHttpClient, log zaplogger.Logger, apiResponse response.APIResponseInterface) {
-	beego.InsertFilterChain("/customer/*", middleware.NewAuthMiddleware(apiResponse).ValidateAuth())
+func InitRouterFilters(restyHttpClient *httpclient.RestyHttpClient, log zaplogger.Logger, apiResponse response.APIResponseInterface, apiKeyUseCase apikey.UseCase, cacheRepo cache.RedisRepository) {
+	beego.InsertFilterChain("/customer/*", middleware.NewAuthMiddleware(apiResponse, cacheRepo).ValidateAuth())
+	beego.InsertFilterChain("/admin/*", middleware.NewAdminMiddleware(apiResponse).ValidateAdmin())
+
+	apiKeyMiddleware := middleware.NewApiKeyMiddleware(apiResponse, apiKeyUseCase)
+	beego.InsertFilterChain("/partner/v1/products", apiKeyMiddleware.ValidateApiKey(domain.ScopeCatalogRead))
+	beego.InsertFilterChain("/partner/v1/products/*", apiKeyMiddleware.ValidateApiKey(domain.ScopeCatalogRead))
+	beego.InsertFilterChain("/partner/v1/order/*", apiKeyMiddleware.ValidateApiKey(domain.ScopeOrderWrite))
 }
@@ -0,0 +1,21 @@
+package shipment
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	GetOrderByID(ctx context.Context, orderID int) (domain.Order, error)
+	GetOrderForUpdate(ctx context.Context, tx *gorm.DB, orderID int) (domain.Order, error)
+	GetOrderItems(ctx context.Context, tx *gorm.DB, orderID int) ([]domain.OrderItem, error)
+	UpdateOrderStatus(ctx context.Context, tx *gorm.DB, orderID int, status string) error
+	InsertShipment(ctx context.Context, tx *gorm.DB, data domain.Shipment) (*domain.Shipment, error)
+	InsertShipmentItems(ctx context.Context, tx *gorm.DB, data []domain.ShipmentItem) error
+	GetShipmentByID(ctx context.Context, tx *gorm.DB, shipmentID int) (domain.Shipment, error)
+	GetShipmentsByOrderID(ctx context.Context, tx *gorm.DB, orderID int) ([]domain.Shipment, error)
+	GetShipmentItems(ctx context.Context, tx *gorm.DB, shipmentIDs []int) ([]domain.ShipmentItem, error)
+	UpdateShipment(ctx context.Context, tx *gorm.DB, shipmentID int, data map[string]interface{}) (int64, error)
+}
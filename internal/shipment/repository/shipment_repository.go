@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/shipment"
+	"gorm.io/gorm"
+)
+
+type ShipmentRepository struct {
+	db *gorm.DB
+}
+
+func NewShipmentRepository(db *gorm.DB) shipment.Repository {
+	return &ShipmentRepository{db}
+}
+
+func (r *ShipmentRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *ShipmentRepository) GetOrderByID(ctx context.Context, orderID int) (domain.Order, error) {
+	var data domain.Order
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", orderID).First(&data).Error
+	data.SetChargedCurrency()
+	return data, err
+}
+
+// GetOrderForUpdate locks the order, its shipments change one at a time.
+func (r *ShipmentRepository) GetOrderForUpdate(ctx context.Context, tx *gorm.DB, orderID int) (domain.Order, error) {
+	var data domain.Order
+
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT * FROM "order" WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, orderID).Scan(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return data, gorm.ErrRecordNotFound
+	}
+	data.SetChargedCurrency()
+	return data, result.Error
+}
+
+func (r *ShipmentRepository) GetOrderItems(ctx context.Context, tx *gorm.DB, orderID int) ([]domain.OrderItem, error) {
+	var data []domain.OrderItem
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("order_id = ? AND deleted_at IS NULL", orderID).Order("id").Find(&data).Error
+	return data, err
+}
+
+// UpdateOrderStatus moves a paid order to the status, unpaid and cancelled orders
+// are left as they are.
+func (r *ShipmentRepository) UpdateOrderStatus(ctx context.Context, tx *gorm.DB, orderID int, status string) error {
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("order").Where("id = ? AND status <> ? AND status IN ?", orderID, status, domain.OrderPaidStatuses).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": gorm.Expr("now()"),
+			"updated_by": "System",
+		}).Error
+}
+
+func (r *ShipmentRepository) InsertShipment(ctx context.Context, tx *gorm.DB, data domain.Shipment) (*domain.Shipment, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *ShipmentRepository) InsertShipmentItems(ctx context.Context, tx *gorm.DB, data []domain.ShipmentItem) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).CreateInBatches(&data, 100).Error
+}
+
+func (r *ShipmentRepository) GetShipmentByID(ctx context.Context, tx *gorm.DB, shipmentID int) (domain.Shipment, error) {
+	var data domain.Shipment
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", shipmentID).First(&data).Error
+	return data, err
+}
+
+func (r *ShipmentRepository) GetShipmentsByOrderID(ctx context.Context, tx *gorm.DB, orderID int) ([]domain.Shipment, error) {
+	var data []domain.Shipment
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("order_id = ? AND deleted_at IS NULL", orderID).Order("id").Find(&data).Error
+	return data, err
+}
+
+func (r *ShipmentRepository) GetShipmentItems(ctx context.Context, tx *gorm.DB, shipmentIDs []int) ([]domain.ShipmentItem, error) {
+	var data []domain.ShipmentItem
+	if len(shipmentIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT si.shipment_id, si.order_item_id, oi.product_id, oi.variant_id, si.quantity 
+				FROM shipment_item si 
+				JOIN order_item oi ON oi.id = si.order_item_id 
+				WHERE si.shipment_id IN ? 
+				ORDER BY si.shipment_id, si.order_item_id`, shipmentIDs).Scan(&data).Error
+	return data, err
+}
+
+func (r *ShipmentRepository) UpdateShipment(ctx context.Context, tx *gorm.DB, shipmentID int, data map[string]interface{}) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("shipment").Where("id = ? AND deleted_at IS NULL", shipmentID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,13 @@
+package shipment
+
+import (
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+)
+
+type UseCase interface {
+	CreateShipment(beegoCtx *beegoContext.Context, req domain.CreateShipmentRequest) (*domain.Shipment, error)
+	UpdateShipment(beegoCtx *beegoContext.Context, req domain.UpdateShipmentRequest) (*domain.Shipment, error)
+	GetOrderShipments(beegoCtx *beegoContext.Context, orderID int) ([]domain.Shipment, error)
+	GetCustomerOrderShipments(beegoCtx *beegoContext.Context, orderID, customerID int) ([]domain.Shipment, error)
+}
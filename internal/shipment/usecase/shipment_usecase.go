@@ -0,0 +1,258 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/shipment"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type ShipmentUseCase struct {
+	shipmentRepo shipment.Repository
+	zapLogger    zaplogger.Logger
+}
+
+func NewShipmentUseCase(shipmentRepo shipment.Repository, zapLogger zaplogger.Logger) shipment.UseCase {
+	return &ShipmentUseCase{
+		shipmentRepo: shipmentRepo,
+		zapLogger:    zapLogger,
+	}
+}
+
+// CreateShipment ships items of a paid order, no more of an item than is left to
+// ship by the shipments not cancelled.
+func (u *ShipmentUseCase) CreateShipment(beegoCtx *beegoContext.Context, req domain.CreateShipmentRequest) (*domain.Shipment, error) {
+	var data *domain.Shipment
+
+	if req.Status == "" {
+		req.Status = domain.ShipmentStatusPending
+	}
+
+	//start transaction
+	errs := u.shipmentRepo.DB().Transaction(func(tx *gorm.DB) error {
+		order, err := u.shipmentRepo.GetOrderForUpdate(beegoCtx.Request.Context(), tx, req.OrderID)
+		if err != nil {
+			return err
+		}
+		if !domain.IsPaidOrderStatus(order.Status) || order.Status == domain.OrderStatusDelivered {
+			return domain.ErrOrderNotShippable
+		}
+
+		items, shipments, err := u.orderShipments(beegoCtx.Request.Context(), tx, req.OrderID)
+		if err != nil {
+			return err
+		}
+
+		//what is left to ship of each item
+		left := make(map[int]int, len(items))
+		for _, v := range items {
+			left[v.ID] = v.Quantity
+		}
+		for _, s := range shipments {
+			if s.Status == domain.ShipmentStatusCancelled {
+				continue
+			}
+			for _, v := range s.Items {
+				left[v.OrderItemID] -= v.Quantity
+			}
+		}
+		for _, v := range req.Items {
+			if v.Quantity > left[v.OrderItemID] {
+				return domain.ErrShipmentQuantity
+			}
+			left[v.OrderItemID] -= v.Quantity
+		}
+
+		now := time.Now()
+		shipmentReq := domain.Shipment{
+			OrderID:        req.OrderID,
+			Carrier:        req.Carrier,
+			TrackingNumber: req.TrackingNumber,
+			Status:         req.Status,
+			CreatedAt:      now,
+			CreatedBy:      "System",
+		}
+		if req.Status == domain.ShipmentStatusShipped {
+			shipmentReq.ShippedAt = &now
+		}
+
+		data, err = u.shipmentRepo.InsertShipment(beegoCtx.Request.Context(), tx, shipmentReq)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range req.Items {
+			data.Items = append(data.Items, domain.ShipmentItem{ShipmentID: data.ID, OrderItemID: v.OrderItemID, Quantity: v.Quantity})
+		}
+		if err := u.shipmentRepo.InsertShipmentItems(beegoCtx.Request.Context(), tx, data.Items); err != nil {
+			return err
+		}
+
+		return u.advanceOrder(beegoCtx.Request.Context(), tx, req.OrderID, items, append(shipments, *data))
+	})
+
+	if errs != nil {
+		if !errors.Is(errs, gorm.ErrRecordNotFound) && !errors.Is(errs, domain.ErrOrderNotShippable) && !errors.Is(errs, domain.ErrShipmentQuantity) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		}
+		return nil, errs
+	}
+
+	return u.getShipment(beegoCtx, data.ID)
+}
+
+// UpdateShipment changes the carrier and the tracking number, and moves the
+// shipment along its statuses. The order follows its shipments.
+func (u *ShipmentUseCase) UpdateShipment(beegoCtx *beegoContext.Context, req domain.UpdateShipmentRequest) (*domain.Shipment, error) {
+	//start transaction
+	errs := u.shipmentRepo.DB().Transaction(func(tx *gorm.DB) error {
+		data, err := u.shipmentRepo.GetShipmentByID(beegoCtx.Request.Context(), tx, req.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := u.shipmentRepo.GetOrderForUpdate(beegoCtx.Request.Context(), tx, data.OrderID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"updated_at": now,
+			"updated_by": "System",
+		}
+		if req.Carrier != nil {
+			updates["carrier"] = *req.Carrier
+		}
+		if req.TrackingNumber != nil {
+			updates["tracking_number"] = *req.TrackingNumber
+		}
+		if req.Status != nil && *req.Status != data.Status {
+			if !data.CanMoveTo(*req.Status) {
+				return domain.ErrShipmentStatus
+			}
+			updates["status"] = *req.Status
+			switch *req.Status {
+			case domain.ShipmentStatusShipped:
+				updates["shipped_at"] = now
+			case domain.ShipmentStatusDelivered:
+				if data.ShippedAt == nil {
+					updates["shipped_at"] = now
+				}
+				updates["delivered_at"] = now
+			}
+		}
+
+		affected, err := u.shipmentRepo.UpdateShipment(beegoCtx.Request.Context(), tx, req.ID, updates)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		items, shipments, err := u.orderShipments(beegoCtx.Request.Context(), tx, data.OrderID)
+		if err != nil {
+			return err
+		}
+		return u.advanceOrder(beegoCtx.Request.Context(), tx, data.OrderID, items, shipments)
+	})
+
+	if errs != nil {
+		if !errors.Is(errs, gorm.ErrRecordNotFound) && !errors.Is(errs, domain.ErrShipmentStatus) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		}
+		return nil, errs
+	}
+
+	return u.getShipment(beegoCtx, req.ID)
+}
+
+func (u *ShipmentUseCase) GetOrderShipments(beegoCtx *beegoContext.Context, orderID int) ([]domain.Shipment, error) {
+	_, data, err := u.orderShipments(beegoCtx.Request.Context(), u.shipmentRepo.DB(), orderID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetCustomerOrderShipments tracks the shipments of an order of the customer, the
+// orders of other customers are not found.
+func (u *ShipmentUseCase) GetCustomerOrderShipments(beegoCtx *beegoContext.Context, orderID, customerID int) ([]domain.Shipment, error) {
+	order, err := u.shipmentRepo.GetOrderByID(beegoCtx.Request.Context(), orderID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return u.GetOrderShipments(beegoCtx, orderID)
+}
+
+// orderShipments returns the items of the order and its shipments with their items.
+func (u *ShipmentUseCase) orderShipments(ctx context.Context, tx *gorm.DB, orderID int) ([]domain.OrderItem, []domain.Shipment, error) {
+	items, err := u.shipmentRepo.GetOrderItems(ctx, tx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	shipments, err := u.shipmentRepo.GetShipmentsByOrderID(ctx, tx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := u.fillItems(ctx, tx, shipments); err != nil {
+		return nil, nil, err
+	}
+
+	return items, shipments, nil
+}
+
+func (u *ShipmentUseCase) fillItems(ctx context.Context, tx *gorm.DB, shipments []domain.Shipment) error {
+	shipmentIDs := make([]int, 0, len(shipments))
+	for _, v := range shipments {
+		shipmentIDs = append(shipmentIDs, v.ID)
+	}
+	items, err := u.shipmentRepo.GetShipmentItems(ctx, tx, shipmentIDs)
+	if err != nil {
+		return err
+	}
+
+	itemsByShipment := make(map[int][]domain.ShipmentItem)
+	for _, v := range items {
+		itemsByShipment[v.ShipmentID] = append(itemsByShipment[v.ShipmentID], v)
+	}
+	for i := range shipments {
+		shipments[i].Items = itemsByShipment[shipments[i].ID]
+	}
+	return nil
+}
+
+// advanceOrder moves the order to the status its shipments give it.
+func (u *ShipmentUseCase) advanceOrder(ctx context.Context, tx *gorm.DB, orderID int, items []domain.OrderItem, shipments []domain.Shipment) error {
+	return u.shipmentRepo.UpdateOrderStatus(ctx, tx, orderID, domain.OrderStatusFromShipments(items, shipments))
+}
+
+func (u *ShipmentUseCase) getShipment(beegoCtx *beegoContext.Context, shipmentID int) (*domain.Shipment, error) {
+	data, err := u.shipmentRepo.GetShipmentByID(beegoCtx.Request.Context(), u.shipmentRepo.DB(), shipmentID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	shipments := []domain.Shipment{data}
+	if err := u.fillItems(beegoCtx.Request.Context(), u.shipmentRepo.DB(), shipments); err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return &shipments[0], nil
+}
@@ -0,0 +1,18 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/money"
+)
+
+var ErrUnknownCarrier = errors.New("unknown carrier")
+
+// Carrier quotes the shipping of parcels by a shipping company, the methods of the
+// carrier rate type are priced by the carrier of their code.
+type Carrier interface {
+	Code() string
+	Rate(ctx context.Context, parcel domain.ShippingParcel) (money.Money, error)
+}
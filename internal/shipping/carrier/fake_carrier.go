@@ -0,0 +1,42 @@
+package carrier
+
+import (
+	"context"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/shipping"
+	"github.com/online-store/pkg/money"
+)
+
+type fakeCarrier struct {
+	code      string
+	base      money.Money
+	perKgRate money.Money
+}
+
+// NewFakeCarrier rates parcels locally, base plus perKgRate for every started
+// kilogram, without calling any carrier. It stands in for the carriers in tests
+// and local runs.
+func NewFakeCarrier(code string, base, perKgRate money.Money) shipping.Carrier {
+	return &fakeCarrier{
+		code:      code,
+		base:      base,
+		perKgRate: perKgRate,
+	}
+}
+
+func (c fakeCarrier) Code() string {
+	return c.code
+}
+
+func (c fakeCarrier) Rate(ctx context.Context, parcel domain.ShippingParcel) (money.Money, error) {
+	if err := ctx.Err(); err != nil {
+		return money.Money{}, err
+	}
+
+	perKg, err := c.perKgRate.Mul(int64((parcel.Weight + 999) / 1000))
+	if err != nil {
+		return money.Money{}, err
+	}
+	return c.base.Add(perKg)
+}
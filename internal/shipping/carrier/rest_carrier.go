@@ -0,0 +1,58 @@
+package carrier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/shipping"
+	"github.com/online-store/pkg/httpclient"
+	"github.com/online-store/pkg/money"
+)
+
+type restCarrier struct {
+	code    string
+	baseURL string
+	apiKey  string
+	client  httpclient.RestyHttpClientInterface
+}
+
+type restRateResponse struct {
+	Cost money.Money `json:"cost"`
+}
+
+// NewRestCarrier rates parcels with the rate API of a carrier at baseURL. The
+// parcel is posted to baseURL/rates as JSON, and the cost is read back as
+// {"cost":{"amount":"12.50","currency":"IDR"}}.
+func NewRestCarrier(code, baseURL, apiKey string, client httpclient.RestyHttpClientInterface) shipping.Carrier {
+	return &restCarrier{
+		code:    code,
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		client:  client,
+	}
+}
+
+func (c restCarrier) Code() string {
+	return c.code
+}
+
+func (c restCarrier) Rate(ctx context.Context, parcel domain.ShippingParcel) (money.Money, error) {
+	var result restRateResponse
+
+	resp, err := c.client.Client().R().
+		SetContext(ctx).
+		SetAuthToken(c.apiKey).
+		SetBody(parcel).
+		SetResult(&result).
+		Post(c.baseURL + "/rates")
+	if err != nil {
+		return money.Money{}, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return money.Money{}, fmt.Errorf("carrier %s: rate request failed with status %d", c.code, resp.StatusCode())
+	}
+
+	return result.Cost, nil
+}
@@ -0,0 +1,24 @@
+package shipping
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	InsertZone(ctx context.Context, tx *gorm.DB, data domain.ShippingZone) (*domain.ShippingZone, error)
+	GetZoneByID(ctx context.Context, zoneID int) (domain.ShippingZone, error)
+	UpdateZone(ctx context.Context, tx *gorm.DB, zoneID int, data map[string]interface{}) (int64, error)
+	ReplaceZoneRegions(ctx context.Context, tx *gorm.DB, zoneID int, regions []string) error
+	GetZoneRegions(ctx context.Context, zoneIDs []int) ([]domain.ShippingZoneRegion, error)
+	GetActiveZoneByRegion(ctx context.Context, tx *gorm.DB, region string) (domain.ShippingZone, error)
+	InsertMethod(ctx context.Context, data domain.ShippingMethod) (*domain.ShippingMethod, error)
+	GetMethodByID(ctx context.Context, methodID int) (domain.ShippingMethod, error)
+	UpdateMethod(ctx context.Context, methodID int, data map[string]interface{}) (int64, error)
+	GetActiveMethodsByZoneID(ctx context.Context, tx *gorm.DB, zoneID int) ([]domain.ShippingMethod, error)
+	FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+	GetProductWeights(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.ShippingLine, error)
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/shipping"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type ShippingRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingRepository(db *gorm.DB) shipping.Repository {
+	return &ShippingRepository{db}
+}
+
+func (r *ShippingRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *ShippingRepository) InsertZone(ctx context.Context, tx *gorm.DB, data domain.ShippingZone) (*domain.ShippingZone, error) {
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *ShippingRepository) GetZoneByID(ctx context.Context, zoneID int) (domain.ShippingZone, error) {
+	var data domain.ShippingZone
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", zoneID).First(&data).Error
+	return data, err
+}
+
+func (r *ShippingRepository) UpdateZone(ctx context.Context, tx *gorm.DB, zoneID int, data map[string]interface{}) (int64, error) {
+	result := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("shipping_zone").Where("id = ? AND deleted_at IS NULL", zoneID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
+
+func (r *ShippingRepository) ReplaceZoneRegions(ctx context.Context, tx *gorm.DB, zoneID int, regions []string) error {
+	db := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+	if err := db.Where("zone_id = ?", zoneID).Delete(&domain.ShippingZoneRegion{}).Error; err != nil {
+		return err
+	}
+
+	data := make([]domain.ShippingZoneRegion, 0, len(regions))
+	for _, v := range regions {
+		data = append(data, domain.ShippingZoneRegion{ZoneID: zoneID, Region: v})
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(&data, 100).Error
+}
+
+func (r *ShippingRepository) GetZoneRegions(ctx context.Context, zoneIDs []int) ([]domain.ShippingZoneRegion, error) {
+	var data []domain.ShippingZoneRegion
+	if len(zoneIDs) == 0 {
+		return data, nil
+	}
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("zone_id IN ?", zoneIDs).Order("zone_id, region").Find(&data).Error
+	return data, err
+}
+
+func (r *ShippingRepository) GetActiveZoneByRegion(ctx context.Context, tx *gorm.DB, region string) (domain.ShippingZone, error) {
+	var data domain.ShippingZone
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT z.* FROM shipping_zone z
+				JOIN shipping_zone_region zr ON zr.zone_id = z.id
+				WHERE z.is_active AND z.deleted_at IS NULL AND zr.region = ?`, region).First(&data).Error
+	return data, err
+}
+
+func (r *ShippingRepository) InsertMethod(ctx context.Context, data domain.ShippingMethod) (*domain.ShippingMethod, error) {
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *ShippingRepository) GetMethodByID(ctx context.Context, methodID int) (domain.ShippingMethod, error) {
+	var data domain.ShippingMethod
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", methodID).First(&data).Error
+	return data, err
+}
+
+func (r *ShippingRepository) UpdateMethod(ctx context.Context, methodID int, data map[string]interface{}) (int64, error) {
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("shipping_method").Where("id = ? AND deleted_at IS NULL", methodID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
+
+func (r *ShippingRepository) GetActiveMethodsByZoneID(ctx context.Context, tx *gorm.DB, zoneID int) ([]domain.ShippingMethod, error) {
+	var data []domain.ShippingMethod
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("zone_id = ? AND is_active AND deleted_at IS NULL", zoneID).Order("id").Find(&data).Error
+	return data, err
+}
+
+func (r *ShippingRepository) FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	paginate := database.NewPaginator(r.db, page, pageSize, model).Raw(query, args, countQuery, args)
+
+	if err := paginate.FindWithOrderBy(ctx, orderBy).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
+
+func (r *ShippingRepository) GetProductWeights(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.ShippingLine, error) {
+	var data []domain.ShippingLine
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT id AS product_id, weight FROM product WHERE id IN ?`, productIDs).Scan(&data).Error
+	return data, err
+}
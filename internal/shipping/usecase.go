@@ -0,0 +1,21 @@
+package shipping
+
+import (
+	"context"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+	"gorm.io/gorm"
+)
+
+type UseCase interface {
+	CreateZone(beegoCtx *beegoContext.Context, req domain.CreateShippingZoneRequest) (*domain.ShippingZone, error)
+	GetZones(beegoCtx *beegoContext.Context, req domain.GetShippingListRequest) (*database.Paginator, error)
+	UpdateZone(beegoCtx *beegoContext.Context, req domain.UpdateShippingZoneRequest) (*domain.ShippingZone, error)
+	CreateMethod(beegoCtx *beegoContext.Context, req domain.CreateShippingMethodRequest) (*domain.ShippingMethod, error)
+	GetMethods(beegoCtx *beegoContext.Context, req domain.GetShippingListRequest) (*database.Paginator, error)
+	UpdateMethod(beegoCtx *beegoContext.Context, req domain.UpdateShippingMethodRequest) (*domain.ShippingMethod, error)
+	Quote(ctx context.Context, tx *gorm.DB, address domain.ShippingAddress, lines []domain.ShippingLine, subtotal money.Money) ([]domain.ShippingQuote, error)
+	QuoteMethod(ctx context.Context, tx *gorm.DB, methodID int, address domain.ShippingAddress, lines []domain.ShippingLine, subtotal money.Money) (*domain.ShippingQuote, error)
+}
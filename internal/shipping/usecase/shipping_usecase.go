@@ -0,0 +1,449 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/shipping"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type ShippingUseCase struct {
+	shippingRepo shipping.Repository
+	carriers     map[string]shipping.Carrier
+	zapLogger    zaplogger.Logger
+}
+
+// NewShippingUseCase returns the shipping use case, the methods of the carrier
+// rate type are quoted by the carrier of the same code.
+func NewShippingUseCase(shippingRepo shipping.Repository, carriers []shipping.Carrier, zapLogger zaplogger.Logger) shipping.UseCase {
+	carrierByCode := make(map[string]shipping.Carrier, len(carriers))
+	for _, v := range carriers {
+		carrierByCode[v.Code()] = v
+	}
+	return &ShippingUseCase{
+		shippingRepo: shippingRepo,
+		carriers:     carrierByCode,
+		zapLogger:    zapLogger,
+	}
+}
+
+func (u *ShippingUseCase) CreateZone(beegoCtx *beegoContext.Context, req domain.CreateShippingZoneRequest) (*domain.ShippingZone, error) {
+	var data *domain.ShippingZone
+
+	//start transaction
+	errs := u.shippingRepo.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		data, err = u.shippingRepo.InsertZone(beegoCtx.Request.Context(), tx, domain.ShippingZone{
+			Name:      req.Name,
+			IsActive:  true,
+			CreatedAt: time.Now(),
+			CreatedBy: "System",
+		})
+		if err != nil {
+			return err
+		}
+
+		data.Regions = normalizeRegions(req.Regions)
+		return u.shippingRepo.ReplaceZoneRegions(beegoCtx.Request.Context(), tx, data.ID, data.Regions)
+	})
+
+	if errs != nil {
+		if pgErr, ok := errs.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeUniqueConstraint {
+			return nil, domain.ErrUniqueConstraint
+		}
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		return nil, errs
+	}
+
+	return data, nil
+}
+
+func (u *ShippingUseCase) GetZones(beegoCtx *beegoContext.Context, req domain.GetShippingListRequest) (*database.Paginator, error) {
+	var entities []domain.ShippingZone
+
+	query := `SELECT * FROM shipping_zone WHERE deleted_at IS NULL`
+	countQuery := `SELECT COUNT(*) FROM shipping_zone WHERE deleted_at IS NULL`
+
+	data, err := u.shippingRepo.FetchWithFilterAndPagination(
+		beegoCtx.Request.Context(),
+		req.Page,
+		req.Limit,
+		query,
+		countQuery,
+		"ORDER BY id",
+		&entities,
+	)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	zoneIDs := make([]int, 0, len(entities))
+	for _, v := range entities {
+		zoneIDs = append(zoneIDs, v.ID)
+	}
+	regions, err := u.shippingRepo.GetZoneRegions(beegoCtx.Request.Context(), zoneIDs)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	regionsByZone := make(map[int][]string)
+	for _, v := range regions {
+		regionsByZone[v.ZoneID] = append(regionsByZone[v.ZoneID], v.Region)
+	}
+	for i := range entities {
+		entities[i].Regions = regionsByZone[entities[i].ID]
+	}
+
+	return data, nil
+}
+
+// UpdateZone changes the given fields only, the regions are replaced when given.
+func (u *ShippingUseCase) UpdateZone(beegoCtx *beegoContext.Context, req domain.UpdateShippingZoneRequest) (*domain.ShippingZone, error) {
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+		"updated_by": "System",
+	}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	//start transaction
+	errs := u.shippingRepo.DB().Transaction(func(tx *gorm.DB) error {
+		affected, err := u.shippingRepo.UpdateZone(beegoCtx.Request.Context(), tx, req.ID, updates)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if len(req.Regions) == 0 {
+			return nil
+		}
+		return u.shippingRepo.ReplaceZoneRegions(beegoCtx.Request.Context(), tx, req.ID, normalizeRegions(req.Regions))
+	})
+
+	if errs != nil {
+		if pgErr, ok := errs.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeUniqueConstraint {
+			return nil, domain.ErrUniqueConstraint
+		}
+		if !errors.Is(errs, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(errs))
+		}
+		return nil, errs
+	}
+
+	data, err := u.shippingRepo.GetZoneByID(beegoCtx.Request.Context(), req.ID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	regions, err := u.shippingRepo.GetZoneRegions(beegoCtx.Request.Context(), []int{data.ID})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	for _, v := range regions {
+		data.Regions = append(data.Regions, v.Region)
+	}
+
+	return &data, nil
+}
+
+func (u *ShippingUseCase) CreateMethod(beegoCtx *beegoContext.Context, req domain.CreateShippingMethodRequest) (*domain.ShippingMethod, error) {
+	if req.RateType == domain.ShippingRateCarrier {
+		if _, ok := u.carriers[*req.Carrier]; !ok {
+			return nil, shipping.ErrUnknownCarrier
+		}
+	} else {
+		req.Carrier, req.CarrierService = nil, nil
+	}
+
+	data, err := u.shippingRepo.InsertMethod(beegoCtx.Request.Context(), domain.ShippingMethod{
+		ZoneID:         req.ZoneID,
+		Name:           req.Name,
+		RateType:       req.RateType,
+		Rate:           req.Rate,
+		PerKgRate:      req.PerKgRate,
+		Carrier:        req.Carrier,
+		CarrierService: req.CarrierService,
+		FreeOver:       req.FreeOver,
+		EstimatedDays:  req.EstimatedDays,
+		IsActive:       true,
+		CreatedAt:      time.Now(),
+		CreatedBy:      "System",
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeForeignKeyConstraint {
+			return nil, domain.ErrForeignKeyConstraint
+		}
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (u *ShippingUseCase) GetMethods(beegoCtx *beegoContext.Context, req domain.GetShippingListRequest) (*database.Paginator, error) {
+	var entities []domain.ShippingMethod
+
+	query := `SELECT * FROM shipping_method WHERE deleted_at IS NULL`
+	countQuery := `SELECT COUNT(*) FROM shipping_method WHERE deleted_at IS NULL`
+
+	data, err := u.shippingRepo.FetchWithFilterAndPagination(
+		beegoCtx.Request.Context(),
+		req.Page,
+		req.Limit,
+		query,
+		countQuery,
+		"ORDER BY zone_id, id",
+		&entities,
+	)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// UpdateMethod changes the given fields only. Orders keep the cost they were
+// quoted.
+func (u *ShippingUseCase) UpdateMethod(beegoCtx *beegoContext.Context, req domain.UpdateShippingMethodRequest) (*domain.ShippingMethod, error) {
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+		"updated_by": "System",
+	}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Rate != nil {
+		updates["rate"] = *req.Rate
+	}
+	if req.PerKgRate != nil {
+		updates["per_kg_rate"] = *req.PerKgRate
+	}
+	if req.FreeOver != nil {
+		updates["free_over"] = *req.FreeOver
+	}
+	if req.EstimatedDays != nil {
+		updates["estimated_days"] = *req.EstimatedDays
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	affected, err := u.shippingRepo.UpdateMethod(beegoCtx.Request.Context(), req.ID, updates)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	data, err := u.shippingRepo.GetMethodByID(beegoCtx.Request.Context(), req.ID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// Quote prices the active methods of the zone of the address for the lines, worth
+// subtotal. A carrier failing to quote leaves its methods out, an address outside
+// every zone has no method.
+func (u *ShippingUseCase) Quote(ctx context.Context, tx *gorm.DB, address domain.ShippingAddress, lines []domain.ShippingLine, subtotal money.Money) ([]domain.ShippingQuote, error) {
+	methods, err := u.zoneMethods(ctx, tx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make([]domain.ShippingQuote, 0, len(methods))
+	if len(methods) == 0 {
+		return quotes, nil
+	}
+
+	weight, err := u.weight(ctx, tx, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range methods {
+		quote, err := u.quote(ctx, v, address, weight, subtotal)
+		if err != nil {
+			if v.RateType == domain.ShippingRateCarrier && ctx.Err() == nil {
+				u.zapLogger.Warn(err)
+				continue
+			}
+			return nil, err
+		}
+		quotes = append(quotes, *quote)
+	}
+
+	return quotes, nil
+}
+
+// QuoteMethod prices the method chosen at checkout, domain.ErrShippingUnavailable
+// is returned when it doesn't ship to the address.
+func (u *ShippingUseCase) QuoteMethod(ctx context.Context, tx *gorm.DB, methodID int, address domain.ShippingAddress, lines []domain.ShippingLine, subtotal money.Money) (*domain.ShippingQuote, error) {
+	methods, err := u.zoneMethods(ctx, tx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range methods {
+		if v.ID != methodID {
+			continue
+		}
+
+		weight, err := u.weight(ctx, tx, lines)
+		if err != nil {
+			return nil, err
+		}
+		quote, err := u.quote(ctx, v, address, weight, subtotal)
+		if err != nil {
+			if v.RateType == domain.ShippingRateCarrier && ctx.Err() == nil {
+				u.zapLogger.Warn(err)
+				return nil, domain.ErrShippingUnavailable
+			}
+			return nil, err
+		}
+		return quote, nil
+	}
+
+	return nil, domain.ErrShippingUnavailable
+}
+
+// zoneMethods returns the active methods of the zone of the address.
+func (u *ShippingUseCase) zoneMethods(ctx context.Context, tx *gorm.DB, address domain.ShippingAddress) ([]domain.ShippingMethod, error) {
+	zone, err := u.shippingRepo.GetActiveZoneByRegion(ctx, tx, normalizeRegion(address.Region))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return u.shippingRepo.GetActiveMethodsByZoneID(ctx, tx, zone.ID)
+}
+
+// weight sums the weight of the lines in grams.
+func (u *ShippingUseCase) weight(ctx context.Context, tx *gorm.DB, lines []domain.ShippingLine) (int, error) {
+	productIDs := make([]int, 0, len(lines))
+	for _, v := range lines {
+		productIDs = append(productIDs, v.ProductID)
+	}
+	weights, err := u.shippingRepo.GetProductWeights(ctx, tx, productIDs)
+	if err != nil {
+		return 0, err
+	}
+	weightByProduct := make(map[int]int, len(weights))
+	for _, v := range weights {
+		weightByProduct[v.ProductID] = v.Weight
+	}
+
+	total := 0
+	for _, v := range lines {
+		total += weightByProduct[v.ProductID] * v.Quantity
+	}
+	return total, nil
+}
+
+func (u *ShippingUseCase) quote(ctx context.Context, method domain.ShippingMethod, address domain.ShippingAddress, weight int, subtotal money.Money) (*domain.ShippingQuote, error) {
+	var (
+		cost money.Money
+		err  error
+	)
+	if method.RateType == domain.ShippingRateCarrier {
+		cost, err = u.carrierCost(ctx, method, address, weight, subtotal)
+	} else {
+		cost, err = method.Cost(weight, subtotal)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ShippingQuote{
+		MethodID:      method.ID,
+		Name:          method.Name,
+		RateType:      method.RateType,
+		Carrier:       method.Carrier,
+		Cost:          cost,
+		EstimatedDays: method.EstimatedDays,
+	}, nil
+}
+
+// carrierCost asks the carrier of the method to rate the parcel, the free shipping
+// threshold of the method still applies.
+func (u *ShippingUseCase) carrierCost(ctx context.Context, method domain.ShippingMethod, address domain.ShippingAddress, weight int, subtotal money.Money) (money.Money, error) {
+	if method.Carrier == nil {
+		return money.Money{}, shipping.ErrUnknownCarrier
+	}
+	carrier, ok := u.carriers[*method.Carrier]
+	if !ok {
+		return money.Money{}, shipping.ErrUnknownCarrier
+	}
+
+	free, err := method.IsFree(subtotal)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if free {
+		return money.Zero(money.DefaultCurrency()), nil
+	}
+
+	parcel := domain.ShippingParcel{
+		Address: address,
+		Weight:  weight,
+		Value:   subtotal,
+	}
+	if method.CarrierService != nil {
+		parcel.Service = *method.CarrierService
+	}
+
+	cost, err := carrier.Rate(ctx, parcel)
+	if err != nil {
+		return money.Money{}, err
+	}
+	//carriers may answer in another currency, the order is kept in the base one
+	if cost.Currency != money.DefaultCurrency() {
+		return money.Money{}, money.ErrCurrencyMismatch
+	}
+	return cost, nil
+}
+
+// normalizeRegion compares regions case insensitively.
+func normalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+func normalizeRegions(regions []string) []string {
+	seen := make(map[string]bool, len(regions))
+	data := make([]string, 0, len(regions))
+	for _, v := range regions {
+		code := normalizeRegion(v)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		data = append(data, code)
+	}
+	return data
+}
@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/shipping"
+	"github.com/online-store/internal/shipping/carrier"
+	"github.com/online-store/pkg/money"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+// fakeShippingRepository serves one zone and its methods, the other queries are
+// not used by the quotes.
+type fakeShippingRepository struct {
+	shipping.Repository
+	region  string
+	methods []domain.ShippingMethod
+	weights []domain.ShippingLine
+}
+
+func (r fakeShippingRepository) GetActiveZoneByRegion(_ context.Context, _ *gorm.DB, region string) (domain.ShippingZone, error) {
+	if region != r.region {
+		return domain.ShippingZone{}, gorm.ErrRecordNotFound
+	}
+	return domain.ShippingZone{ID: 1}, nil
+}
+
+func (r fakeShippingRepository) GetActiveMethodsByZoneID(context.Context, *gorm.DB, int) ([]domain.ShippingMethod, error) {
+	return r.methods, nil
+}
+
+func (r fakeShippingRepository) GetProductWeights(context.Context, *gorm.DB, []int) ([]domain.ShippingLine, error) {
+	return r.weights, nil
+}
+
+// failingCarrier never rates a parcel.
+type failingCarrier struct{}
+
+func (failingCarrier) Code() string {
+	return "down"
+}
+
+func (failingCarrier) Rate(context.Context, domain.ShippingParcel) (money.Money, error) {
+	return money.Money{}, errors.New("carrier is down")
+}
+
+// quietLogger drops the logs.
+type quietLogger struct {
+	zaplogger.Logger
+}
+
+func (quietLogger) Warn(...interface{}) {}
+
+func amount(value int64) money.Money {
+	return money.New(value, money.DefaultCurrency())
+}
+
+func stringPtr(v string) *string {
+	return &v
+}
+
+func TestQuote(t *testing.T) {
+	freeOver := amount(100000)
+	flat := domain.ShippingMethod{ID: 1, Name: "Flat", RateType: domain.ShippingRateFlat, Rate: amount(10000)}
+	weight := domain.ShippingMethod{ID: 2, Name: "Weight", RateType: domain.ShippingRateWeight, Rate: amount(5000), PerKgRate: amount(2000)}
+	free := domain.ShippingMethod{ID: 3, Name: "Free over", RateType: domain.ShippingRateFlat, Rate: amount(10000), FreeOver: &freeOver}
+	fake := domain.ShippingMethod{ID: 4, Name: "Fake", RateType: domain.ShippingRateCarrier, Carrier: stringPtr("fake")}
+	freeFake := domain.ShippingMethod{ID: 5, Name: "Fake free over", RateType: domain.ShippingRateCarrier, Carrier: stringPtr("fake"), FreeOver: &freeOver}
+	down := domain.ShippingMethod{ID: 6, Name: "Down", RateType: domain.ShippingRateCarrier, Carrier: stringPtr("down")}
+	unknown := domain.ShippingMethod{ID: 7, Name: "Unknown", RateType: domain.ShippingRateCarrier, Carrier: stringPtr("unknown")}
+
+	// 2 x 700 g and 1 x 1200 g, 2.6 kg
+	lines := []domain.ShippingLine{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}}
+	weights := []domain.ShippingLine{{ProductID: 1, Weight: 700}, {ProductID: 2, Weight: 1200}}
+
+	tests := []struct {
+		name     string
+		methods  []domain.ShippingMethod
+		region   string
+		subtotal money.Money
+		// costs of the quoted methods by method ID
+		costs map[int]int64
+	}{
+		{
+			name:     "flat costs its rate",
+			methods:  []domain.ShippingMethod{flat},
+			region:   "JAKARTA",
+			subtotal: amount(50000),
+			costs:    map[int]int64{1: 10000},
+		},
+		{
+			name:     "weight adds the rate of every started kilogram",
+			methods:  []domain.ShippingMethod{weight},
+			region:   "JAKARTA",
+			subtotal: amount(50000),
+			costs:    map[int]int64{2: 11000},
+		},
+		{
+			name:     "below the free shipping threshold",
+			methods:  []domain.ShippingMethod{free},
+			region:   "JAKARTA",
+			subtotal: amount(99999),
+			costs:    map[int]int64{3: 10000},
+		},
+		{
+			name:     "free from the free shipping threshold",
+			methods:  []domain.ShippingMethod{free},
+			region:   "JAKARTA",
+			subtotal: amount(100000),
+			costs:    map[int]int64{3: 0},
+		},
+		{
+			name:     "carrier rates the parcel",
+			methods:  []domain.ShippingMethod{fake},
+			region:   "JAKARTA",
+			subtotal: amount(50000),
+			costs:    map[int]int64{4: 7500},
+		},
+		{
+			name:     "carrier method is free from the free shipping threshold",
+			methods:  []domain.ShippingMethod{freeFake},
+			region:   "JAKARTA",
+			subtotal: amount(150000),
+			costs:    map[int]int64{5: 0},
+		},
+		{
+			name:     "failing carriers are left out",
+			methods:  []domain.ShippingMethod{flat, down, unknown, fake},
+			region:   "JAKARTA",
+			subtotal: amount(50000),
+			costs:    map[int]int64{1: 10000, 4: 7500},
+		},
+		{
+			name:     "region is compared case insensitively",
+			methods:  []domain.ShippingMethod{flat},
+			region:   " jakarta ",
+			subtotal: amount(50000),
+			costs:    map[int]int64{1: 10000},
+		},
+		{
+			name:     "address outside every zone",
+			methods:  []domain.ShippingMethod{flat},
+			region:   "BALI",
+			subtotal: amount(50000),
+			costs:    map[int]int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := NewShippingUseCase(
+				fakeShippingRepository{region: "JAKARTA", methods: tt.methods, weights: weights},
+				[]shipping.Carrier{carrier.NewFakeCarrier("fake", amount(1500), amount(2000)), failingCarrier{}},
+				quietLogger{},
+			)
+
+			quotes, err := u.Quote(context.Background(), nil, domain.ShippingAddress{Region: tt.region}, lines, tt.subtotal)
+			if err != nil {
+				t.Fatalf("Quote() error = %v", err)
+			}
+
+			costs := make(map[int]int64, len(quotes))
+			for _, v := range quotes {
+				costs[v.MethodID] = v.Cost.Amount
+			}
+			if !reflect.DeepEqual(costs, tt.costs) {
+				t.Errorf("Quote() costs = %v, want %v", costs, tt.costs)
+			}
+		})
+	}
+}
+
+func TestQuoteMethod(t *testing.T) {
+	flat := domain.ShippingMethod{ID: 1, Name: "Flat", RateType: domain.ShippingRateFlat, Rate: amount(10000)}
+	fake := domain.ShippingMethod{ID: 4, Name: "Fake", RateType: domain.ShippingRateCarrier, Carrier: stringPtr("fake")}
+	down := domain.ShippingMethod{ID: 6, Name: "Down", RateType: domain.ShippingRateCarrier, Carrier: stringPtr("down")}
+
+	tests := []struct {
+		name     string
+		methodID int
+		region   string
+		cost     int64
+		err      error
+	}{
+		{name: "flat", methodID: 1, region: "JAKARTA", cost: 10000},
+		{name: "carrier", methodID: 4, region: "JAKARTA", cost: 3500},
+		{name: "failing carrier is unavailable", methodID: 6, region: "JAKARTA", err: domain.ErrShippingUnavailable},
+		{name: "method of another zone", methodID: 9, region: "JAKARTA", err: domain.ErrShippingUnavailable},
+		{name: "address outside every zone", methodID: 1, region: "BALI", err: domain.ErrShippingUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := NewShippingUseCase(
+				fakeShippingRepository{region: "JAKARTA", methods: []domain.ShippingMethod{flat, fake, down}, weights: []domain.ShippingLine{{ProductID: 1, Weight: 800}}},
+				[]shipping.Carrier{carrier.NewFakeCarrier("fake", amount(1500), amount(2000)), failingCarrier{}},
+				quietLogger{},
+			)
+
+			quote, err := u.QuoteMethod(context.Background(), nil, tt.methodID, domain.ShippingAddress{Region: tt.region}, []domain.ShippingLine{{ProductID: 1, Quantity: 1}}, amount(50000))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("QuoteMethod() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if quote.MethodID != tt.methodID || quote.Cost.Amount != tt.cost {
+				t.Errorf("QuoteMethod() = method %d costing %d, want method %d costing %d", quote.MethodID, quote.Cost.Amount, tt.methodID, tt.cost)
+			}
+		})
+	}
+}
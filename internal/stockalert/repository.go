@@ -0,0 +1,19 @@
+package stockalert
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	UpdateLowStockThreshold(ctx context.Context, productID int, threshold *int) (int64, error)
+	GetLowStockProducts(ctx context.Context, defaultThreshold int) ([]domain.LowStockProduct, error)
+	MarkLowStock(ctx context.Context, productIDs []int, defaultThreshold int) ([]domain.LowStockProduct, error)
+	ClearLowStock(ctx context.Context, productIDs []int, defaultThreshold int) error
+	GetAvailableStock(ctx context.Context, productID int, variantID *int) (int, error)
+	InsertSubscription(ctx context.Context, data domain.StockSubscription) (*domain.StockSubscription, error)
+	ClaimBackInStockNotices(ctx context.Context, productIDs []int) ([]domain.BackInStockNotice, error)
+	ReleaseBackInStockNotice(ctx context.Context, subscriptionID int) error
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/stockalert"
+	"gorm.io/gorm"
+)
+
+// availableStockQuery sums the stock of the products that can still be ordered
+// across the active warehouses, products no warehouse stocks have none.
+const availableStockQuery = `SELECT p.id AS product_id, COALESCE(SUM(ws.stock - ws.reserved_stock), 0) AS available 
+				FROM product p 
+				LEFT JOIN warehouse_stock ws ON ws.product_id = p.id AND ws.warehouse_id IN (SELECT id FROM warehouse WHERE is_active AND deleted_at IS NULL) 
+				WHERE p.deleted_at IS NULL`
+
+type StockAlertRepository struct {
+	db *gorm.DB
+}
+
+func NewStockAlertRepository(db *gorm.DB) stockalert.Repository {
+	return &StockAlertRepository{db}
+}
+
+func (r *StockAlertRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *StockAlertRepository) UpdateLowStockThreshold(ctx context.Context, productID int, threshold *int) (int64, error) {
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("product").Where("id = ? AND deleted_at IS NULL", productID).
+		Updates(map[string]interface{}{"low_stock_threshold": threshold, "updated_at": gorm.Expr("now()"), "updated_by": "System"})
+	return result.RowsAffected, result.Error
+}
+
+func (r *StockAlertRepository) GetLowStockProducts(ctx context.Context, defaultThreshold int) ([]domain.LowStockProduct, error) {
+	var data []domain.LowStockProduct
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT 
+					p.id AS product_id, p.sku, p."name", a.available, COALESCE(p.low_stock_threshold, ?) AS threshold 
+				FROM product p 
+				JOIN (`+availableStockQuery+` GROUP BY p.id) a ON a.product_id = p.id 
+				WHERE a.available <= COALESCE(p.low_stock_threshold, ?) 
+				ORDER BY a.available, p.id`, defaultThreshold, defaultThreshold).Scan(&data).Error
+	return data, err
+}
+
+// MarkLowStock flags the products that fell to their threshold and returns them.
+// Products already flagged are skipped, so each drop is alerted once even when
+// several checks run at the same time.
+func (r *StockAlertRepository) MarkLowStock(ctx context.Context, productIDs []int, defaultThreshold int) ([]domain.LowStockProduct, error) {
+	var data []domain.LowStockProduct
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`UPDATE product p 
+				SET low_stock_alerted_at = now() 
+				FROM (`+availableStockQuery+` AND p.id IN ? GROUP BY p.id) a 
+				WHERE p.id = a.product_id AND p.low_stock_alerted_at IS NULL AND a.available <= COALESCE(p.low_stock_threshold, ?) 
+				RETURNING p.id AS product_id, p.sku, p."name", a.available, COALESCE(p.low_stock_threshold, ?) AS threshold`,
+		productIDs, defaultThreshold, defaultThreshold).Scan(&data).Error
+	return data, err
+}
+
+// ClearLowStock lifts the flag of the products restocked above their threshold, the
+// next drop is alerted again.
+func (r *StockAlertRepository) ClearLowStock(ctx context.Context, productIDs []int, defaultThreshold int) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Exec(`UPDATE product p 
+				SET low_stock_alerted_at = NULL 
+				FROM (`+availableStockQuery+` AND p.id IN ? GROUP BY p.id) a 
+				WHERE p.id = a.product_id AND p.low_stock_alerted_at IS NOT NULL AND a.available > COALESCE(p.low_stock_threshold, ?)`,
+		productIDs, defaultThreshold).Error
+}
+
+func (r *StockAlertRepository) GetAvailableStock(ctx context.Context, productID int, variantID *int) (int, error) {
+	var data struct {
+		Available int `gorm:"column:available"`
+	}
+
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT 
+					COALESCE(SUM(ws.stock - ws.reserved_stock) FILTER (WHERE w.is_active AND w.deleted_at IS NULL AND (?::int8 IS NULL OR ws.variant_id = ?)), 0) AS available 
+				FROM product p 
+				LEFT JOIN warehouse_stock ws ON ws.product_id = p.id 
+				LEFT JOIN warehouse w ON w.id = ws.warehouse_id 
+				WHERE p.id = ? AND p.deleted_at IS NULL 
+				GROUP BY p.id`, variantID, variantID, productID).Scan(&data)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return data.Available, result.Error
+}
+
+func (r *StockAlertRepository) InsertSubscription(ctx context.Context, data domain.StockSubscription) (*domain.StockSubscription, error) {
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("NotifiedAt").Create(&data).Error
+
+	return &data, err
+}
+
+// ClaimBackInStockNotices marks the pending subscriptions to the products that can
+// be ordered again as notified and returns them, each one is claimed once. A notice
+// that couldn't be sent is given back with ReleaseBackInStockNotice.
+func (r *StockAlertRepository) ClaimBackInStockNotices(ctx context.Context, productIDs []int) ([]domain.BackInStockNotice, error) {
+	var data []domain.BackInStockNotice
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`UPDATE stock_subscription s 
+				SET notified_at = now() 
+				FROM customer c, product p 
+				WHERE c.customer_id = s.customer_id AND p.id = s.product_id AND p.deleted_at IS NULL 
+					AND s.notified_at IS NULL AND s.product_id IN ? 
+					AND (SELECT COALESCE(SUM(ws.stock - ws.reserved_stock), 0) 
+						FROM warehouse_stock ws 
+						JOIN warehouse w ON w.id = ws.warehouse_id 
+						WHERE w.is_active AND w.deleted_at IS NULL AND ws.product_id = s.product_id AND (s.variant_id IS NULL OR ws.variant_id = s.variant_id)) > 0 
+				RETURNING s.id AS subscription_id, c.email, s.lang, p."name" AS product_name, 
+					COALESCE((SELECT v.sku FROM product_variant v WHERE v.id = s.variant_id), '') AS variant_sku`, productIDs).Scan(&data).Error
+	return data, err
+}
+
+// ReleaseBackInStockNotice makes a claimed subscription pending again, so the next
+// stock check notifies the customer.
+func (r *StockAlertRepository) ReleaseBackInStockNotice(ctx context.Context, subscriptionID int) error {
+	return r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("stock_subscription").Where("id = ?", subscriptionID).
+		Updates(map[string]interface{}{"notified_at": nil}).Error
+}
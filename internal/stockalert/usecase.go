@@ -0,0 +1,15 @@
+package stockalert
+
+import (
+	"context"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+)
+
+type UseCase interface {
+	SetLowStockThreshold(beegoCtx *beegoContext.Context, req domain.SetLowStockThresholdRequest) error
+	GetLowStockProducts(beegoCtx *beegoContext.Context) ([]domain.LowStockProduct, error)
+	Subscribe(beegoCtx *beegoContext.Context, req domain.CreateStockSubscriptionRequest) (*domain.StockSubscription, error)
+	CheckStock(ctx context.Context, productIDs []int)
+	CheckStockInBackground(productIDs []int)
+}
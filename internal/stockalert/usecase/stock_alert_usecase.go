@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/beego/i18n"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/stockalert"
+	"github.com/online-store/pkg/notifier"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type StockAlertUseCase struct {
+	stockAlertRepo   stockalert.Repository
+	alertNotifier    notifier.Notifier
+	customerNotifier notifier.Notifier
+	defaultThreshold int
+	alertLang        string
+	zapLogger        zaplogger.Logger
+}
+
+// NewStockAlertUseCase sends the low-stock alerts to the staff through alertNotifier,
+// in alertLang, and the back-in-stock notifications to the customers through
+// customerNotifier.
+func NewStockAlertUseCase(stockAlertRepo stockalert.Repository, alertNotifier, customerNotifier notifier.Notifier, defaultThreshold int, alertLang string, zapLogger zaplogger.Logger) stockalert.UseCase {
+	return &StockAlertUseCase{
+		stockAlertRepo:   stockAlertRepo,
+		alertNotifier:    alertNotifier,
+		customerNotifier: customerNotifier,
+		defaultThreshold: defaultThreshold,
+		alertLang:        alertLang,
+		zapLogger:        zapLogger,
+	}
+}
+
+// SetLowStockThreshold sets the threshold of the product, a nil threshold brings
+// back the default one.
+func (u *StockAlertUseCase) SetLowStockThreshold(beegoCtx *beegoContext.Context, req domain.SetLowStockThresholdRequest) error {
+	affected, err := u.stockAlertRepo.UpdateLowStockThreshold(beegoCtx.Request.Context(), req.ProductID, req.Threshold)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+	if affected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	// the product may already be below its new threshold
+	u.CheckStockInBackground([]int{req.ProductID})
+
+	return nil
+}
+
+func (u *StockAlertUseCase) GetLowStockProducts(beegoCtx *beegoContext.Context) ([]domain.LowStockProduct, error) {
+	data, err := u.stockAlertRepo.GetLowStockProducts(beegoCtx.Request.Context(), u.defaultThreshold)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Subscribe asks to notify the customer when the product is back in stock, which
+// only makes sense while it can't be ordered.
+func (u *StockAlertUseCase) Subscribe(beegoCtx *beegoContext.Context, req domain.CreateStockSubscriptionRequest) (*domain.StockSubscription, error) {
+	available, err := u.stockAlertRepo.GetAvailableStock(beegoCtx.Request.Context(), req.ProductID, req.VariantID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return nil, err
+	}
+	if available > 0 {
+		return nil, domain.ErrProductInStock
+	}
+
+	data, err := u.stockAlertRepo.InsertSubscription(beegoCtx.Request.Context(), domain.StockSubscription{
+		CustomerID: req.CustomerID,
+		ProductID:  req.ProductID,
+		VariantID:  req.VariantID,
+		Lang:       req.Lang,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		pgerr, ok := err.(*pgconn.PgError)
+		if !ok {
+			return nil, err
+		}
+		switch pgerr.Code {
+		case domain.PgCodeForeignKeyConstraint:
+			return nil, domain.ErrForeignKeyConstraint
+		case domain.PgCodeUniqueConstraint:
+			return nil, domain.ErrUniqueConstraint
+		default:
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// CheckStockInBackground checks the stock of the products in a goroutine of its own.
+// A panicking notifier is logged instead of taking the process down.
+func (u *StockAlertUseCase) CheckStockInBackground(productIDs []int) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				u.zapLogger.Error(fmt.Errorf("stock check panicked: %v", r))
+			}
+		}()
+		u.CheckStock(context.Background(), productIDs)
+	}()
+}
+
+// CheckStock alerts the products whose stock just fell to their threshold and
+// notifies the customers waiting for the products that can be ordered again, a
+// customer whose notification failed stays subscribed. It is called after the
+// stock of the products changed, usually in the background, so errors are only
+// logged.
+func (u *StockAlertUseCase) CheckStock(ctx context.Context, productIDs []int) {
+	alerts, err := u.stockAlertRepo.MarkLowStock(ctx, productIDs, u.defaultThreshold)
+	if err != nil {
+		u.zapLogger.Error(err)
+		return
+	}
+
+	for _, v := range alerts {
+		sku := ""
+		if v.SKU != nil {
+			sku = *v.SKU
+		}
+
+		err := u.alertNotifier.Notify(ctx, notifier.Message{
+			Subject: i18n.Tr(u.alertLang, "message.lowStockAlertSubject", v.Name),
+			Body:    i18n.Tr(u.alertLang, "message.lowStockAlertBody", v.Name, sku, v.Available, v.Threshold),
+		})
+		if err != nil {
+			u.zapLogger.Error(err)
+		}
+	}
+
+	if err := u.stockAlertRepo.ClearLowStock(ctx, productIDs, u.defaultThreshold); err != nil {
+		u.zapLogger.Error(err)
+	}
+
+	notices, err := u.stockAlertRepo.ClaimBackInStockNotices(ctx, productIDs)
+	if err != nil {
+		u.zapLogger.Error(err)
+		return
+	}
+
+	for _, v := range notices {
+		name := v.ProductName
+		if v.VariantSKU != "" {
+			name = fmt.Sprintf("%s (%s)", v.ProductName, v.VariantSKU)
+		}
+
+		err := u.customerNotifier.Notify(ctx, notifier.Message{
+			Subject: i18n.Tr(v.Lang, "message.backInStockSubject", name),
+			Body:    i18n.Tr(v.Lang, "message.backInStockBody", name),
+			To:      []string{v.Email},
+		})
+		if err != nil {
+			u.zapLogger.Error(err)
+			// the customer is still waiting, the next check tries again
+			if err := u.stockAlertRepo.ReleaseBackInStockNotice(ctx, v.SubscriptionID); err != nil {
+				u.zapLogger.Error(err)
+			}
+		}
+	}
+}
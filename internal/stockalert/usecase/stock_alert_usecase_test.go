@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/stockalert"
+	"github.com/online-store/pkg/notifier"
+	"github.com/online-store/pkg/zaplogger"
+)
+
+// fakeStockAlertRepository has no product below its threshold and claims the
+// notices it holds, it records the subscriptions released.
+type fakeStockAlertRepository struct {
+	stockalert.Repository
+	notices  []domain.BackInStockNotice
+	released []int
+}
+
+func (r *fakeStockAlertRepository) MarkLowStock(context.Context, []int, int) ([]domain.LowStockProduct, error) {
+	return nil, nil
+}
+
+func (r *fakeStockAlertRepository) ClearLowStock(context.Context, []int, int) error {
+	return nil
+}
+
+func (r *fakeStockAlertRepository) ClaimBackInStockNotices(context.Context, []int) ([]domain.BackInStockNotice, error) {
+	return r.notices, nil
+}
+
+func (r *fakeStockAlertRepository) ReleaseBackInStockNotice(_ context.Context, subscriptionID int) error {
+	r.released = append(r.released, subscriptionID)
+	return nil
+}
+
+// bouncingNotifier fails to deliver to the bounced addresses.
+type bouncingNotifier struct {
+	bounced map[string]bool
+}
+
+func (n bouncingNotifier) Notify(_ context.Context, message notifier.Message) error {
+	for _, v := range message.To {
+		if n.bounced[v] {
+			return errors.New("mailbox unavailable")
+		}
+	}
+	return nil
+}
+
+// quietLogger drops the logs.
+type quietLogger struct {
+	zaplogger.Logger
+}
+
+func (quietLogger) Error(...interface{}) {}
+
+func TestCheckStockBackInStock(t *testing.T) {
+	notices := []domain.BackInStockNotice{
+		{SubscriptionID: 1, Email: "a@example.com", Lang: "en", ProductName: "Mug"},
+		{SubscriptionID: 2, Email: "b@example.com", Lang: "en", ProductName: "Mug", VariantSKU: "MUG-RED"},
+	}
+
+	tests := []struct {
+		name     string
+		bounced  map[string]bool
+		released []int
+	}{
+		{name: "delivered notices stay claimed"},
+		{name: "failed notice is released", bounced: map[string]bool{"b@example.com": true}, released: []int{2}},
+		{name: "every failed notice is released", bounced: map[string]bool{"a@example.com": true, "b@example.com": true}, released: []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStockAlertRepository{notices: notices}
+			u := NewStockAlertUseCase(repo, bouncingNotifier{}, bouncingNotifier{bounced: tt.bounced}, 5, "en", quietLogger{})
+
+			u.CheckStock(context.Background(), []int{1})
+
+			if !reflect.DeepEqual(repo.released, tt.released) {
+				t.Errorf("released = %v, want %v", repo.released, tt.released)
+			}
+		})
+	}
+}
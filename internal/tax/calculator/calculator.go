@@ -0,0 +1,123 @@
+// Package calculator computes the taxes of order lines. It only computes, loading
+// the tax rates and the lines is up to the caller.
+package calculator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/money"
+)
+
+// rateScale is the scale of a rate as an integer, rates keep 4 decimals of percent.
+const rateScale = 100 * 10000
+
+// Calculate taxes the lines shipped to region. The discount of the order is first
+// split over the lines by their amount, then each line is taxed at its most
+// specific rate and its tax rounded half away from zero to the minor unit. In the
+// inclusive mode the tax is taken out of the discounted amount, in the exclusive
+// mode it is added to it. The same lines and rates always give the same taxes.
+func Calculate(mode string, rates []domain.TaxRate, region string, lines []domain.TaxLine, discount money.Money) (domain.TaxResult, error) {
+	weights := make([]int64, len(lines))
+	var subtotal money.Money
+	for i, v := range lines {
+		weights[i] = v.Amount.Amount
+		var err error
+		if subtotal, err = subtotal.Add(v.Amount); err != nil {
+			return domain.TaxResult{}, err
+		}
+	}
+	currency := subtotal.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency()
+	}
+
+	if discount.Amount > subtotal.Amount {
+		discount.Amount = subtotal.Amount
+	}
+	shares, err := money.New(discount.Amount, currency).Allocate(weights)
+	if err != nil {
+		return domain.TaxResult{}, err
+	}
+
+	result := domain.TaxResult{
+		Mode:  mode,
+		Lines: make([]domain.LineTax, 0, len(lines)),
+		Taxes: []domain.OrderTax{},
+		Total: money.Zero(currency),
+	}
+	byRate := make(map[int]int)
+	for i, v := range lines {
+		base, err := money.New(v.Amount.Amount, currency).Sub(shares[i])
+		if err != nil {
+			return domain.TaxResult{}, err
+		}
+
+		line := domain.LineTax{Discount: shares[i], Taxable: base, Tax: money.Zero(currency)}
+		rate := match(rates, v.CategoryID, region)
+		if rate != nil {
+			scaled := int64(math.Round(rate.Rate * 10000))
+			if mode == domain.TaxInclusive {
+				line.Tax, err = base.MulDiv(scaled, rateScale+scaled)
+			} else {
+				line.Tax, err = base.MulDiv(scaled, rateScale)
+			}
+			if err != nil {
+				return domain.TaxResult{}, err
+			}
+			if mode == domain.TaxInclusive {
+				if line.Taxable, err = base.Sub(line.Tax); err != nil {
+					return domain.TaxResult{}, err
+				}
+			}
+			line.TaxRateID = &rate.ID
+			line.Rate = rate.Rate
+
+			j, ok := byRate[rate.ID]
+			if !ok {
+				j = len(result.Taxes)
+				byRate[rate.ID] = j
+				result.Taxes = append(result.Taxes, domain.OrderTax{
+					TaxRateID: rate.ID,
+					Name:      rate.Name,
+					Rate:      rate.Rate,
+					Taxable:   money.Zero(currency),
+					Tax:       money.Zero(currency),
+				})
+			}
+			if result.Taxes[j].Taxable, err = result.Taxes[j].Taxable.Add(line.Taxable); err != nil {
+				return domain.TaxResult{}, err
+			}
+			if result.Taxes[j].Tax, err = result.Taxes[j].Tax.Add(line.Tax); err != nil {
+				return domain.TaxResult{}, err
+			}
+			if result.Total, err = result.Total.Add(line.Tax); err != nil {
+				return domain.TaxResult{}, err
+			}
+		}
+		result.Lines = append(result.Lines, line)
+	}
+
+	return result, nil
+}
+
+// match returns the most specific rate of a line, the lowest ID on ties, nil when
+// no rate applies.
+func match(rates []domain.TaxRate, categoryID int, region string) *domain.TaxRate {
+	sorted := append([]domain.TaxRate{}, rates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var (
+		best  *domain.TaxRate
+		score = -1
+	)
+	for i, v := range sorted {
+		if s := v.Specificity(categoryID, region); s > score {
+			best, score = &sorted[i], s
+		}
+	}
+	return best
+}
@@ -0,0 +1,202 @@
+package calculator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/money"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func stringPtr(v string) *string {
+	return &v
+}
+
+func usd(amount int64) money.Money {
+	return money.New(amount, "USD")
+}
+
+func taxLine(categoryID int, amount int64) domain.TaxLine {
+	return domain.TaxLine{ProductID: categoryID, CategoryID: categoryID, Amount: usd(amount)}
+}
+
+func TestCalculate(t *testing.T) {
+	standard := domain.TaxRate{ID: 1, Name: "VAT", Rate: 10}
+	full := domain.TaxRate{ID: 2, Name: "Full", Rate: 100}
+	regional := domain.TaxRate{ID: 3, Name: "Regional VAT", Rate: 11, Region: stringPtr("jakarta")}
+	category := domain.TaxRate{ID: 4, Name: "Food", Rate: 5, CategoryID: intPtr(7)}
+
+	tests := []struct {
+		name     string
+		mode     string
+		rates    []domain.TaxRate
+		region   string
+		lines    []domain.TaxLine
+		discount int64
+		// discounts, taxables and taxes of the lines
+		discounts []int64
+		taxables  []int64
+		taxes     []int64
+		total     int64
+		err       error
+	}{
+		{
+			name:      "exclusive adds the tax to the amount",
+			mode:      domain.TaxExclusive,
+			rates:     []domain.TaxRate{standard},
+			lines:     []domain.TaxLine{taxLine(1, 1000)},
+			discounts: []int64{0},
+			taxables:  []int64{1000},
+			taxes:     []int64{100},
+			total:     100,
+		},
+		{
+			name:      "exclusive rounds half away from zero",
+			mode:      domain.TaxExclusive,
+			rates:     []domain.TaxRate{standard},
+			lines:     []domain.TaxLine{taxLine(1, 1005), taxLine(1, 1004)},
+			discounts: []int64{0, 0},
+			taxables:  []int64{1005, 1004},
+			taxes:     []int64{101, 100},
+			total:     201,
+		},
+		{
+			name:      "inclusive takes the tax out of the amount",
+			mode:      domain.TaxInclusive,
+			rates:     []domain.TaxRate{standard},
+			lines:     []domain.TaxLine{taxLine(1, 1100), taxLine(1, 1105)},
+			discounts: []int64{0, 0},
+			taxables:  []int64{1000, 1005},
+			taxes:     []int64{100, 100},
+			total:     200,
+		},
+		{
+			name:      "inclusive rounds half away from zero",
+			mode:      domain.TaxInclusive,
+			rates:     []domain.TaxRate{full},
+			lines:     []domain.TaxLine{taxLine(1, 5)},
+			discounts: []int64{0},
+			taxables:  []int64{2},
+			taxes:     []int64{3},
+			total:     3,
+		},
+		{
+			name:      "discount remainder goes to the first line on ties",
+			mode:      domain.TaxExclusive,
+			rates:     []domain.TaxRate{standard},
+			lines:     []domain.TaxLine{taxLine(1, 100), taxLine(1, 100), taxLine(1, 100)},
+			discount:  100,
+			discounts: []int64{34, 33, 33},
+			taxables:  []int64{66, 67, 67},
+			taxes:     []int64{7, 7, 7},
+			total:     21,
+		},
+		{
+			name:      "discount is split by the line amounts",
+			mode:      domain.TaxInclusive,
+			rates:     []domain.TaxRate{standard},
+			lines:     []domain.TaxLine{taxLine(1, 1100), taxLine(1, 2200)},
+			discount:  100,
+			discounts: []int64{33, 67},
+			taxables:  []int64{970, 1939},
+			taxes:     []int64{97, 194},
+			total:     291,
+		},
+		{
+			name:      "discount above the subtotal leaves nothing to tax",
+			mode:      domain.TaxExclusive,
+			rates:     []domain.TaxRate{standard},
+			lines:     []domain.TaxLine{taxLine(1, 100)},
+			discount:  500,
+			discounts: []int64{100},
+			taxables:  []int64{0},
+			taxes:     []int64{0},
+			total:     0,
+		},
+		{
+			name:      "zero amount lines",
+			mode:      domain.TaxExclusive,
+			rates:     []domain.TaxRate{standard},
+			lines:     []domain.TaxLine{taxLine(1, 0), taxLine(1, 0)},
+			discount:  10,
+			discounts: []int64{0, 0},
+			taxables:  []int64{0, 0},
+			taxes:     []int64{0, 0},
+			total:     0,
+		},
+		{
+			name:     "negative amount line",
+			mode:     domain.TaxExclusive,
+			rates:    []domain.TaxRate{standard},
+			lines:    []domain.TaxLine{taxLine(1, 100), taxLine(1, -200)},
+			discount: 10,
+			err:      money.ErrInvalidAmount,
+		},
+		{
+			name:      "the most specific rate applies",
+			mode:      domain.TaxExclusive,
+			rates:     []domain.TaxRate{standard, regional, category},
+			region:    "jakarta",
+			lines:     []domain.TaxLine{taxLine(1, 1000), taxLine(7, 1000)},
+			discounts: []int64{0, 0},
+			taxables:  []int64{1000, 1000},
+			taxes:     []int64{110, 50},
+			total:     160,
+		},
+		{
+			name:      "no rate applies",
+			mode:      domain.TaxExclusive,
+			rates:     []domain.TaxRate{regional, category},
+			region:    "bali",
+			lines:     []domain.TaxLine{taxLine(1, 1000)},
+			discounts: []int64{0},
+			taxables:  []int64{1000},
+			taxes:     []int64{0},
+			total:     0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Calculate(tt.mode, tt.rates, tt.region, tt.lines, usd(tt.discount))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Calculate() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+
+			var discounts, taxables, taxes []int64
+			for _, v := range got.Lines {
+				discounts = append(discounts, v.Discount.Amount)
+				taxables = append(taxables, v.Taxable.Amount)
+				taxes = append(taxes, v.Tax.Amount)
+			}
+			if !reflect.DeepEqual(discounts, tt.discounts) {
+				t.Errorf("discounts = %v, want %v", discounts, tt.discounts)
+			}
+			if !reflect.DeepEqual(taxables, tt.taxables) {
+				t.Errorf("taxables = %v, want %v", taxables, tt.taxables)
+			}
+			if !reflect.DeepEqual(taxes, tt.taxes) {
+				t.Errorf("taxes = %v, want %v", taxes, tt.taxes)
+			}
+			if got.Total.Amount != tt.total {
+				t.Errorf("Total = %d, want %d", got.Total.Amount, tt.total)
+			}
+
+			var sum int64
+			for _, v := range got.Taxes {
+				sum += v.Tax.Amount
+			}
+			if sum != got.Total.Amount {
+				t.Errorf("taxes by rate add up to %d, want %d", sum, got.Total.Amount)
+			}
+		})
+	}
+}
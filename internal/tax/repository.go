@@ -0,0 +1,19 @@
+package tax
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	DB() *gorm.DB
+	InsertTaxRate(ctx context.Context, data domain.TaxRate) (*domain.TaxRate, error)
+	FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+	GetTaxRateByID(ctx context.Context, taxRateID int) (domain.TaxRate, error)
+	GetActiveTaxRates(ctx context.Context, tx *gorm.DB) ([]domain.TaxRate, error)
+	UpdateTaxRate(ctx context.Context, taxRateID int, data map[string]interface{}) (int64, error)
+	GetProductCategories(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.TaxLine, error)
+	InsertOrderTaxes(ctx context.Context, tx *gorm.DB, data []domain.OrderTax) error
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/tax"
+	"github.com/online-store/pkg/database"
+	"gorm.io/gorm"
+)
+
+type TaxRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxRepository(db *gorm.DB) tax.Repository {
+	return &TaxRepository{db}
+}
+
+func (r *TaxRepository) DB() *gorm.DB {
+	return r.db
+}
+
+func (r *TaxRepository) InsertTaxRate(ctx context.Context, data domain.TaxRate) (*domain.TaxRate, error) {
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *TaxRepository) FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	paginate := database.NewPaginator(r.db, page, pageSize, model).Raw(query, args, countQuery, args)
+
+	if err := paginate.FindWithOrderBy(ctx, orderBy).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
+
+func (r *TaxRepository) GetTaxRateByID(ctx context.Context, taxRateID int) (domain.TaxRate, error) {
+	var data domain.TaxRate
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND deleted_at IS NULL", taxRateID).First(&data).Error
+	return data, err
+}
+
+func (r *TaxRepository) GetActiveTaxRates(ctx context.Context, tx *gorm.DB) ([]domain.TaxRate, error) {
+	var data []domain.TaxRate
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("is_active AND deleted_at IS NULL").Order("id").Find(&data).Error
+	return data, err
+}
+
+func (r *TaxRepository) UpdateTaxRate(ctx context.Context, taxRateID int, data map[string]interface{}) (int64, error) {
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("tax_rate").Where("id = ? AND deleted_at IS NULL", taxRateID).
+		Updates(data)
+	return result.RowsAffected, result.Error
+}
+
+func (r *TaxRepository) GetProductCategories(ctx context.Context, tx *gorm.DB, productIDs []int) ([]domain.TaxLine, error) {
+	var data []domain.TaxLine
+	if len(productIDs) == 0 {
+		return data, nil
+	}
+
+	err := tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Raw(`SELECT id AS product_id, category_id FROM product WHERE id IN ?`, productIDs).Scan(&data).Error
+	return data, err
+}
+
+func (r *TaxRepository) InsertOrderTaxes(ctx context.Context, tx *gorm.DB, data []domain.OrderTax) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return tx.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).CreateInBatches(&data, 100).Error
+}
@@ -0,0 +1,18 @@
+package tax
+
+import (
+	"context"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+	"gorm.io/gorm"
+)
+
+type UseCase interface {
+	CreateTaxRate(beegoCtx *beegoContext.Context, req domain.CreateTaxRateRequest) (*domain.TaxRate, error)
+	GetTaxRates(beegoCtx *beegoContext.Context, req domain.GetTaxRateListRequest) (*database.Paginator, error)
+	UpdateTaxRate(beegoCtx *beegoContext.Context, req domain.UpdateTaxRateRequest) (*domain.TaxRate, error)
+	CalculateOrder(ctx context.Context, tx *gorm.DB, region string, order []domain.OrderRequest, discount money.Money) (*domain.TaxResult, error)
+	RecordOrder(ctx context.Context, tx *gorm.DB, orderID int, result domain.TaxResult) error
+}
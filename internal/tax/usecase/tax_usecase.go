@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/tax"
+	"github.com/online-store/internal/tax/calculator"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+type TaxUseCase struct {
+	taxRepo   tax.Repository
+	mode      string
+	zapLogger zaplogger.Logger
+}
+
+// NewTaxUseCase returns the tax use case of a store pricing in mode, the prices
+// include the tax unless mode is exclusive.
+func NewTaxUseCase(taxRepo tax.Repository, mode string, zapLogger zaplogger.Logger) tax.UseCase {
+	if mode != domain.TaxExclusive {
+		mode = domain.TaxInclusive
+	}
+	return &TaxUseCase{
+		taxRepo:   taxRepo,
+		mode:      mode,
+		zapLogger: zapLogger,
+	}
+}
+
+func (u *TaxUseCase) CreateTaxRate(beegoCtx *beegoContext.Context, req domain.CreateTaxRateRequest) (*domain.TaxRate, error) {
+	data, err := u.taxRepo.InsertTaxRate(beegoCtx.Request.Context(), domain.TaxRate{
+		Name:       req.Name,
+		Rate:       req.Rate,
+		CategoryID: req.CategoryID,
+		Region:     normalizeRegion(req.Region),
+		IsActive:   true,
+		CreatedAt:  time.Now(),
+		CreatedBy:  "System",
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeForeignKeyConstraint {
+			return nil, domain.ErrForeignKeyConstraint
+		}
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (u *TaxUseCase) GetTaxRates(beegoCtx *beegoContext.Context, req domain.GetTaxRateListRequest) (*database.Paginator, error) {
+	var entities []domain.TaxRate
+
+	query := `SELECT * FROM tax_rate WHERE deleted_at IS NULL`
+	countQuery := `SELECT COUNT(*) FROM tax_rate WHERE deleted_at IS NULL`
+
+	data, err := u.taxRepo.FetchWithFilterAndPagination(
+		beegoCtx.Request.Context(),
+		req.Page,
+		req.Limit,
+		query,
+		countQuery,
+		"ORDER BY id",
+		&entities,
+	)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// UpdateTaxRate changes the given fields only. Orders keep the rate they were
+// taxed at.
+func (u *TaxUseCase) UpdateTaxRate(beegoCtx *beegoContext.Context, req domain.UpdateTaxRateRequest) (*domain.TaxRate, error) {
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+		"updated_by": "System",
+	}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Rate != nil {
+		updates["rate"] = *req.Rate
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	affected, err := u.taxRepo.UpdateTaxRate(beegoCtx.Request.Context(), req.ID, updates)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	data, err := u.taxRepo.GetTaxRateByID(beegoCtx.Request.Context(), req.ID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// CalculateOrder taxes the checkout lines shipped to region, after the discount
+// of the order. The lines of the result follow the order lines.
+func (u *TaxUseCase) CalculateOrder(ctx context.Context, tx *gorm.DB, region string, order []domain.OrderRequest, discount money.Money) (*domain.TaxResult, error) {
+	rates, err := u.taxRepo.GetActiveTaxRates(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]int, 0, len(order))
+	for _, v := range order {
+		productIDs = append(productIDs, v.ProductID)
+	}
+	categories, err := u.taxRepo.GetProductCategories(ctx, tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	categoryByProduct := make(map[int]int, len(categories))
+	for _, v := range categories {
+		categoryByProduct[v.ProductID] = v.CategoryID
+	}
+
+	lines := make([]domain.TaxLine, 0, len(order))
+	for _, v := range order {
+		lines = append(lines, domain.TaxLine{
+			ProductID:  v.ProductID,
+			CategoryID: categoryByProduct[v.ProductID],
+			Amount:     v.Price,
+		})
+	}
+
+	var code string
+	if r := normalizeRegion(&region); r != nil {
+		code = *r
+	}
+	result, err := calculator.Calculate(u.mode, rates, code, lines, discount)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RecordOrder keeps the tax summary of the order.
+func (u *TaxUseCase) RecordOrder(ctx context.Context, tx *gorm.DB, orderID int, result domain.TaxResult) error {
+	data := make([]domain.OrderTax, 0, len(result.Taxes))
+	for _, v := range result.Taxes {
+		v.OrderID = orderID
+		v.CreatedAt = time.Now()
+		data = append(data, v)
+	}
+
+	return u.taxRepo.InsertOrderTaxes(ctx, tx, data)
+}
+
+// normalizeRegion compares regions case insensitively, an empty region is none.
+func normalizeRegion(region *string) *string {
+	if region == nil {
+		return nil
+	}
+	code := strings.ToUpper(strings.TrimSpace(*region))
+	if code == "" {
+		return nil
+	}
+	return &code
+}
@@ -0,0 +1,16 @@
+package wishlist
+
+import (
+	"context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+)
+
+type Repository interface {
+	GetProductPrice(ctx context.Context, productID int, variantID *int) (money.Money, error)
+	InsertWishlist(ctx context.Context, data domain.Wishlist) (*domain.Wishlist, error)
+	GetWishlistByID(ctx context.Context, wishlistID, customerID int) (domain.Wishlist, error)
+	DeleteWishlist(ctx context.Context, wishlistID, customerID int) (int64, error)
+	FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error)
+}
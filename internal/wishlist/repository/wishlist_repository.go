@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/wishlist"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/money"
+	"gorm.io/gorm"
+)
+
+type WishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) wishlist.Repository {
+	return &WishlistRepository{db}
+}
+
+// GetProductPrice returns the current price of the product, of its variant when
+// variantID is set.
+func (r *WishlistRepository) GetProductPrice(ctx context.Context, productID int, variantID *int) (money.Money, error) {
+	var data []struct {
+		Price money.Money `gorm:"column:price"`
+	}
+
+	db := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx)))
+	var err error
+	if variantID == nil {
+		err = db.Raw(`SELECT p.price FROM product p WHERE p.id = ? AND p.deleted_at IS NULL`, productID).Scan(&data).Error
+	} else {
+		err = db.Raw(`SELECT COALESCE(v.price, p.price) AS price 
+					FROM product_variant v 
+					JOIN product p ON p.id = v.product_id 
+					WHERE v.id = ? AND v.product_id = ? AND v.deleted_at IS NULL AND p.deleted_at IS NULL`, *variantID, productID).Scan(&data).Error
+	}
+	if err != nil {
+		return money.Money{}, err
+	}
+	if len(data) == 0 {
+		return money.Money{}, gorm.ErrRecordNotFound
+	}
+	return data[0].Price, nil
+}
+
+func (r *WishlistRepository) InsertWishlist(ctx context.Context, data domain.Wishlist) (*domain.Wishlist, error) {
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Omit("UpdatedAt", "UpdatedBy", "DeletedAt", "DeletedBy").Create(&data).Error
+
+	return &data, err
+}
+
+func (r *WishlistRepository) GetWishlistByID(ctx context.Context, wishlistID, customerID int) (domain.Wishlist, error) {
+	var data domain.Wishlist
+
+	err := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).Where("id = ? AND customer_id = ? AND deleted_at IS NULL", wishlistID, customerID).First(&data).Error
+	return data, err
+}
+
+func (r *WishlistRepository) DeleteWishlist(ctx context.Context, wishlistID, customerID int) (int64, error) {
+	result := r.db.WithContext(newrelic.NewContext(ctx, newrelic.FromContext(ctx))).
+		Table("wishlist").Where("id = ? AND customer_id = ? AND deleted_at IS NULL", wishlistID, customerID).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": domain.CustomerActor(customerID),
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *WishlistRepository) FetchWithFilterAndPagination(ctx context.Context, page int, pageSize int, query string, countQuery string, orderBy string, model interface{}, args ...interface{}) (*database.Paginator, error) {
+	paginate := database.NewPaginator(r.db, page, pageSize, model).Raw(query, args, countQuery, args)
+
+	if err := paginate.FindWithOrderBy(ctx, orderBy).Error; err != nil {
+		return paginate, err
+	}
+	return paginate, nil
+}
@@ -0,0 +1,14 @@
+package wishlist
+
+import (
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg/database"
+)
+
+type UseCase interface {
+	AddToWishlist(beegoCtx *beegoContext.Context, req domain.AddWishlistRequest) (*domain.Wishlist, error)
+	GetWishlist(beegoCtx *beegoContext.Context, req domain.GetWishlistRequest) (*database.Paginator, error)
+	DeleteWishlist(beegoCtx *beegoContext.Context, wishlistID, customerID int) error
+	MoveToCart(beegoCtx *beegoContext.Context, req domain.MoveWishlistToCartRequest) error
+}
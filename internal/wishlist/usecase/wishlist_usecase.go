@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/jackc/pgconn"
+	"github.com/online-store/internal/cart"
+	"github.com/online-store/internal/currency"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/internal/wishlist"
+	"github.com/online-store/pkg/database"
+	"github.com/online-store/pkg/zaplogger"
+	"gorm.io/gorm"
+)
+
+// wishlistProductQuery selects the wishlist of a customer with the current price and
+// stock of the products, the stock of the variant for entries with one.
+const wishlistProductQuery = `SELECT 
+					wl.id AS wishlist_id, 
+					wl.product_id, 
+					p."name" AS product_name, 
+					wl.variant_id, 
+					v.sku, 
+					wl.added_price, 
+					COALESCE(v.price, p.price) AS current_price, 
+					COALESCE(v.price, p.price) < wl.added_price AS price_dropped, 
+					COALESCE((SELECT SUM(ws.stock - ws.reserved_stock) FROM warehouse_stock ws JOIN warehouse w ON w.id = ws.warehouse_id 
+						WHERE ws.product_id = wl.product_id AND (wl.variant_id IS NULL OR ws.variant_id = wl.variant_id) AND w.is_active AND w.deleted_at IS NULL), 0) AS available_stock, 
+					wl.created_at 
+				FROM wishlist wl 
+				JOIN product p ON p.id = wl.product_id 
+				LEFT JOIN product_variant v ON v.id = wl.variant_id 
+				WHERE wl.deleted_at IS NULL AND p.deleted_at IS NULL AND wl.customer_id = ?`
+
+type WishlistUseCase struct {
+	wishlistRepo wishlist.Repository
+	cartUC       cart.UseCase
+	currencyUC   currency.UseCase
+	zapLogger    zaplogger.Logger
+}
+
+func NewWishlistUseCase(wishlistRepo wishlist.Repository, cartUC cart.UseCase, currencyUC currency.UseCase, zapLogger zaplogger.Logger) wishlist.UseCase {
+	return &WishlistUseCase{
+		wishlistRepo: wishlistRepo,
+		cartUC:       cartUC,
+		currencyUC:   currencyUC,
+		zapLogger:    zapLogger,
+	}
+}
+
+// AddToWishlist saves the product for the customer with its current price, a
+// product is saved once.
+func (u *WishlistUseCase) AddToWishlist(beegoCtx *beegoContext.Context, req domain.AddWishlistRequest) (*domain.Wishlist, error) {
+	price, err := u.wishlistRepo.GetProductPrice(beegoCtx.Request.Context(), req.ProductID, req.VariantID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return nil, err
+	}
+
+	data, err := u.wishlistRepo.InsertWishlist(beegoCtx.Request.Context(), domain.Wishlist{
+		CustomerID: req.CustomerID,
+		ProductID:  req.ProductID,
+		VariantID:  req.VariantID,
+		AddedPrice: price,
+		CreatedAt:  time.Now(),
+		CreatedBy:  domain.CustomerActor(req.CustomerID),
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == domain.PgCodeUniqueConstraint {
+			return nil, domain.ErrUniqueConstraint
+		}
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetWishlist lists the wishlist of the customer, the latest saved first, with the
+// prices converted to the requested currency.
+func (u *WishlistUseCase) GetWishlist(beegoCtx *beegoContext.Context, req domain.GetWishlistRequest) (*database.Paginator, error) {
+	var entities []domain.WishlistProduct
+
+	rate, err := u.currencyUC.GetExchangeRate(beegoCtx.Request.Context(), req.Currency)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	countQuery := `SELECT COUNT(*) FROM wishlist wl 
+				JOIN product p ON p.id = wl.product_id 
+				WHERE wl.deleted_at IS NULL AND p.deleted_at IS NULL AND wl.customer_id = ?`
+
+	data, err := u.wishlistRepo.FetchWithFilterAndPagination(
+		beegoCtx.Request.Context(),
+		req.Page,
+		req.Limit,
+		wishlistProductQuery,
+		countQuery,
+		"ORDER BY wl.created_at DESC, wl.id DESC",
+		&entities,
+		req.CustomerID,
+	)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	for i, v := range entities {
+		if entities[i].AddedPrice, err = rate.Convert(v.AddedPrice); err != nil {
+			return nil, err
+		}
+		if entities[i].CurrentPrice, err = rate.Convert(v.CurrentPrice); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func (u *WishlistUseCase) DeleteWishlist(beegoCtx *beegoContext.Context, wishlistID, customerID int) error {
+	rowsAffected, err := u.wishlistRepo.DeleteWishlist(beegoCtx.Request.Context(), wishlistID, customerID)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		return err
+	}
+	if rowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// MoveToCart adds the wishlist entry to the cart of the customer through the cart
+// use case, then removes it from the wishlist.
+func (u *WishlistUseCase) MoveToCart(beegoCtx *beegoContext.Context, req domain.MoveWishlistToCartRequest) error {
+	data, err := u.wishlistRepo.GetWishlistByID(beegoCtx.Request.Context(), req.ID, req.CustomerID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return err
+	}
+
+	//the product may be gone since it was saved
+	if _, err := u.wishlistRepo.GetProductPrice(beegoCtx.Request.Context(), data.ProductID, data.VariantID); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			beegoCtx.Input.SetData("stackTrace", u.zapLogger.SetMessageLog(err))
+		}
+		return err
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	if err := u.cartUC.InsertCartItem(beegoCtx, domain.CreateCartRequest{
+		CartItem:   []domain.CartItem{{ProductID: data.ProductID, VariantID: data.VariantID, Quantity: quantity}},
+		CustomerID: req.CustomerID,
+	}); err != nil {
+		return err
+	}
+
+	return u.DeleteWishlist(beegoCtx, req.ID, req.CustomerID)
+}
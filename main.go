@@ -18,7 +18,14 @@ import (
 	"github.com/online-store/pkg/cache/redis"
 	"github.com/online-store/pkg/database"
 	"github.com/online-store/pkg/httpclient"
+	"github.com/online-store/pkg/jwtkey"
+	"github.com/online-store/pkg/money"
+	"github.com/online-store/pkg/notifier"
+	"github.com/online-store/pkg/notifier/email"
+	notifierLogger "github.com/online-store/pkg/notifier/logger"
+	"github.com/online-store/pkg/notifier/slack"
 	"github.com/online-store/pkg/response"
+	"github.com/online-store/pkg/storage/local"
 	"github.com/online-store/pkg/zaplogger"
 	"net"
 	"net/http"
@@ -42,6 +49,68 @@ import (
 	orderHandler "github.com/online-store/internal/order/delivery/http"
 	orderRepository "github.com/online-store/internal/order/repository"
 	orderUseCase "github.com/online-store/internal/order/usecase"
+
+	apiKeyHandler "github.com/online-store/internal/apikey/delivery/http"
+	apiKeyRepository "github.com/online-store/internal/apikey/repository"
+	apiKeyUseCase "github.com/online-store/internal/apikey/usecase"
+
+	mediaHandler "github.com/online-store/internal/media/delivery/http"
+	mediaRepository "github.com/online-store/internal/media/repository"
+	mediaUseCase "github.com/online-store/internal/media/usecase"
+
+	inventoryAllocator "github.com/online-store/internal/inventory/allocator"
+	inventoryHandler "github.com/online-store/internal/inventory/delivery/http"
+	inventoryRepository "github.com/online-store/internal/inventory/repository"
+	inventoryUseCase "github.com/online-store/internal/inventory/usecase"
+
+	stockAlertHandler "github.com/online-store/internal/stockalert/delivery/http"
+	stockAlertRepository "github.com/online-store/internal/stockalert/repository"
+	stockAlertUseCase "github.com/online-store/internal/stockalert/usecase"
+
+	couponHandler "github.com/online-store/internal/coupon/delivery/http"
+	couponRepository "github.com/online-store/internal/coupon/repository"
+	couponUseCase "github.com/online-store/internal/coupon/usecase"
+
+	promotionHandler "github.com/online-store/internal/promotion/delivery/http"
+	promotionRepository "github.com/online-store/internal/promotion/repository"
+	promotionUseCase "github.com/online-store/internal/promotion/usecase"
+
+	currencyHandler "github.com/online-store/internal/currency/delivery/http"
+	currencyRepository "github.com/online-store/internal/currency/repository"
+	currencyUseCase "github.com/online-store/internal/currency/usecase"
+
+	taxHandler "github.com/online-store/internal/tax/delivery/http"
+	taxRepository "github.com/online-store/internal/tax/repository"
+	taxUseCase "github.com/online-store/internal/tax/usecase"
+
+	"github.com/online-store/internal/shipping"
+	shippingCarrier "github.com/online-store/internal/shipping/carrier"
+	shippingHandler "github.com/online-store/internal/shipping/delivery/http"
+	shippingRepository "github.com/online-store/internal/shipping/repository"
+	shippingUseCase "github.com/online-store/internal/shipping/usecase"
+
+	shipmentHandler "github.com/online-store/internal/shipment/delivery/http"
+	shipmentRepository "github.com/online-store/internal/shipment/repository"
+	shipmentUseCase "github.com/online-store/internal/shipment/usecase"
+
+	returnHandler "github.com/online-store/internal/returns/delivery/http"
+	returnRepository "github.com/online-store/internal/returns/repository"
+	returnUseCase "github.com/online-store/internal/returns/usecase"
+
+	invoiceHandler "github.com/online-store/internal/invoice/delivery/http"
+	invoiceRepository "github.com/online-store/internal/invoice/repository"
+	invoiceUseCase "github.com/online-store/internal/invoice/usecase"
+
+	reviewHandler "github.com/online-store/internal/review/delivery/http"
+	reviewRepository "github.com/online-store/internal/review/repository"
+	reviewUseCase "github.com/online-store/internal/review/usecase"
+
+	wishlistHandler "github.com/online-store/internal/wishlist/delivery/http"
+	wishlistRepository "github.com/online-store/internal/wishlist/repository"
+	wishlistUseCase "github.com/online-store/internal/wishlist/usecase"
+
+	recommendationRepository "github.com/online-store/internal/recommendation/repository"
+	recommendationUseCase "github.com/online-store/internal/recommendation/usecase"
 )
 
 func main() {
@@ -65,6 +134,20 @@ func main() {
 		dbSectionConfig = config
 	}
 
+	// jwt signing keys
+	if err := jwtkey.Init(os.Getenv("JWT_KEY_DIR"), os.Getenv("JWT_ACTIVE_KID")); err != nil {
+		zapLog.Fatal(err)
+	}
+
+	// cursor pagination signing key
+	database.SetCursorSecret([]byte(os.Getenv("CURSOR_SECRET")))
+
+	// paginated queries don't outlive the handlers
+	database.SetPaginatorTimeout(time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5)) * time.Second)
+
+	// currency of the stored amounts
+	money.SetDefaultCurrency(beego.AppConfig.DefaultString("currency", "IDR"))
+
 	gormDb, err := database.New(database.ConfigFromEnvironment(dbSectionConfig))
 	if err != nil {
 		zapLog.Fatal(err)
@@ -81,6 +164,46 @@ func main() {
 		beego.BConfig.WebConfig.StaticDir["/swagger"] = "swagger"
 	}
 
+	// uploaded media, the multipart overhead is allowed on top of the largest upload
+	mediaPath := beego.AppConfig.DefaultString("mediaPath", "./media")
+	mediaBaseUrl := beego.AppConfig.DefaultString("mediaBaseUrl", "/media")
+	mediaMaxUploadSize := beego.AppConfig.DefaultInt64("mediaMaxUploadSize", domain.DefaultMaxImageUploadSize)
+	importMaxUploadSize := beego.AppConfig.DefaultInt64("importMaxUploadSize", domain.DefaultMaxImportUploadSize)
+	beego.BConfig.WebConfig.StaticDir["/media"] = mediaPath
+	beego.BConfig.MaxUploadSize = mediaMaxUploadSize + 1<<20
+	if importMaxUploadSize > mediaMaxUploadSize {
+		beego.BConfig.MaxUploadSize = importMaxUploadSize + 1<<20
+	}
+
+	// low-stock alerts go to every configured channel, back-in-stock notifications
+	// are emailed to the customers, or only logged without an SMTP server
+	logNotifier := notifierLogger.NewLogNotifier(zapLog)
+	alertNotifiers := []notifier.Notifier{logNotifier}
+	customerNotifier := logNotifier
+	if smtpHost := beego.AppConfig.DefaultString("smtpHost", ""); smtpHost != "" {
+		var alertEmailTo []string
+		if to := beego.AppConfig.DefaultString("alertEmailTo", ""); to != "" {
+			alertEmailTo = strings.Split(to, ",")
+		}
+
+		emailNotifier := email.NewEmailNotifier(
+			smtpHost,
+			beego.AppConfig.DefaultInt("smtpPort", 587),
+			beego.AppConfig.DefaultString("smtpUsername", ""),
+			beego.AppConfig.DefaultString("smtpPassword", ""),
+			beego.AppConfig.DefaultString("smtpFrom", ""),
+			alertEmailTo,
+			10*time.Second,
+		)
+		customerNotifier = emailNotifier
+		if len(alertEmailTo) > 0 {
+			alertNotifiers = append(alertNotifiers, emailNotifier)
+		}
+	}
+	if slackWebhookUrl := beego.AppConfig.DefaultString("slackWebhookUrl", ""); slackWebhookUrl != "" {
+		alertNotifiers = append(alertNotifiers, slack.NewSlackNotifier(slackWebhookUrl, 10*time.Second))
+	}
+
 	beego.BConfig.RecoverFunc = func(context *beegoContext.Context, config *beego.Config) {
 		if err := recover(); err != nil {
 			fmt.Println("masuk selalu", err)
@@ -153,20 +276,73 @@ func main() {
 
 	apiResponseInterface := response.NewAPIResponse()
 
-	// init routers filters
-	internal.InitRouterFilters(restyClient, zapLog, apiResponseInterface)
-
 	//init repository
 	productRepository := productRepository.NewProductRepository(gormDb.Conn())
 	customerRepo := customerRepository.NewCustomerRepository(gormDb.Conn())
 	cartRepo := cartRepository.NewCartRepository(gormDb.Conn())
 	orderRepo := orderRepository.NewOrderRepository(gormDb.Conn())
+	apiKeyRepo := apiKeyRepository.NewApiKeyRepository(gormDb.Conn())
+	mediaRepo := mediaRepository.NewMediaRepository(gormDb.Conn())
+	inventoryRepo := inventoryRepository.NewInventoryRepository(gormDb.Conn())
+	stockAlertRepo := stockAlertRepository.NewStockAlertRepository(gormDb.Conn())
+	couponRepo := couponRepository.NewCouponRepository(gormDb.Conn())
+	promotionRepo := promotionRepository.NewPromotionRepository(gormDb.Conn())
+	currencyRepo := currencyRepository.NewCurrencyRepository(gormDb.Conn())
+	taxRepo := taxRepository.NewTaxRepository(gormDb.Conn())
+	shippingRepo := shippingRepository.NewShippingRepository(gormDb.Conn())
+	shipmentRepo := shipmentRepository.NewShipmentRepository(gormDb.Conn())
+	returnRepo := returnRepository.NewReturnRepository(gormDb.Conn())
+	invoiceRepo := invoiceRepository.NewInvoiceRepository(gormDb.Conn())
+	reviewRepo := reviewRepository.NewReviewRepository(gormDb.Conn())
+	wishlistRepo := wishlistRepository.NewWishlistRepository(gormDb.Conn())
+	recommendationRepo := recommendationRepository.NewRecommendationRepository(gormDb.Conn())
 
 	//init use case
-	productUseCase := productUC.NewProductUseCase(productRepository, redisRepository, zapLog)
-	customerUC := customerUseCase.NewCustomerUseCase(customerRepo, zapLog)
-	cartUC := cartUseCase.NewCustomerUseCase(cartRepo, zapLog, redisRepository)
-	orderUC := orderUseCase.NewOrderUseCase(orderRepo, zapLog)
+	stockAlertUC := stockAlertUseCase.NewStockAlertUseCase(
+		stockAlertRepo,
+		notifier.NewMultiNotifier(alertNotifiers...),
+		customerNotifier,
+		beego.AppConfig.DefaultInt("lowStockThreshold", domain.DefaultLowStockThreshold),
+		beego.AppConfig.DefaultString("alertLang", "en"),
+		zapLog,
+	)
+	mediaUC := mediaUseCase.NewMediaUseCase(mediaRepo, local.NewLocalStorage(mediaPath, mediaBaseUrl), mediaMaxUploadSize, zapLog)
+	currencyUC := currencyUseCase.NewCurrencyUseCase(currencyRepo, zapLog)
+	// carrier rates, a local fake carrier stands in when no carrier API is configured
+	var carrier shipping.Carrier
+	carrierCode := beego.AppConfig.DefaultString("carrier::code", "courier")
+	if carrierUrl := beego.AppConfig.DefaultString("carrier::url", ""); carrierUrl != "" {
+		carrier = shippingCarrier.NewRestCarrier(carrierCode, carrierUrl, beego.AppConfig.DefaultString("carrier::apiKey", ""), restyClient)
+	} else {
+		carrier = shippingCarrier.NewFakeCarrier(carrierCode,
+			money.FromMajor(beego.AppConfig.DefaultFloat("carrier::fakeBaseRate", 10000), money.DefaultCurrency()),
+			money.FromMajor(beego.AppConfig.DefaultFloat("carrier::fakePerKgRate", 5000), money.DefaultCurrency()))
+	}
+	shippingUC := shippingUseCase.NewShippingUseCase(shippingRepo, []shipping.Carrier{carrier}, zapLog)
+	recommendationUC := recommendationUseCase.NewRecommendationUseCase(recommendationRepo, beego.AppConfig.DefaultInt("recommendation::limit", 8), beego.AppConfig.DefaultInt("recommendation::minOrders", 2), zapLog)
+	productUseCase := productUC.NewProductUseCase(productRepository, inventoryRepo, mediaUC, stockAlertUC, currencyUC, recommendationUC, redisRepository, zapLog)
+	customerUC := customerUseCase.NewCustomerUseCase(customerRepo, zapLog, redisRepository)
+	promotionUC := promotionUseCase.NewPromotionUseCase(promotionRepo, zapLog)
+	cartUC := cartUseCase.NewCustomerUseCase(cartRepo, promotionUC, currencyUC, shippingUC, recommendationUC, zapLog, redisRepository)
+	couponUC := couponUseCase.NewCouponUseCase(couponRepo, promotionUC, zapLog)
+	taxUC := taxUseCase.NewTaxUseCase(taxRepo, beego.AppConfig.DefaultString("taxMode", domain.TaxInclusive), zapLog)
+	invoiceUC := invoiceUseCase.NewInvoiceUseCase(invoiceRepo, domain.InvoiceIssuer{
+		Name:      beego.AppConfig.DefaultString("company::name", "Online Store"),
+		Address:   beego.AppConfig.DefaultString("company::address", ""),
+		Phone:     beego.AppConfig.DefaultString("company::phone", ""),
+		Email:     beego.AppConfig.DefaultString("company::email", ""),
+		TaxNumber: beego.AppConfig.DefaultString("company::taxNumber", ""),
+	}, beego.AppConfig.DefaultString("company::invoicePrefix", "INV"), zapLog)
+	orderUC := orderUseCase.NewOrderUseCase(orderRepo, inventoryRepo, inventoryAllocator.NewSingleWarehouseAllocator(), couponUC, promotionUC, stockAlertUC, currencyUC, taxUC, shippingUC, invoiceUC, redisRepository, zapLog)
+	shipmentUC := shipmentUseCase.NewShipmentUseCase(shipmentRepo, zapLog)
+	returnUC := returnUseCase.NewReturnUseCase(returnRepo, inventoryRepo, stockAlertUC, zapLog)
+	reviewUC := reviewUseCase.NewReviewUseCase(reviewRepo, redisRepository, zapLog)
+	wishlistUC := wishlistUseCase.NewWishlistUseCase(wishlistRepo, cartUC, currencyUC, zapLog)
+	apiKeyUC := apiKeyUseCase.NewApiKeyUseCase(apiKeyRepo, zapLog)
+	inventoryUC := inventoryUseCase.NewInventoryUseCase(inventoryRepo, stockAlertUC, redisRepository, zapLog)
+
+	// init routers filters
+	internal.InitRouterFilters(restyClient, zapLog, apiResponseInterface, apiKeyUC, redisRepository)
 
 	// default error handler
 	beego.ErrorController(&internal.BaseController{})
@@ -176,6 +352,39 @@ func main() {
 	customerHandler.NewCustomerHandler(customerUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
 	cartHandler.NewProductHandler(cartUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
 	orderHandler.NewOrderHandler(orderUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	apiKeyHandler.NewApiKeyHandler(apiKeyUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	mediaHandler.NewMediaHandler(mediaUC, mediaMaxUploadSize, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	inventoryHandler.NewInventoryHandler(inventoryUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	stockAlertHandler.NewStockAlertHandler(stockAlertUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	couponHandler.NewCouponHandler(couponUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	promotionHandler.NewPromotionHandler(promotionUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	currencyHandler.NewCurrencyHandler(currencyUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	taxHandler.NewTaxHandler(taxUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	shippingHandler.NewShippingHandler(shippingUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	shipmentHandler.NewShipmentHandler(shipmentUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	returnHandler.NewReturnHandler(returnUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	invoiceHandler.NewInvoiceHandler(invoiceUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	reviewHandler.NewReviewHandler(reviewUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+	wishlistHandler.NewWishlistHandler(wishlistUC, time.Duration(beego.AppConfig.DefaultInt("executionTimeout", 5))*time.Second, apiResponseInterface)
+
+	// Refreshing the recommendations in the background until the server shuts down
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	recommendationRefreshInterval := beego.AppConfig.DefaultInt("recommendation::refreshInterval", 60)
+	if recommendationRefreshInterval <= 0 {
+		recommendationRefreshInterval = 60
+	}
+	go recommendationUC.Run(jobCtx, time.Duration(recommendationRefreshInterval)*time.Minute)
+
+	// Cancelling the orders left unpaid, so their reserved stock is sold again
+	orderExpiryInterval := beego.AppConfig.DefaultInt("order::expiryInterval", 5)
+	if orderExpiryInterval <= 0 {
+		orderExpiryInterval = 5
+	}
+	orderPendingTTL := beego.AppConfig.DefaultInt("order::pendingTTL", 60)
+	if orderPendingTTL <= 0 {
+		orderPendingTTL = 60
+	}
+	go orderUC.Run(jobCtx, time.Duration(orderExpiryInterval)*time.Minute, time.Duration(orderPendingTTL)*time.Minute)
 
 	// Initializing the server in a goroutine so that
 	// it won't block the graceful shutdown handling below
@@ -192,6 +401,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
 	sig := <-quit
+	stopJobs()
 
 	pid := syscall.Getpid()
 
@@ -108,4 +108,753 @@ CREATE TABLE "public"."payment" (
   "deleted_at" timestamptz(6),
   "deleted_by" varchar(50),
   PRIMARY KEY ("id")
-);
\ No newline at end of file
+);
+
+CREATE TABLE "public"."api_key" (
+ "id" serial8,
+ "name" varchar(100),
+ "key_prefix" varchar(20),
+ "key_hash" varchar(64) NOT NULL,
+ "scopes" varchar(255),
+ "customer_id" int8,
+ "expired_at" timestamptz(6),
+ "revoked_at" timestamptz(6),
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50) DEFAULT 'system',
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "uq_api_key_hash" UNIQUE ("key_hash"),
+  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "public"."customer" ("customer_id")
+);
+
+CREATE TABLE "public"."audit_log" (
+ "id" serial8,
+ "customer_id" int8,
+ "action" varchar(50),
+ "description" varchar(255),
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50) DEFAULT 'system',
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "public"."customer" ("customer_id")
+);
+
+-- product full-text search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE "public"."product" ADD COLUMN "search_vector" tsvector;
+
+CREATE OR REPLACE FUNCTION "public"."product_search_vector"(p_name varchar, p_description varchar, p_category_id int8) RETURNS tsvector AS $$
+  SELECT setweight(to_tsvector('simple', coalesce(p_name, '')), 'A') ||
+         setweight(to_tsvector('simple', coalesce(p_description, '')), 'B') ||
+         setweight(to_tsvector('simple', coalesce((SELECT c."name" FROM "public"."category" c WHERE c.id = p_category_id), '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION "public"."product_search_vector_trigger"() RETURNS trigger AS $$
+BEGIN
+  NEW.search_vector := "public"."product_search_vector"(NEW."name", NEW.description, NEW.category_id);
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_product_search_vector" BEFORE INSERT OR UPDATE OF "name", "description", "category_id" ON "public"."product"
+  FOR EACH ROW EXECUTE FUNCTION "public"."product_search_vector_trigger"();
+
+CREATE OR REPLACE FUNCTION "public"."category_search_vector_trigger"() RETURNS trigger AS $$
+BEGIN
+  UPDATE "public"."product" SET search_vector = "public"."product_search_vector"("name", description, category_id) WHERE category_id = NEW.id;
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_category_search_vector" AFTER UPDATE OF "name" ON "public"."category"
+  FOR EACH ROW EXECUTE FUNCTION "public"."category_search_vector_trigger"();
+
+UPDATE "public"."product" SET search_vector = "public"."product_search_vector"("name", description, category_id);
+
+CREATE INDEX "idx_product_search_vector" ON "public"."product" USING GIN ("search_vector");
+CREATE INDEX "idx_product_name_trgm" ON "public"."product" USING GIN ("name" gin_trgm_ops);
+
+-- product variants
+CREATE TABLE "public"."product_variant" (
+ "id" serial8,
+ "product_id" int8 NOT NULL,
+ "sku" varchar(64) NOT NULL,
+ "price" float8,
+ "stock" int4 NOT NULL DEFAULT 0,
+ "reserved_stock" int4 NOT NULL DEFAULT 0,
+ "barcode" varchar(64),
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50) DEFAULT 'system',
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "uq_product_variant_sku" UNIQUE ("sku"),
+  CONSTRAINT "uq_product_variant_product" UNIQUE ("id", "product_id"),
+  CONSTRAINT "chk_product_variant_stock" CHECK ("reserved_stock" >= 0 AND "reserved_stock" <= "stock"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id")
+);
+
+CREATE TABLE "public"."product_variant_option" (
+ "variant_id" int8 NOT NULL,
+ "name" varchar(50) NOT NULL,
+ "value" varchar(50) NOT NULL,
+  PRIMARY KEY ("variant_id", "name"),
+  CONSTRAINT "fk_product_variant" FOREIGN KEY ("variant_id") REFERENCES "public"."product_variant" ("id")
+);
+
+ALTER TABLE "public"."product" ADD COLUMN "reserved_stock" int4 NOT NULL DEFAULT 0;
+
+-- a variant must belong to the product of the line, lines without variant skip the check
+ALTER TABLE "public"."cart" ADD COLUMN "variant_id" int8;
+ALTER TABLE "public"."cart" ADD CONSTRAINT "fk_product_variant" FOREIGN KEY ("variant_id", "product_id") REFERENCES "public"."product_variant" ("id", "product_id");
+
+ALTER TABLE "public"."order_item" ADD COLUMN "variant_id" int8;
+ALTER TABLE "public"."order_item" ADD COLUMN "sku" varchar(64);
+ALTER TABLE "public"."order_item" ADD CONSTRAINT "fk_product_variant" FOREIGN KEY ("variant_id", "product_id") REFERENCES "public"."product_variant" ("id", "product_id");
+
+-- product images, the files live in the media storage under storage_key
+CREATE TABLE "public"."product_image" (
+ "id" serial8,
+ "product_id" int8 NOT NULL,
+ "position" int4 NOT NULL DEFAULT 0,
+ "storage_key" varchar(255) NOT NULL,
+ "content_type" varchar(50) NOT NULL,
+ "size" int8 NOT NULL,
+ "width" int4 NOT NULL,
+ "height" int4 NOT NULL,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50) DEFAULT 'system',
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id")
+);
+
+CREATE INDEX "idx_product_image_product" ON "public"."product_image" ("product_id", "position") WHERE "deleted_at" IS NULL;
+
+-- products are imported and exported by sku, rows created before have none
+ALTER TABLE "public"."product" ADD COLUMN "sku" varchar(64);
+ALTER TABLE "public"."product" ADD CONSTRAINT "uq_product_sku" UNIQUE ("sku");
+
+-- inventory ledger, the stock of a product or variant is the sum of its movements
+CREATE TABLE "public"."inventory_movement" (
+ "id" serial8,
+ "product_id" int8 NOT NULL,
+ "variant_id" int8,
+ "quantity" int4 NOT NULL DEFAULT 0,
+ "reserved_quantity" int4 NOT NULL DEFAULT 0,
+ "reason" varchar(30) NOT NULL,
+ "reference" varchar(50),
+ "note" varchar(255),
+ "actor" varchar(50) NOT NULL,
+"created_at" timestamptz(6) DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id"),
+  CONSTRAINT "fk_product_variant" FOREIGN KEY ("variant_id", "product_id") REFERENCES "public"."product_variant" ("id", "product_id")
+);
+
+CREATE INDEX "idx_inventory_movement_product" ON "public"."inventory_movement" ("product_id", "created_at");
+CREATE INDEX "idx_inventory_movement_variant" ON "public"."inventory_movement" ("variant_id") WHERE "variant_id" IS NOT NULL;
+
+-- open the ledger with the current stock
+INSERT INTO "public"."inventory_movement" ("product_id", "quantity", "reserved_quantity", "reason", "actor")
+SELECT "id", "stock", "reserved_stock", 'opening_balance', 'System' FROM "public"."product" WHERE "stock" <> 0 OR "reserved_stock" <> 0;
+INSERT INTO "public"."inventory_movement" ("product_id", "variant_id", "quantity", "reserved_quantity", "reason", "actor")
+SELECT "product_id", "id", "stock", "reserved_stock", 'opening_balance', 'System' FROM "public"."product_variant" WHERE "stock" <> 0 OR "reserved_stock" <> 0;
+
+ALTER TABLE "public"."product" ADD CONSTRAINT "chk_product_stock" CHECK ("reserved_stock" >= 0 AND "reserved_stock" <= "stock");
+
+-- warehouses, the stock of a product or variant is the sum of its stock in every warehouse
+CREATE TABLE "public"."warehouse" (
+ "id" serial8,
+ "code" varchar(20) NOT NULL,
+ "name" varchar(50) NOT NULL,
+ "address" varchar(255) NOT NULL DEFAULT '',
+ "priority" int4 NOT NULL DEFAULT 0,
+ "is_active" bool NOT NULL DEFAULT true,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50) DEFAULT 'system',
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "uq_warehouse_code" UNIQUE ("code")
+);
+
+INSERT INTO "public"."warehouse" ("code", "name") VALUES ('MAIN', 'Main Warehouse');
+
+CREATE TABLE "public"."warehouse_stock" (
+ "id" serial8,
+ "warehouse_id" int8 NOT NULL,
+ "product_id" int8 NOT NULL,
+ "variant_id" int8,
+ "stock" int4 NOT NULL DEFAULT 0,
+ "reserved_stock" int4 NOT NULL DEFAULT 0,
+ "updated_at" timestamptz(6) DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_warehouse" FOREIGN KEY ("warehouse_id") REFERENCES "public"."warehouse" ("id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id"),
+  CONSTRAINT "fk_product_variant" FOREIGN KEY ("variant_id", "product_id") REFERENCES "public"."product_variant" ("id", "product_id"),
+  CONSTRAINT "chk_warehouse_stock" CHECK ("reserved_stock" >= 0 AND "reserved_stock" <= "stock")
+);
+
+CREATE UNIQUE INDEX "uq_warehouse_stock" ON "public"."warehouse_stock" ("warehouse_id", "product_id", (COALESCE("variant_id", 0)));
+CREATE INDEX "idx_warehouse_stock_product" ON "public"."warehouse_stock" ("product_id");
+
+-- the warehouses the items of an order are shipped from
+CREATE TABLE "public"."order_item_allocation" (
+ "id" serial8,
+ "order_id" int8 NOT NULL,
+ "product_id" int8 NOT NULL,
+ "variant_id" int8,
+ "warehouse_id" int8 NOT NULL,
+ "quantity" int4 NOT NULL,
+"created_at" timestamptz(6) DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_order" FOREIGN KEY ("order_id") REFERENCES "public"."order" ("id"),
+  CONSTRAINT "fk_warehouse" FOREIGN KEY ("warehouse_id") REFERENCES "public"."warehouse" ("id")
+);
+
+CREATE INDEX "idx_order_item_allocation_order" ON "public"."order_item_allocation" ("order_id");
+
+ALTER TABLE "public"."inventory_movement" ADD COLUMN "warehouse_id" int8;
+ALTER TABLE "public"."inventory_movement" ADD CONSTRAINT "fk_warehouse" FOREIGN KEY ("warehouse_id") REFERENCES "public"."warehouse" ("id");
+
+-- the current stock, its reservations and its ledger move to the main warehouse
+INSERT INTO "public"."warehouse_stock" ("warehouse_id", "product_id", "stock", "reserved_stock")
+SELECT w."id", p."id", p."stock", p."reserved_stock" FROM "public"."product" p, "public"."warehouse" w WHERE w."code" = 'MAIN' AND (p."stock" <> 0 OR p."reserved_stock" <> 0);
+INSERT INTO "public"."warehouse_stock" ("warehouse_id", "product_id", "variant_id", "stock", "reserved_stock")
+SELECT w."id", v."product_id", v."id", v."stock", v."reserved_stock" FROM "public"."product_variant" v, "public"."warehouse" w WHERE w."code" = 'MAIN' AND (v."stock" <> 0 OR v."reserved_stock" <> 0);
+
+UPDATE "public"."inventory_movement" SET "warehouse_id" = (SELECT "id" FROM "public"."warehouse" WHERE "code" = 'MAIN');
+
+INSERT INTO "public"."order_item_allocation" ("order_id", "product_id", "variant_id", "warehouse_id", "quantity")
+SELECT oi."order_id", oi."product_id", oi."variant_id", w."id", oi."quantity"
+FROM "public"."order_item" oi
+JOIN "public"."order" o ON o."id" = oi."order_id"
+JOIN "public"."warehouse" w ON w."code" = 'MAIN'
+WHERE o."payment_id" IS NULL AND oi."deleted_at" IS NULL;
+
+-- low-stock alerts, products without a threshold use the lowStockThreshold setting
+ALTER TABLE "public"."product" ADD COLUMN "low_stock_threshold" int4;
+ALTER TABLE "public"."product" ADD COLUMN "low_stock_alerted_at" timestamptz(6);
+
+-- customers waiting for a product, or one of its variants, to be back in stock
+CREATE TABLE "public"."stock_subscription" (
+ "id" serial8,
+ "customer_id" int8 NOT NULL,
+ "product_id" int8 NOT NULL,
+ "variant_id" int8,
+ "lang" varchar(5) NOT NULL,
+ "notified_at" timestamptz(6),
+"created_at" timestamptz(6) DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "public"."customer" ("customer_id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id"),
+  CONSTRAINT "fk_product_variant" FOREIGN KEY ("variant_id", "product_id") REFERENCES "public"."product_variant" ("id", "product_id")
+);
+
+CREATE UNIQUE INDEX "uq_stock_subscription_pending" ON "public"."stock_subscription" ("customer_id", "product_id", (COALESCE("variant_id", 0))) WHERE "notified_at" IS NULL;
+CREATE INDEX "idx_stock_subscription_product" ON "public"."stock_subscription" ("product_id") WHERE "notified_at" IS NULL;
+
+-- coupons, their codes are stored in upper case
+CREATE TABLE "public"."coupon" (
+ "id" serial8,
+ "code" varchar(30) NOT NULL,
+ "description" varchar(255) NOT NULL DEFAULT '',
+ "discount_type" varchar(20) NOT NULL,
+ "discount_value" float8 NOT NULL,
+ "max_discount" float8,
+ "min_spend" float8 NOT NULL DEFAULT 0,
+ "usage_limit" int4,
+ "usage_per_customer" int4,
+ "used_count" int4 NOT NULL DEFAULT 0,
+ "starts_at" timestamptz(6),
+ "ends_at" timestamptz(6),
+ "is_active" bool NOT NULL DEFAULT true,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50) DEFAULT 'system',
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "uq_coupon_code" UNIQUE ("code"),
+  CONSTRAINT "chk_coupon_discount_type" CHECK ("discount_type" IN ('percentage', 'fixed'))
+);
+
+-- the products and categories a coupon is restricted to, none means every product
+CREATE TABLE "public"."coupon_target" (
+ "coupon_id" int8 NOT NULL,
+ "product_id" int8,
+ "category_id" int8,
+  CONSTRAINT "fk_coupon" FOREIGN KEY ("coupon_id") REFERENCES "public"."coupon" ("id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id"),
+  CONSTRAINT "fk_category" FOREIGN KEY ("category_id") REFERENCES "public"."category" ("id"),
+  CONSTRAINT "chk_coupon_target" CHECK (("product_id" IS NULL) <> ("category_id" IS NULL))
+);
+
+CREATE INDEX "idx_coupon_target_coupon" ON "public"."coupon_target" ("coupon_id");
+
+CREATE TABLE "public"."coupon_redemption" (
+ "id" serial8,
+ "coupon_id" int8 NOT NULL,
+ "customer_id" int8 NOT NULL,
+ "order_id" int8 NOT NULL,
+ "discount" float8 NOT NULL,
+"created_at" timestamptz(6) DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_coupon" FOREIGN KEY ("coupon_id") REFERENCES "public"."coupon" ("id"),
+  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "public"."customer" ("customer_id"),
+  CONSTRAINT "fk_order" FOREIGN KEY ("order_id") REFERENCES "public"."order" ("id")
+);
+
+CREATE INDEX "idx_coupon_redemption_customer" ON "public"."coupon_redemption" ("coupon_id", "customer_id");
+
+ALTER TABLE "public"."order" ADD COLUMN "subtotal" float8;
+ALTER TABLE "public"."order" ADD COLUMN "discount" float8 NOT NULL DEFAULT 0;
+ALTER TABLE "public"."order" ADD COLUMN "coupon_id" int8;
+ALTER TABLE "public"."order" ADD CONSTRAINT "fk_coupon" FOREIGN KEY ("coupon_id") REFERENCES "public"."coupon" ("id");
+
+UPDATE "public"."order" SET "subtotal" = "total_price";
+
+-- automatic promotions, evaluated by ascending priority
+CREATE TABLE "public"."promotion" (
+ "id" serial8,
+ "name" varchar(100) NOT NULL,
+ "rule_type" varchar(20) NOT NULL,
+ "priority" int4 NOT NULL DEFAULT 0,
+ "stackable" bool NOT NULL DEFAULT false,
+ "buy_quantity" int4,
+ "get_quantity" int4,
+ "bundle_price" float8,
+ "starts_at" timestamptz(6),
+ "ends_at" timestamptz(6),
+ "is_active" bool NOT NULL DEFAULT true,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50) DEFAULT 'system',
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "chk_promotion_rule_type" CHECK ("rule_type" IN ('buy_x_get_y', 'bundle', 'tiered'))
+);
+
+CREATE TABLE "public"."promotion_target" (
+ "promotion_id" int8 NOT NULL,
+ "product_id" int8,
+ "category_id" int8,
+  CONSTRAINT "fk_promotion" FOREIGN KEY ("promotion_id") REFERENCES "public"."promotion" ("id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id"),
+  CONSTRAINT "fk_category" FOREIGN KEY ("category_id") REFERENCES "public"."category" ("id"),
+  CONSTRAINT "chk_promotion_target" CHECK (("product_id" IS NULL) <> ("category_id" IS NULL))
+);
+
+CREATE INDEX "idx_promotion_target_promotion" ON "public"."promotion_target" ("promotion_id");
+
+CREATE TABLE "public"."promotion_tier" (
+ "promotion_id" int8 NOT NULL,
+ "min_total" float8 NOT NULL,
+ "discount_type" varchar(20) NOT NULL,
+ "discount_value" float8 NOT NULL,
+  CONSTRAINT "fk_promotion" FOREIGN KEY ("promotion_id") REFERENCES "public"."promotion" ("id")
+);
+
+CREATE INDEX "idx_promotion_tier_promotion" ON "public"."promotion_tier" ("promotion_id");
+
+CREATE TABLE "public"."order_promotion" (
+ "order_id" int8 NOT NULL,
+ "promotion_id" int8 NOT NULL,
+ "name" varchar(100) NOT NULL,
+ "discount" float8 NOT NULL,
+"created_at" timestamptz(6) DEFAULT now(),
+  CONSTRAINT "fk_order" FOREIGN KEY ("order_id") REFERENCES "public"."order" ("id"),
+  CONSTRAINT "fk_promotion" FOREIGN KEY ("promotion_id") REFERENCES "public"."promotion" ("id")
+);
+
+CREATE INDEX "idx_order_promotion_order" ON "public"."order_promotion" ("order_id");
+
+ALTER TABLE "public"."order" ADD COLUMN "promotion_discount" float8 NOT NULL DEFAULT 0;
+
+-- amounts are stored as whole minor units of the store currency, set
+-- store.currency to the conf currency before running. The exponents are the ones
+-- of pkg/money.
+SET store.currency = 'IDR';
+
+CREATE FUNCTION pg_temp.to_minor(amount float8) RETURNS int8 AS $$
+  SELECT round(amount * power(10, CASE
+    WHEN upper(current_setting('store.currency')) IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 3
+    WHEN upper(current_setting('store.currency')) IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 0
+    ELSE 2
+  END))::int8
+$$ LANGUAGE sql;
+
+ALTER TABLE "public"."product" ALTER COLUMN "price" TYPE int8 USING pg_temp.to_minor("price");
+ALTER TABLE "public"."product_variant" ALTER COLUMN "price" TYPE int8 USING pg_temp.to_minor("price");
+ALTER TABLE "public"."order" ALTER COLUMN "subtotal" TYPE int8 USING pg_temp.to_minor("subtotal");
+ALTER TABLE "public"."order" ALTER COLUMN "promotion_discount" TYPE int8 USING pg_temp.to_minor("promotion_discount");
+ALTER TABLE "public"."order" ALTER COLUMN "discount" TYPE int8 USING pg_temp.to_minor("discount");
+ALTER TABLE "public"."order" ALTER COLUMN "total_price" TYPE int8 USING pg_temp.to_minor("total_price");
+ALTER TABLE "public"."order_item" ALTER COLUMN "price" TYPE int8 USING pg_temp.to_minor("price");
+ALTER TABLE "public"."payment" ALTER COLUMN "amount" TYPE int8 USING pg_temp.to_minor("amount");
+ALTER TABLE "public"."coupon" ALTER COLUMN "max_discount" TYPE int8 USING pg_temp.to_minor("max_discount");
+ALTER TABLE "public"."coupon" ALTER COLUMN "min_spend" TYPE int8 USING pg_temp.to_minor("min_spend");
+ALTER TABLE "public"."coupon_redemption" ALTER COLUMN "discount" TYPE int8 USING pg_temp.to_minor("discount");
+ALTER TABLE "public"."promotion" ALTER COLUMN "bundle_price" TYPE int8 USING pg_temp.to_minor("bundle_price");
+ALTER TABLE "public"."promotion_tier" ALTER COLUMN "min_total" TYPE int8 USING pg_temp.to_minor("min_total");
+ALTER TABLE "public"."order_promotion" ALTER COLUMN "discount" TYPE int8 USING pg_temp.to_minor("discount");
+
+-- fixed discounts are amounts of their own, discount_value only keeps percentages
+ALTER TABLE "public"."coupon" ADD COLUMN "discount_amount" int8;
+ALTER TABLE "public"."coupon" ALTER COLUMN "discount_value" DROP NOT NULL;
+UPDATE "public"."coupon" SET "discount_amount" = pg_temp.to_minor("discount_value"), "discount_value" = NULL WHERE "discount_type" = 'fixed';
+ALTER TABLE "public"."coupon" ADD CONSTRAINT "chk_coupon_discount" CHECK (("discount_type" = 'percentage' AND "discount_value" IS NOT NULL AND "discount_amount" IS NULL) OR ("discount_type" = 'fixed' AND "discount_amount" IS NOT NULL AND "discount_value" IS NULL));
+ALTER TABLE "public"."promotion_tier" ADD COLUMN "discount_amount" int8;
+ALTER TABLE "public"."promotion_tier" ALTER COLUMN "discount_value" DROP NOT NULL;
+UPDATE "public"."promotion_tier" SET "discount_amount" = pg_temp.to_minor("discount_value"), "discount_value" = NULL WHERE "discount_type" = 'fixed';
+ALTER TABLE "public"."promotion_tier" ADD CONSTRAINT "chk_promotion_tier_discount" CHECK (("discount_type" = 'percentage' AND "discount_value" IS NOT NULL AND "discount_amount" IS NULL) OR ("discount_type" = 'fixed' AND "discount_amount" IS NOT NULL AND "discount_value" IS NULL));
+
+-- exchange rates of the display currencies, how much of the currency one unit of
+-- the base currency is worth
+CREATE TABLE "public"."exchange_rate" (
+ "currency" varchar(3) NOT NULL,
+ "rate" numeric(24,12) NOT NULL,
+"updated_at" timestamptz(6) DEFAULT now(),
+"updated_by" varchar(50),
+  PRIMARY KEY ("currency"),
+  CONSTRAINT "chk_exchange_rate_rate" CHECK ("rate" > 0)
+);
+
+ALTER TABLE "public"."order" ADD COLUMN "currency" varchar(3);
+ALTER TABLE "public"."order" ADD COLUMN "exchange_rate" numeric(24,12) NOT NULL DEFAULT 1;
+ALTER TABLE "public"."order" ADD COLUMN "charged_total" int8;
+UPDATE "public"."order" SET "currency" = upper(current_setting('store.currency')), "charged_total" = "total_price";
+ALTER TABLE "public"."order" ALTER COLUMN "currency" SET NOT NULL;
+ALTER TABLE "public"."order" ALTER COLUMN "charged_total" SET NOT NULL;
+ALTER TABLE "public"."payment" ADD COLUMN "currency" varchar(3);
+UPDATE "public"."payment" SET "currency" = upper(current_setting('store.currency'));
+ALTER TABLE "public"."payment" ALTER COLUMN "currency" SET NOT NULL;
+
+-- tax rates by product category and shipping region, the most specific one applies
+CREATE TABLE "public"."tax_rate" (
+ "id" serial8,
+ "name" varchar(100) NOT NULL,
+ "rate" numeric(7,4) NOT NULL,
+ "category_id" int8,
+ "region" varchar(50),
+ "is_active" bool NOT NULL DEFAULT true,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50),
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_category" FOREIGN KEY ("category_id") REFERENCES "public"."category" ("id"),
+  CONSTRAINT "chk_tax_rate_rate" CHECK ("rate" >= 0 AND "rate" <= 100)
+);
+
+CREATE TABLE "public"."order_tax" (
+ "order_id" int8 NOT NULL,
+ "tax_rate_id" int8 NOT NULL,
+ "name" varchar(100) NOT NULL,
+ "rate" numeric(7,4) NOT NULL,
+ "taxable_amount" int8 NOT NULL,
+ "tax_amount" int8 NOT NULL,
+"created_at" timestamptz(6) DEFAULT now(),
+  CONSTRAINT "fk_order" FOREIGN KEY ("order_id") REFERENCES "public"."order" ("id"),
+  CONSTRAINT "fk_tax_rate" FOREIGN KEY ("tax_rate_id") REFERENCES "public"."tax_rate" ("id")
+);
+
+CREATE INDEX "idx_order_tax_order" ON "public"."order_tax" ("order_id");
+
+ALTER TABLE "public"."order_item" ADD COLUMN "discount" int8 NOT NULL DEFAULT 0;
+ALTER TABLE "public"."order_item" ADD COLUMN "tax_rate_id" int8 REFERENCES "public"."tax_rate" ("id");
+ALTER TABLE "public"."order_item" ADD COLUMN "tax_rate" numeric(7,4) NOT NULL DEFAULT 0;
+ALTER TABLE "public"."order_item" ADD COLUMN "tax_amount" int8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "public"."order" ADD COLUMN "tax_mode" varchar(20) NOT NULL DEFAULT 'inclusive';
+ALTER TABLE "public"."order" ADD COLUMN "tax_total" int8 NOT NULL DEFAULT 0;
+ALTER TABLE "public"."order" ADD COLUMN "region" varchar(50);
+
+-- weight in grams and dimensions in millimeters of the products, for shipping
+ALTER TABLE "public"."product" ADD COLUMN "weight" int4 NOT NULL DEFAULT 0;
+ALTER TABLE "public"."product" ADD COLUMN "length" int4 NOT NULL DEFAULT 0;
+ALTER TABLE "public"."product" ADD COLUMN "width" int4 NOT NULL DEFAULT 0;
+ALTER TABLE "public"."product" ADD COLUMN "height" int4 NOT NULL DEFAULT 0;
+
+-- shipping zones, a region is shipped to with the methods of its zone
+CREATE TABLE "public"."shipping_zone" (
+ "id" serial8,
+ "name" varchar(100) NOT NULL,
+ "is_active" bool NOT NULL DEFAULT true,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50),
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id")
+);
+
+CREATE TABLE "public"."shipping_zone_region" (
+ "zone_id" int8 NOT NULL,
+ "region" varchar(50) NOT NULL,
+  PRIMARY KEY ("region"),
+  CONSTRAINT "fk_shipping_zone" FOREIGN KEY ("zone_id") REFERENCES "public"."shipping_zone" ("id")
+);
+
+CREATE INDEX "idx_shipping_zone_region_zone" ON "public"."shipping_zone_region" ("zone_id");
+
+CREATE TABLE "public"."shipping_method" (
+ "id" serial8,
+ "zone_id" int8 NOT NULL,
+ "name" varchar(100) NOT NULL,
+ "rate_type" varchar(20) NOT NULL,
+ "rate" int8 NOT NULL DEFAULT 0,
+ "per_kg_rate" int8 NOT NULL DEFAULT 0,
+ "carrier" varchar(50),
+ "carrier_service" varchar(50),
+ "free_over" int8,
+ "estimated_days" int4,
+ "is_active" bool NOT NULL DEFAULT true,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50),
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_shipping_zone" FOREIGN KEY ("zone_id") REFERENCES "public"."shipping_zone" ("id"),
+  CONSTRAINT "chk_shipping_method_rate_type" CHECK ("rate_type" IN ('flat', 'weight', 'carrier'))
+);
+
+ALTER TABLE "public"."order" ADD COLUMN "shipping_method_id" int8 REFERENCES "public"."shipping_method" ("id");
+ALTER TABLE "public"."order" ADD COLUMN "shipping_method" varchar(100);
+ALTER TABLE "public"."order" ADD COLUMN "shipping_cost" int8 NOT NULL DEFAULT 0;
+ALTER TABLE "public"."order" ADD COLUMN "shipping_address" varchar(255);
+ALTER TABLE "public"."order" ADD COLUMN "shipping_city" varchar(100);
+ALTER TABLE "public"."order" ADD COLUMN "shipping_postal_code" varchar(20);
+
+-- order lifecycle, pending until paid then shipped and delivered by its shipments
+ALTER TABLE "public"."order" ADD COLUMN "status" varchar(20) NOT NULL DEFAULT 'pending';
+UPDATE "public"."order" SET "status" = 'paid' WHERE "payment_id" IS NOT NULL;
+
+-- shipments of the orders, an order may ship in several
+CREATE TABLE "public"."shipment" (
+ "id" serial8,
+ "order_id" int8 NOT NULL,
+ "carrier" varchar(50) NOT NULL,
+ "tracking_number" varchar(100),
+ "status" varchar(20) NOT NULL,
+ "shipped_at" timestamptz(6),
+ "delivered_at" timestamptz(6),
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50),
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_order" FOREIGN KEY ("order_id") REFERENCES "public"."order" ("id"),
+  CONSTRAINT "chk_shipment_status" CHECK ("status" IN ('pending', 'shipped', 'in_transit', 'delivered', 'cancelled'))
+);
+
+CREATE INDEX "idx_shipment_order" ON "public"."shipment" ("order_id");
+
+CREATE TABLE "public"."shipment_item" (
+ "shipment_id" int8 NOT NULL,
+ "order_item_id" int8 NOT NULL,
+ "quantity" int4 NOT NULL,
+  PRIMARY KEY ("shipment_id", "order_item_id"),
+  CONSTRAINT "fk_shipment" FOREIGN KEY ("shipment_id") REFERENCES "public"."shipment" ("id"),
+  CONSTRAINT "fk_order_item" FOREIGN KEY ("order_item_id") REFERENCES "public"."order_item" ("id"),
+  CONSTRAINT "chk_shipment_item_quantity" CHECK ("quantity" > 0)
+);
+
+-- returns of delivered order items, every step is recorded in return_event
+CREATE TABLE "public"."return_request" (
+ "id" serial8,
+ "order_id" int8 NOT NULL,
+ "customer_id" int8 NOT NULL,
+ "status" varchar(20) NOT NULL,
+ "refund_amount" int8 NOT NULL DEFAULT 0,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50),
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_order" FOREIGN KEY ("order_id") REFERENCES "public"."order" ("id"),
+  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "public"."customer" ("id"),
+  CONSTRAINT "chk_return_request_status" CHECK ("status" IN ('requested', 'approved', 'rejected', 'received', 'completed'))
+);
+
+CREATE INDEX "idx_return_request_order" ON "public"."return_request" ("order_id");
+CREATE INDEX "idx_return_request_customer" ON "public"."return_request" ("customer_id");
+
+CREATE TABLE "public"."return_item" (
+ "return_id" int8 NOT NULL,
+ "order_item_id" int8 NOT NULL,
+ "quantity" int4 NOT NULL,
+ "reason" varchar(30) NOT NULL,
+ "note" varchar(255),
+ "refund_amount" int8 NOT NULL DEFAULT 0,
+ "outcome" varchar(20),
+ "warehouse_id" int8,
+  PRIMARY KEY ("return_id", "order_item_id"),
+  CONSTRAINT "fk_return_request" FOREIGN KEY ("return_id") REFERENCES "public"."return_request" ("id"),
+  CONSTRAINT "fk_order_item" FOREIGN KEY ("order_item_id") REFERENCES "public"."order_item" ("id"),
+  CONSTRAINT "fk_warehouse" FOREIGN KEY ("warehouse_id") REFERENCES "public"."warehouse" ("id"),
+  CONSTRAINT "chk_return_item_quantity" CHECK ("quantity" > 0),
+  CONSTRAINT "chk_return_item_outcome" CHECK ("outcome" IN ('restock', 'write_off'))
+);
+
+CREATE TABLE "public"."return_event" (
+ "id" serial8,
+ "return_id" int8 NOT NULL,
+ "status" varchar(20) NOT NULL,
+ "actor" varchar(50) NOT NULL,
+ "note" varchar(255),
+ "created_at" timestamptz(6) NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_return_request" FOREIGN KEY ("return_id") REFERENCES "public"."return_request" ("id")
+);
+
+CREATE INDEX "idx_return_event_return" ON "public"."return_event" ("return_id");
+
+-- refunds of the approved returns, amount in the base currency and charged_amount
+-- in the currency the order was charged in
+CREATE TABLE "public"."refund" (
+ "id" serial8,
+ "return_id" int8 NOT NULL,
+ "order_id" int8 NOT NULL,
+ "payment_id" int8,
+ "amount" int8 NOT NULL,
+ "currency" varchar(3) NOT NULL,
+ "charged_amount" int8 NOT NULL,
+ "status" varchar(50) NOT NULL,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_return_request" FOREIGN KEY ("return_id") REFERENCES "public"."return_request" ("id"),
+  CONSTRAINT "fk_order" FOREIGN KEY ("order_id") REFERENCES "public"."order" ("id"),
+  CONSTRAINT "fk_payment" FOREIGN KEY ("payment_id") REFERENCES "public"."payment" ("id"),
+  CONSTRAINT "uq_refund_return" UNIQUE ("return_id")
+);
+
+-- invoices of the paid orders, numbered without gaps within each year
+CREATE TABLE "public"."invoice_sequence" (
+ "year" int4 NOT NULL,
+ "last_number" int4 NOT NULL,
+  PRIMARY KEY ("year")
+);
+
+CREATE TABLE "public"."invoice" (
+ "id" serial8,
+ "order_id" int8 NOT NULL,
+ "number" varchar(50) NOT NULL,
+ "year" int4 NOT NULL,
+ "sequence" int4 NOT NULL,
+ "issued_at" timestamptz(6) NOT NULL,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_order" FOREIGN KEY ("order_id") REFERENCES "public"."order" ("id"),
+  CONSTRAINT "uq_invoice_order" UNIQUE ("order_id"),
+  CONSTRAINT "uq_invoice_number" UNIQUE ("year", "sequence")
+);
+
+-- product reviews, only approved reviews are listed and counted in the rating of the product
+CREATE TABLE "public"."review" (
+ "id" serial8,
+ "product_id" int8 NOT NULL,
+ "customer_id" int8 NOT NULL,
+ "rating" int2 NOT NULL,
+ "title" varchar(100) NOT NULL,
+ "body" text NOT NULL,
+ "status" varchar(20) NOT NULL,
+ "helpful_count" int4 NOT NULL DEFAULT 0,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50),
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id"),
+  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "public"."customer" ("customer_id"),
+  CONSTRAINT "uq_review_customer_product" UNIQUE ("product_id", "customer_id"),
+  CONSTRAINT "chk_review_rating" CHECK ("rating" BETWEEN 1 AND 5)
+);
+
+CREATE INDEX "idx_review_product_status" ON "public"."review" ("product_id", "status");
+
+-- a customer finds a review helpful once
+CREATE TABLE "public"."review_vote" (
+ "review_id" int8 NOT NULL,
+ "customer_id" int8 NOT NULL,
+ "created_at" timestamptz(6) NOT NULL DEFAULT now(),
+  PRIMARY KEY ("review_id", "customer_id"),
+  CONSTRAINT "fk_review" FOREIGN KEY ("review_id") REFERENCES "public"."review" ("id"),
+  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "public"."customer" ("customer_id")
+);
+
+ALTER TABLE "public"."product" ADD COLUMN "rating_average" numeric(3,2) NOT NULL DEFAULT 0;
+ALTER TABLE "public"."product" ADD COLUMN "rating_count" int4 NOT NULL DEFAULT 0;
+
+-- products saved by the customers for later, with their price when saved to show price drops
+CREATE TABLE "public"."wishlist" (
+ "id" serial8,
+ "customer_id" int8 NOT NULL,
+ "product_id" int8 NOT NULL,
+ "variant_id" int8,
+ "added_price" int8 NOT NULL,
+"created_at" timestamptz(6) DEFAULT now(),
+  "created_by" varchar(50),
+  "updated_at" timestamptz(6),
+  "updated_by" varchar(50),
+  "deleted_at" timestamptz(6),
+  "deleted_by" varchar(50),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "public"."customer" ("customer_id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id"),
+  CONSTRAINT "fk_product_variant" FOREIGN KEY ("variant_id", "product_id") REFERENCES "public"."product_variant" ("id", "product_id")
+);
+
+CREATE UNIQUE INDEX "uq_wishlist" ON "public"."wishlist" ("customer_id", "product_id", (COALESCE("variant_id", 0))) WHERE "deleted_at" IS NULL;
+
+-- recommendations, refreshed by a background job from the items of the paid orders.
+-- product_related keeps the products bought most often with each product, score being
+-- the number of orders with both
+CREATE TABLE "public"."product_related" (
+ "product_id" int8 NOT NULL,
+ "related_product_id" int8 NOT NULL,
+ "score" int4 NOT NULL,
+ "updated_at" timestamptz(6) NOT NULL DEFAULT now(),
+  PRIMARY KEY ("product_id", "related_product_id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id"),
+  CONSTRAINT "fk_related_product" FOREIGN KEY ("related_product_id") REFERENCES "public"."product" ("id")
+);
+
+-- the best selling products of each category, the fallback when a product has too few
+-- orders to have related products
+CREATE TABLE "public"."category_bestseller" (
+ "category_id" int8 NOT NULL,
+ "product_id" int8 NOT NULL,
+ "sold" int8 NOT NULL,
+ "updated_at" timestamptz(6) NOT NULL DEFAULT now(),
+  PRIMARY KEY ("category_id", "product_id"),
+  CONSTRAINT "fk_category" FOREIGN KEY ("category_id") REFERENCES "public"."category" ("id"),
+  CONSTRAINT "fk_product" FOREIGN KEY ("product_id") REFERENCES "public"."product" ("id")
+);
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+var (
+	ErrInvalidCursor = errors.New("cursor is invalid")
+
+	cursorSecret []byte
+)
+
+func init() {
+	// random fallback, cursors then only survive until the process restarts
+	cursorSecret = make([]byte, 32)
+	if _, err := rand.Read(cursorSecret); err != nil {
+		panic(err)
+	}
+}
+
+// SetCursorSecret sets the key used to sign cursors. Every instance serving the
+// same endpoints must share it for cursors to stay valid across instances.
+func SetCursorSecret(secret []byte) {
+	if len(secret) > 0 {
+		cursorSecret = secret
+	}
+}
+
+// CursorKey is one column of the keyset used by cursor pagination.
+// Column is the name of the column in the result of the raw query and Field the
+// struct field of the records holding its value. The last key must be unique.
+type CursorKey struct {
+	Column string
+	Field  string
+	Desc   bool
+}
+
+type cursorPayload struct {
+	Direction string            `json:"d"`
+	Columns   string            `json:"c"`
+	Values    []json.RawMessage `json:"v"`
+}
+
+func cursorColumns(keys []CursorKey) string {
+	columns := make([]string, 0, len(keys))
+	for _, v := range keys {
+		column := v.Column
+		if v.Desc {
+			column += " desc"
+		}
+		columns = append(columns, column)
+	}
+	return strings.Join(columns, ",")
+}
+
+func signCursor(payload []byte) string {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encodeCursor builds an opaque cursor pointing at record in the given direction.
+func encodeCursor(direction string, keys []CursorKey, record reflect.Value) (string, error) {
+	payload := cursorPayload{Direction: direction, Columns: cursorColumns(keys)}
+	for _, v := range keys {
+		field := record.FieldByName(v.Field)
+		if !field.IsValid() {
+			return "", errors.New("cursor field " + v.Field + " not found")
+		}
+		value, err := json.Marshal(field.Interface())
+		if err != nil {
+			return "", err
+		}
+		payload.Values = append(payload.Values, value)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw) + "." + signCursor(raw), nil
+}
+
+// decodeCursor verifies the cursor signature and decodes the key values into the
+// types of the record fields, so they bind to the query with their original type.
+func decodeCursor(cursor string, keys []CursorKey, recordType reflect.Type) (string, []interface{}, error) {
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return "", nil, ErrInvalidCursor
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", nil, ErrInvalidCursor
+	}
+	if !hmac.Equal([]byte(signCursor(raw)), []byte(parts[1])) {
+		return "", nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return "", nil, ErrInvalidCursor
+	}
+	if payload.Columns != cursorColumns(keys) || len(payload.Values) != len(keys) {
+		return "", nil, ErrInvalidCursor
+	}
+	if payload.Direction != cursorNext && payload.Direction != cursorPrev {
+		return "", nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, 0, len(keys))
+	for i, v := range keys {
+		field, ok := recordType.FieldByName(v.Field)
+		if !ok {
+			return "", nil, ErrInvalidCursor
+		}
+		value := reflect.New(field.Type)
+		if err := json.Unmarshal(payload.Values[i], value.Interface()); err != nil {
+			return "", nil, ErrInvalidCursor
+		}
+		values = append(values, value.Elem().Interface())
+	}
+
+	return payload.Direction, values, nil
+}
+
+// keysetCondition builds the row comparison selecting the rows after the cursor
+// in the key order, expanded so every key can have its own direction:
+// (a > ?) OR (a = ? AND b > ?) ...
+func keysetCondition(keys []CursorKey, values []interface{}, backward bool) (string, []interface{}) {
+	var (
+		conditions []string
+		vars       []interface{}
+	)
+	for i := range keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].Column+" = ?")
+			vars = append(vars, values[j])
+		}
+
+		operator := ">"
+		if keys[i].Desc != backward {
+			operator = "<"
+		}
+		parts = append(parts, keys[i].Column+" "+operator+" ?")
+		vars = append(vars, values[i])
+
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", vars
+}
+
+func keysetOrderBy(keys []CursorKey, backward bool) string {
+	columns := make([]string, 0, len(keys))
+	for _, v := range keys {
+		direction := "ASC"
+		if v.Desc != backward {
+			direction = "DESC"
+		}
+		columns = append(columns, v.Column+" "+direction)
+	}
+	return "ORDER BY " + strings.Join(columns, ", ")
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	keys := []CursorKey{{Column: "bucket", Field: "Bucket"}, {Column: "id", Field: "ID", Desc: true}}
+	values := []interface{}{1, 7}
+
+	tests := []struct {
+		name      string
+		backward  bool
+		condition string
+		vars      []interface{}
+		orderBy   string
+	}{
+		{
+			name:      "forward",
+			condition: "((bucket > ?) OR (bucket = ? AND id < ?))",
+			vars:      []interface{}{1, 1, 7},
+			orderBy:   "ORDER BY bucket ASC, id DESC",
+		},
+		{
+			name:      "backward",
+			backward:  true,
+			condition: "((bucket < ?) OR (bucket = ? AND id > ?))",
+			vars:      []interface{}{1, 1, 7},
+			orderBy:   "ORDER BY bucket DESC, id ASC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, vars := keysetCondition(keys, values, tt.backward)
+			if condition != tt.condition {
+				t.Errorf("keysetCondition() = %q, want %q", condition, tt.condition)
+			}
+			if !reflect.DeepEqual(vars, tt.vars) {
+				t.Errorf("keysetCondition() vars = %v, want %v", vars, tt.vars)
+			}
+			if orderBy := keysetOrderBy(keys, tt.backward); orderBy != tt.orderBy {
+				t.Errorf("keysetOrderBy() = %q, want %q", orderBy, tt.orderBy)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	keys := []CursorKey{{Column: "bucket", Field: "Bucket"}, {Column: "id", Field: "ID", Desc: true}}
+	recordType := reflect.TypeOf(testRecord{})
+
+	cursor, err := encodeCursor(cursorPrev, keys, reflect.ValueOf(testRecord{ID: 7, Bucket: 1}))
+	if err != nil {
+		t.Fatalf("encodeCursor() error = %v", err)
+	}
+
+	direction, values, err := decodeCursor(cursor, keys, recordType)
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if direction != cursorPrev || !reflect.DeepEqual(values, []interface{}{1, 7}) {
+		t.Errorf("decodeCursor() = %s %v, want %s [1 7]", direction, values, cursorPrev)
+	}
+
+	otherKeys, err := encodeCursor(cursorNext, keys[1:], reflect.ValueOf(testRecord{ID: 7}))
+	if err != nil {
+		t.Fatalf("encodeCursor() error = %v", err)
+	}
+
+	for name, v := range map[string]string{
+		"tampered":      cursor + "x",
+		"unsigned":      cursor[:len(cursor)-1],
+		"other keys":    otherKeys,
+		"not a cursor":  "cursor",
+		"empty payload": "." + signCursor(nil),
+	} {
+		if _, _, err := decodeCursor(v, keys, recordType); err != ErrInvalidCursor {
+			t.Errorf("decodeCursor(%s) error = %v, want %v", name, err, ErrInvalidCursor)
+		}
+	}
+}
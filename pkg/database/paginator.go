@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"math"
+	"reflect"
 	"strconv"
+	"time"
 
 	"gorm.io/gorm/clause"
 
@@ -13,7 +15,9 @@ import (
 type (
 	PaginatorInterface interface {
 		Raw(query string, vars []interface{}, countQuery string, countVars []interface{}) *Paginator
-		UpdatePageInfo(ctx context.Context)
+		Cursor(cursor string, keys ...CursorKey) *Paginator
+		Timeout(timeout time.Duration) *Paginator
+		UpdatePageInfo(ctx context.Context) error
 		Find(ctx context.Context) *gorm.DB
 		FindWithOrderBy(ctx context.Context, orderBy string) *gorm.DB
 	}
@@ -21,6 +25,9 @@ type (
 
 // Paginator structure containing pagination information and result records.
 // Can be sent to the client directly.
+//
+// In offset mode `MaxPage`, `Total` and `CurrentPage` are filled, in cursor mode
+// (see Cursor) `NextCursor` and `PrevCursor` are filled instead.
 type Paginator struct {
 	DB *gorm.DB `json:"-"`
 
@@ -31,14 +38,29 @@ type Paginator struct {
 	rawCountQuery     string
 	rawCountQueryVars []interface{}
 
-	MaxPage     int64 `json:"max_page"`
-	Total       int64 `json:"total"`
-	PageSize    int   `json:"page_size"`
-	CurrentPage int   `json:"current_page"`
+	MaxPage     *int64 `json:"max_page,omitempty"`
+	Total       *int64 `json:"total,omitempty"`
+	PageSize    int    `json:"page_size"`
+	CurrentPage int    `json:"current_page,omitempty"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	PrevCursor  string `json:"prev_cursor,omitempty"`
+
+	cursor     string
+	cursorKeys []CursorKey
+	timeout    time.Duration
 
 	loadedPageInfo bool
 }
 
+// defaultTimeout is the Timeout of every new Paginator.
+var defaultTimeout time.Duration
+
+// SetPaginatorTimeout bounds the queries of every Paginator created afterwards,
+// usually to the execution timeout of the handlers. Zero means no bound.
+func SetPaginatorTimeout(timeout time.Duration) {
+	defaultTimeout = timeout
+}
+
 func paginateScope(page, pageSize int) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		offset := (page - 1) * pageSize
@@ -59,12 +81,13 @@ func paginateScope(page, pageSize int) func(db *gorm.DB) *gorm.DB {
 //	    response.JSON(http.StatusOK, paginator)
 //	}
 func NewPaginator(db *gorm.DB, page, pageSize int, dest interface{}) PaginatorInterface {
-	return &Paginator{
+	p := &Paginator{
 		DB:          db,
 		CurrentPage: page,
 		PageSize:    pageSize,
 		Records:     dest,
 	}
+	return p.Timeout(defaultTimeout)
 }
 
 // Raw set a raw SQL query and count query.
@@ -75,12 +98,42 @@ func (p *Paginator) Raw(query string, vars []interface{}, countQuery string, cou
 	p.rawQuery = query
 	p.rawQueryVars = vars
 	p.rawCountQuery = countQuery
-	p.rawCountQueryVars = vars
+	p.rawCountQueryVars = countVars
+	return p
+}
+
+// Timeout bounds the queries run by Find and FindWithOrderBy, on top of any
+// deadline already carried by their context. Zero means no extra bound.
+func (p *Paginator) Timeout(timeout time.Duration) *Paginator {
+	p.timeout = timeout
+	return p
+}
+
+func (p *Paginator) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.timeout > 0 {
+		return context.WithTimeout(ctx, p.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Cursor switches the Paginator to keyset pagination on a raw query. Instead of
+// OFFSET and a COUNT query, rows are selected after (or before) the signed cursor
+// returned by a previous page, ordered by keys. An empty cursor fetches the first page.
+// The raw query is wrapped in a sub query so keys refer to its result columns.
+// The order by given to FindWithOrderBy is ignored in this mode.
+//
+//	paginator := database.NewPaginator(db, 0, pageSize, &products).
+//		Raw(query, args, "", nil).
+//		Cursor(cursor, database.CursorKey{Column: "created_at", Field: "CreatedAt", Desc: true}, database.CursorKey{Column: "id", Field: "ID", Desc: true})
+func (p *Paginator) Cursor(cursor string, keys ...CursorKey) *Paginator {
+	p.cursor = cursor
+	p.cursorKeys = keys
+	p.CurrentPage = 0
 	return p
 }
 
 // UpdatePageInfo executes count request to calculate the `Total` and `MaxPage`.
-func (p *Paginator) UpdatePageInfo(ctx context.Context) {
+func (p *Paginator) UpdatePageInfo(ctx context.Context) error {
 	count := int64(0)
 	db := p.DB.WithContext(ctx).Session(&gorm.Session{})
 	prevPreloads := db.Statement.Preloads
@@ -97,37 +150,125 @@ func (p *Paginator) UpdatePageInfo(ctx context.Context) {
 		err = db.Model(p.Records).Count(&count).Error
 	}
 	if err != nil {
-		panic(err)
+		return err
 	}
-	p.Total = count
-	p.MaxPage = int64(math.Ceil(float64(count) / float64(p.PageSize)))
-	if p.MaxPage == 0 {
-		p.MaxPage = 1
+	maxPage := int64(math.Ceil(float64(count) / float64(p.PageSize)))
+	if maxPage == 0 {
+		maxPage = 1
 	}
+	p.Total = &count
+	p.MaxPage = &maxPage
 	p.loadedPageInfo = true
+	return nil
 }
 
 // Find requests page information (total records and max page) and
 // executes the transaction. The Paginate struct is updated automatically, as
 // well as the destination slice given in NewPaginator().
+// A failing count query is reported through the returned DB's Error.
 func (p *Paginator) Find(ctx context.Context) *gorm.DB {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	if len(p.cursorKeys) > 0 {
+		return p.findCursor(ctx)
+	}
 	if !p.loadedPageInfo {
-		p.UpdatePageInfo(ctx)
+		if err := p.UpdatePageInfo(ctx); err != nil {
+			db := p.DB.WithContext(ctx)
+			db.AddError(err)
+			return db
+		}
 	}
 	if p.rawQuery != "" {
 		return p.rawStatement(ctx).Scan(p.Records)
 	}
-	return p.DB.Scopes(paginateScope(p.CurrentPage, p.PageSize)).Find(p.Records)
+	return p.DB.WithContext(ctx).Scopes(paginateScope(p.CurrentPage, p.PageSize)).Find(p.Records)
 }
 
 func (p *Paginator) FindWithOrderBy(ctx context.Context, orderBy string) *gorm.DB {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	if len(p.cursorKeys) > 0 {
+		return p.findCursor(ctx)
+	}
 	if !p.loadedPageInfo {
-		p.UpdatePageInfo(ctx)
+		if err := p.UpdatePageInfo(ctx); err != nil {
+			db := p.DB.WithContext(ctx)
+			db.AddError(err)
+			return db
+		}
 	}
 	if p.rawQuery != "" {
 		return p.rawStatementCustomOrderBy(ctx, orderBy).Scan(p.Records)
 	}
-	return p.DB.Scopes(paginateScope(p.CurrentPage, p.PageSize)).Find(p.Records)
+	return p.DB.WithContext(ctx).Scopes(paginateScope(p.CurrentPage, p.PageSize)).Find(p.Records)
+}
+
+// findCursor fetches one page after the cursor plus one extra row telling
+// whether another page exists, then builds the cursors of the neighbour pages.
+func (p *Paginator) findCursor(ctx context.Context) *gorm.DB {
+	recordType := reflect.Indirect(reflect.ValueOf(p.Records)).Type().Elem()
+	if recordType.Kind() == reflect.Ptr {
+		recordType = recordType.Elem()
+	}
+
+	backward := false
+	query := "SELECT * FROM (" + p.rawQuery + ") AS cursor_page"
+	vars := append([]interface{}{}, p.rawQueryVars...)
+	if p.cursor != "" {
+		direction, values, err := decodeCursor(p.cursor, p.cursorKeys, recordType)
+		if err != nil {
+			db := p.DB.WithContext(ctx)
+			db.AddError(err)
+			return db
+		}
+		backward = direction == cursorPrev
+
+		condition, conditionVars := keysetCondition(p.cursorKeys, values, backward)
+		query += " WHERE " + condition
+		vars = append(vars, conditionVars...)
+	}
+	query += " " + keysetOrderBy(p.cursorKeys, backward) + " LIMIT " + strconv.Itoa(p.PageSize+1)
+
+	db := p.DB.WithContext(ctx).Raw(query, vars...).Scan(p.Records)
+	if db.Error != nil {
+		return db
+	}
+
+	records := reflect.Indirect(reflect.ValueOf(p.Records))
+	hasMore := records.Len() > p.PageSize
+	if hasMore {
+		records.Set(records.Slice(0, p.PageSize))
+	}
+	if backward {
+		swap := reflect.Swapper(records.Interface())
+		for i, j := 0, records.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	p.NextCursor, p.PrevCursor = "", ""
+	if records.Len() == 0 {
+		return db
+	}
+
+	var err error
+	if hasMore || backward {
+		if p.NextCursor, err = encodeCursor(cursorNext, p.cursorKeys, reflect.Indirect(records.Index(records.Len()-1))); err != nil {
+			db.AddError(err)
+			return db
+		}
+	}
+	if (hasMore && backward) || (!backward && p.cursor != "") {
+		if p.PrevCursor, err = encodeCursor(cursorPrev, p.cursorKeys, reflect.Indirect(records.Index(0))); err != nil {
+			db.AddError(err)
+			return db
+		}
+	}
+
+	return db
 }
 
 func (p *Paginator) rawStatement(ctx context.Context) *gorm.DB {
@@ -0,0 +1,351 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDSNEnv names the connection string of the Postgres the tests run against,
+// usually the one of docker-compose.yml. The tests are skipped without it.
+const testDSNEnv = "TEST_DATABASE_URL"
+
+// testItems is the number of rows of the item table, "record" ones have an odd
+// id and "other" ones an even id.
+const testItems = 25
+
+type testRecord struct {
+	ID     int    `gorm:"column:id"`
+	Name   string `gorm:"column:name"`
+	Bucket int    `gorm:"column:bucket"`
+}
+
+// openTestDatabase opens a transaction on the test database holding a temporary
+// item table, rolled back once the test ends.
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	tx := conn.Begin()
+	if tx.Error != nil {
+		t.Fatalf("Begin() error = %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	// CREATE TABLE AS takes no bind parameter
+	err = tx.Exec(fmt.Sprintf(`CREATE TEMPORARY TABLE item ON COMMIT DROP AS 
+				SELECT id, CASE WHEN id %% 2 = 1 THEN 'record' ELSE 'other' END AS name 
+				FROM generate_series(1, %d) AS id`, testItems)).Error
+	if err != nil {
+		t.Fatalf("create item error = %v", err)
+	}
+	return tx
+}
+
+func recordIDs(records []testRecord) []int {
+	ids := make([]int, 0, len(records))
+	for _, v := range records {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+func TestPaginatorFind(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       int
+		pageSize   int
+		query      string
+		args       []interface{}
+		countQuery string
+		countArgs  []interface{}
+		orderBy    string
+		timeout    time.Duration
+		total      int64
+		maxPage    int64
+		records    []int
+		failed     bool
+		deadline   bool
+	}{
+		{
+			name:       "first page",
+			page:       1,
+			pageSize:   5,
+			query:      "SELECT id, name FROM item WHERE name = ? ORDER BY id",
+			args:       []interface{}{"record"},
+			countQuery: "SELECT COUNT(*) FROM item WHERE name = ?",
+			countArgs:  []interface{}{"record"},
+			total:      13,
+			maxPage:    3,
+			records:    []int{1, 3, 5, 7, 9},
+		},
+		{
+			name:       "last page is partial",
+			page:       3,
+			pageSize:   5,
+			query:      "SELECT id, name FROM item WHERE name = ? ORDER BY id",
+			args:       []interface{}{"record"},
+			countQuery: "SELECT COUNT(*) FROM item WHERE name = ?",
+			countArgs:  []interface{}{"record"},
+			total:      13,
+			maxPage:    3,
+			records:    []int{21, 23, 25},
+		},
+		{
+			name:       "no record still has a page",
+			page:       1,
+			pageSize:   5,
+			query:      "SELECT id, name FROM item WHERE name = ? ORDER BY id",
+			args:       []interface{}{"none"},
+			countQuery: "SELECT COUNT(*) FROM item WHERE name = ?",
+			countArgs:  []interface{}{"none"},
+			total:      0,
+			maxPage:    1,
+			records:    []int{},
+		},
+		{
+			name:       "count arguments are their own",
+			page:       1,
+			pageSize:   5,
+			query:      "SELECT id, name FROM item WHERE name = ? AND id > ? ORDER BY id",
+			args:       []interface{}{"record", 20},
+			countQuery: "SELECT COUNT(*) FROM (SELECT id FROM item WHERE name = ? AND id > ?) AS c",
+			countArgs:  []interface{}{"record", 20},
+			total:      3,
+			maxPage:    1,
+			records:    []int{21, 23, 25},
+		},
+		{
+			name:       "count sub query",
+			page:       2,
+			pageSize:   2,
+			query:      "SELECT MIN(id) AS id, name FROM item GROUP BY name, id % 5 ORDER BY 1",
+			countQuery: "SELECT COUNT(*) FROM (SELECT 1 FROM item GROUP BY name, id % 5) AS c",
+			total:      10,
+			maxPage:    5,
+			records:    []int{3, 4},
+		},
+		{
+			name:       "custom order by fetches the page",
+			page:       2,
+			pageSize:   5,
+			query:      "SELECT id, name FROM item WHERE name = ?",
+			args:       []interface{}{"record"},
+			countQuery: "SELECT COUNT(*) FROM item WHERE name = ?",
+			countArgs:  []interface{}{"record"},
+			orderBy:    "ORDER BY id DESC",
+			total:      13,
+			maxPage:    3,
+			records:    []int{15, 13, 11, 9, 7},
+		},
+		{
+			name:       "custom order by past the last page",
+			page:       4,
+			pageSize:   5,
+			query:      "SELECT id, name FROM item WHERE name = ?",
+			args:       []interface{}{"record"},
+			countQuery: "SELECT COUNT(*) FROM item WHERE name = ?",
+			countArgs:  []interface{}{"record"},
+			orderBy:    "ORDER BY id DESC",
+			total:      13,
+			maxPage:    3,
+			records:    []int{},
+		},
+		{
+			name:       "failing count is returned",
+			page:       1,
+			pageSize:   5,
+			query:      "SELECT id, name FROM item ORDER BY id",
+			countQuery: "SELECT COUNT(*) FROM missing_item",
+			failed:     true,
+		},
+		{
+			name:       "timeout bounds the queries",
+			page:       1,
+			pageSize:   5,
+			query:      "SELECT id, name FROM item ORDER BY id",
+			countQuery: "SELECT COUNT(*) FROM item, pg_sleep(1)",
+			timeout:    50 * time.Millisecond,
+			deadline:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := openTestDatabase(t)
+
+			var records []testRecord
+			paginator := NewPaginator(conn, tt.page, tt.pageSize, &records).
+				Raw(tt.query, tt.args, tt.countQuery, tt.countArgs).
+				Timeout(tt.timeout)
+
+			var result *gorm.DB
+			if tt.orderBy != "" {
+				result = paginator.FindWithOrderBy(context.Background(), tt.orderBy)
+			} else {
+				result = paginator.Find(context.Background())
+			}
+
+			switch {
+			case tt.deadline:
+				if !errors.Is(result.Error, context.DeadlineExceeded) {
+					t.Fatalf("Find() error = %v, want %v", result.Error, context.DeadlineExceeded)
+				}
+				return
+			case tt.failed:
+				if result.Error == nil {
+					t.Fatal("Find() error = nil, want the count error")
+				}
+				if records != nil {
+					t.Errorf("records = %v, want none", recordIDs(records))
+				}
+				return
+			case result.Error != nil:
+				t.Fatalf("Find() error = %v", result.Error)
+			}
+
+			if paginator.Total == nil || *paginator.Total != tt.total {
+				t.Errorf("Total = %v, want %d", paginator.Total, tt.total)
+			}
+			if paginator.MaxPage == nil || *paginator.MaxPage != tt.maxPage {
+				t.Errorf("MaxPage = %v, want %d", paginator.MaxPage, tt.maxPage)
+			}
+			if got := recordIDs(records); !reflect.DeepEqual(got, tt.records) {
+				t.Errorf("records = %v, want %v", got, tt.records)
+			}
+		})
+	}
+}
+
+func TestPaginatorDefaultTimeout(t *testing.T) {
+	conn := openTestDatabase(t)
+
+	SetPaginatorTimeout(50 * time.Millisecond)
+	defer SetPaginatorTimeout(0)
+
+	var records []testRecord
+	result := NewPaginator(conn, 1, 10, &records).
+		Raw("SELECT id, name FROM item ORDER BY id", nil, "SELECT COUNT(*) FROM item, pg_sleep(1)", nil).
+		Find(context.Background())
+	if !errors.Is(result.Error, context.DeadlineExceeded) {
+		t.Fatalf("Find() error = %v, want %v", result.Error, context.DeadlineExceeded)
+	}
+}
+
+// cursorQuery selects the "record" items with a bucket shared by several of them,
+// the keys refer to the bucket alias through the sub query wrapping the query.
+const cursorQuery = "SELECT id, name, id % 3 AS bucket FROM item WHERE name = ?"
+
+// cursorKeys orders the items by bucket then by id descending, so the keyset
+// condition has to compare the second key within a bucket.
+var cursorKeys = []CursorKey{{Column: "bucket", Field: "Bucket"}, {Column: "id", Field: "ID", Desc: true}}
+
+// cursorOrder is the order of the "record" items by cursorKeys.
+var cursorOrder = []int{21, 15, 9, 3, 25, 19, 13, 7, 1, 23, 17, 11, 5}
+
+// cursorPage fetches the page of pageSize items at cursor.
+func cursorPage(t *testing.T, conn *gorm.DB, pageSize int, cursor string) ([]int, *Paginator) {
+	t.Helper()
+
+	var records []testRecord
+	paginator := NewPaginator(conn, 0, pageSize, &records).
+		Raw(cursorQuery, []interface{}{"record"}, "", nil).
+		Cursor(cursor, cursorKeys...)
+	if err := paginator.Find(context.Background()).Error; err != nil {
+		t.Fatalf("Find(%q) error = %v", cursor, err)
+	}
+	return recordIDs(records), paginator
+}
+
+func TestPaginatorCursor(t *testing.T) {
+	conn := openTestDatabase(t)
+	pageSize := 4
+
+	var (
+		pages   [][]int
+		cursors []string
+	)
+	cursor := ""
+	for {
+		ids, paginator := cursorPage(t, conn, pageSize, cursor)
+		if paginator.Total != nil || paginator.MaxPage != nil {
+			t.Errorf("Total = %v, MaxPage = %v, want none in cursor mode", paginator.Total, paginator.MaxPage)
+		}
+		if (paginator.PrevCursor != "") != (cursor != "") {
+			t.Errorf("page %d PrevCursor = %q, want one: %t", len(pages), paginator.PrevCursor, cursor != "")
+		}
+		pages = append(pages, ids)
+		cursors = append(cursors, paginator.PrevCursor)
+		if paginator.NextCursor == "" {
+			break
+		}
+		if len(pages) > len(cursorOrder) {
+			t.Fatalf("pages = %v, the next cursor never ends", pages)
+		}
+		cursor = paginator.NextCursor
+	}
+
+	var forward []int
+	for i, v := range pages {
+		if len(v) != pageSize && i != len(pages)-1 {
+			t.Errorf("page %d = %v, want %d items", i, v, pageSize)
+		}
+		forward = append(forward, v...)
+	}
+	if !reflect.DeepEqual(forward, cursorOrder) {
+		t.Fatalf("pages forward = %v, want %v", pages, cursorOrder)
+	}
+
+	// walking back from each page gives the page before it
+	for i := len(pages) - 1; i > 0; i-- {
+		ids, paginator := cursorPage(t, conn, pageSize, cursors[i])
+		if !reflect.DeepEqual(ids, pages[i-1]) {
+			t.Errorf("page before %d = %v, want %v", i, ids, pages[i-1])
+		}
+		if paginator.NextCursor == "" {
+			t.Errorf("page before %d has no NextCursor", i)
+		}
+		if (paginator.PrevCursor != "") != (i > 1) {
+			t.Errorf("page before %d PrevCursor = %q, want one: %t", i, paginator.PrevCursor, i > 1)
+		}
+	}
+}
+
+func TestPaginatorTamperedCursor(t *testing.T) {
+	conn := openTestDatabase(t)
+
+	_, paginator := cursorPage(t, conn, 4, "")
+
+	var records []testRecord
+	err := NewPaginator(conn, 0, 4, &records).
+		Raw(cursorQuery, []interface{}{"record"}, "", nil).
+		Cursor(paginator.NextCursor+"x", cursorKeys...).
+		Find(context.Background()).Error
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("Find() error = %v, want %v", err, ErrInvalidCursor)
+	}
+}
@@ -1,15 +1,22 @@
 package pkg
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	beegoContext "github.com/beego/beego/v2/server/web/context"
 	"github.com/beego/i18n"
-	"os"
+	"github.com/online-store/pkg/jwtkey"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+// TokenExpiration is how long an access token issued by GenerateJWT stays valid.
+const TokenExpiration = 72 * time.Hour
+
 // GetLangVersion sets site language version.
 func GetLangVersion(ctx *beegoContext.Context) string {
 	// 1. Check URL arguments.
@@ -37,26 +44,56 @@ func GetLangVersion(ctx *beegoContext.Context) string {
 	return lang
 }
 
+// GetCurrency reads the currency prices are shown in, from the `currency` URL
+// argument or else the Currency header. It is empty when none is asked.
+func GetCurrency(ctx *beegoContext.Context) string {
+	currency := ctx.Input.Query("currency")
+	if len(currency) == 0 {
+		currency = ctx.Request.Header.Get("Currency")
+	}
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
 func GenerateJWT(userID int) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	if jwtkey.Default == nil {
+		return "", jwtkey.ErrNoKeyMaterial
+	}
+	return jwtkey.Default.Sign(jwt.MapClaims{
 		"userID": userID,
-		"exp":    time.Now().Add(time.Hour * 72).Unix(),
+		"exp":    time.Now().Add(TokenExpiration).Unix(),
 	})
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
 }
 
 func ValidateJWT(tokenString string) (int, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+	if jwtkey.Default == nil {
+		return 0, jwtkey.ErrNoKeyMaterial
+	}
+	token, err := jwtkey.Default.Parse(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return 0, err
+	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := int(claims["userID"].(float64))
-		return userID, nil
-	} else {
-		return 0, err
+		userID, ok := claims["userID"].(float64)
+		if !ok {
+			return 0, errors.New("invalid token claims")
+		}
+		return int(userID), nil
+	}
+	return 0, errors.New("invalid token")
+}
+
+// GenerateApiKey returns a random api key with the given prefix.
+func GenerateApiKey(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// HashApiKey returns the hex encoded SHA-256 digest stored in place of the plain key.
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
 }
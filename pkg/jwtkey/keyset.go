@@ -0,0 +1,211 @@
+package jwtkey
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrKeyDirRequired     = errors.New("jwt key directory is required")
+	ErrNoKeyMaterial      = errors.New("no jwt key material found")
+	ErrActiveKeyRequired  = errors.New("jwt active kid is required when several private keys are configured")
+	ErrActiveKeyNotFound  = errors.New("jwt active kid has no private key")
+	ErrUnsupportedKeyType = errors.New("unsupported jwt key type")
+	ErrUnknownKid         = errors.New("unknown jwt kid")
+)
+
+// Default is the key set used by pkg.GenerateJWT and pkg.ValidateJWT, set by Init.
+var Default *KeySet
+
+type (
+	// Key is a single signing key identified by its kid.
+	// Retired keys only carry the public half and are kept for verification.
+	Key struct {
+		Kid        string
+		Method     jwt.SigningMethod
+		PrivateKey crypto.Signer
+		PublicKey  crypto.PublicKey
+	}
+
+	KeySet struct {
+		keys      map[string]*Key
+		activeKid string
+	}
+
+	JWK struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n,omitempty"`
+		E   string `json:"e,omitempty"`
+		Crv string `json:"crv,omitempty"`
+		X   string `json:"x,omitempty"`
+	}
+
+	JWKS struct {
+		Keys []JWK `json:"keys"`
+	}
+)
+
+// Init loads the key set from dir and installs it as Default.
+func Init(dir, activeKid string) error {
+	keySet, err := LoadKeySet(dir, activeKid)
+	if err != nil {
+		return err
+	}
+	Default = keySet
+	return nil
+}
+
+// LoadKeySet reads every "<kid>.pem" file in dir. Private keys (PKCS#1 or PKCS#8
+// RSA, PKCS#8 Ed25519) can sign, public keys (PKIX) are verification only.
+// activeKid may be empty when exactly one private key exists.
+func LoadKeySet(dir, activeKid string) (*KeySet, error) {
+	if dir == "" {
+		return nil, ErrKeyDirRequired
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keySet := &KeySet{keys: map[string]*Key{}}
+	var privateKids []string
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := parseKeyFile(kid, file)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", kid, err)
+		}
+		keySet.keys[kid] = key
+		if key.PrivateKey != nil {
+			privateKids = append(privateKids, kid)
+		}
+	}
+
+	if len(privateKids) == 0 {
+		return nil, ErrNoKeyMaterial
+	}
+
+	if activeKid == "" {
+		if len(privateKids) > 1 {
+			return nil, ErrActiveKeyRequired
+		}
+		activeKid = privateKids[0]
+	}
+
+	if key, ok := keySet.keys[activeKid]; !ok || key.PrivateKey == nil {
+		return nil, ErrActiveKeyNotFound
+	}
+	keySet.activeKid = activeKid
+
+	return keySet, nil
+}
+
+func parseKeyFile(kid, file string) (*Key, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("invalid pem")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, ErrUnsupportedKeyType
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{Kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = EdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = EdDSA, k
+	default:
+		return nil, ErrUnsupportedKeyType
+	}
+
+	return key, nil
+}
+
+// Sign signs claims with the active key and sets the kid header.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := s.keys[s.activeKid]
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.PrivateKey)
+}
+
+// Parse verifies tokenString against the key named by its kid header.
+func (s *KeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKid
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.PublicKey, nil
+	})
+}
+
+// JWKS returns the public half of every configured key.
+func (s *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, kid := range kids {
+		key := s.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch k := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
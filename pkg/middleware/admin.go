@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	beego "github.com/beego/beego/v2/server/web"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/response"
+	"net/http"
+	"os"
+)
+
+const AdminTokenHeader = "X-Admin-Token"
+
+type AdminMiddleware struct {
+	response.APIResponseInterface
+}
+
+func NewAdminMiddleware(apiResponse response.APIResponseInterface) *AdminMiddleware {
+	return &AdminMiddleware{APIResponseInterface: apiResponse}
+}
+
+// ValidateAdmin compares the X-Admin-Token header against ADMIN_TOKEN.
+// Every request is rejected when ADMIN_TOKEN is not set.
+func (m *AdminMiddleware) ValidateAdmin() beego.FilterChain {
+	return func(next beego.FilterFunc) beego.FilterFunc {
+		return func(ctx *beegoContext.Context) {
+			token := ctx.Request.Header.Get(AdminTokenHeader)
+			if token == "" {
+				m.APIResponseInterface.ResponseError(
+					ctx,
+					http.StatusUnauthorized,
+					domain.MissingTokenErrorCode,
+					domain.ErrorCodeText(domain.MissingTokenErrorCode, pkg.GetLangVersion(ctx)),
+					errors.New("token is missing"))
+				return
+			}
+
+			adminToken := os.Getenv("ADMIN_TOKEN")
+			if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+				m.APIResponseInterface.ResponseError(
+					ctx,
+					http.StatusForbidden,
+					domain.RequestForbiddenErrorCode,
+					domain.ErrorCodeText(domain.RequestForbiddenErrorCode, pkg.GetLangVersion(ctx)),
+					errors.New("invalid admin token"))
+				return
+			}
+
+			next(ctx)
+		}
+	}
+}
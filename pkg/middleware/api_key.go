@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"errors"
+	beego "github.com/beego/beego/v2/server/web"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/online-store/internal/apikey"
+	"github.com/online-store/internal/domain"
+	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/response"
+	"net/http"
+)
+
+type ApiKeyMiddleware struct {
+	response.APIResponseInterface
+	apiKeyUseCase apikey.UseCase
+}
+
+func NewApiKeyMiddleware(apiResponse response.APIResponseInterface, apiKeyUseCase apikey.UseCase) *ApiKeyMiddleware {
+	return &ApiKeyMiddleware{APIResponseInterface: apiResponse, apiKeyUseCase: apiKeyUseCase}
+}
+
+// ValidateApiKey authenticates the X-API-Key header and requires the key to carry scope.
+// The key's customer is exposed as "userID" so customer handlers can be reused for partners.
+func (m *ApiKeyMiddleware) ValidateApiKey(scope string) beego.FilterChain {
+	return func(next beego.FilterFunc) beego.FilterFunc {
+		return func(ctx *beegoContext.Context) {
+			key := ctx.Request.Header.Get(domain.ApiKeyHeader)
+			if key == "" {
+				m.APIResponseInterface.ResponseError(
+					ctx,
+					http.StatusUnauthorized,
+					domain.MissingApiKeyErrorCode,
+					domain.ErrorCodeText(domain.MissingApiKeyErrorCode, pkg.GetLangVersion(ctx)),
+					errors.New("api key is missing"))
+				return
+			}
+
+			data, err := m.apiKeyUseCase.AuthenticateApiKey(ctx, key, scope)
+			if err != nil {
+				switch {
+				case errors.Is(err, domain.ErrApiKeyNotRegistered):
+					m.APIResponseInterface.ResponseError(
+						ctx,
+						http.StatusUnauthorized,
+						domain.ApiKeyNotRegisteredErrorCode,
+						domain.ErrorCodeText(domain.ApiKeyNotRegisteredErrorCode, pkg.GetLangVersion(ctx)),
+						err)
+				case errors.Is(err, domain.ErrApiKeyInvalid):
+					m.APIResponseInterface.ResponseError(
+						ctx,
+						http.StatusUnauthorized,
+						domain.InvalidApiKeyErrorCode,
+						domain.ErrorCodeText(domain.InvalidApiKeyErrorCode, pkg.GetLangVersion(ctx)),
+						err)
+				case errors.Is(err, domain.ErrApiKeyForbidden):
+					m.APIResponseInterface.ResponseError(
+						ctx,
+						http.StatusForbidden,
+						domain.RequestForbiddenErrorCode,
+						domain.ErrorCodeText(domain.RequestForbiddenErrorCode, pkg.GetLangVersion(ctx)),
+						err)
+				default:
+					m.APIResponseInterface.ResponseError(
+						ctx,
+						http.StatusInternalServerError,
+						domain.ServerErrorCode,
+						domain.ErrorCodeText(domain.ServerErrorCode, pkg.GetLangVersion(ctx)),
+						nil)
+				}
+				return
+			}
+
+			ctx.Input.SetData("userID", data.CustomerID)
+			ctx.Input.SetData("apiKeyID", data.ID)
+			next(ctx)
+		}
+	}
+}
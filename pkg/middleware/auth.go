@@ -2,10 +2,13 @@ package middleware
 
 import (
 	"errors"
+	"fmt"
 	beego "github.com/beego/beego/v2/server/web"
 	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/go-redis/redis/v8"
 	"github.com/online-store/internal/domain"
 	"github.com/online-store/pkg"
+	"github.com/online-store/pkg/cache"
 	"github.com/online-store/pkg/response"
 	"net/http"
 	"strings"
@@ -13,10 +16,11 @@ import (
 
 type AuthMiddleware struct {
 	response.APIResponseInterface
+	cacheRepo cache.RedisRepository
 }
 
-func NewAuthMiddleware(apiResponse response.APIResponseInterface) *AuthMiddleware {
-	return &AuthMiddleware{APIResponseInterface: apiResponse}
+func NewAuthMiddleware(apiResponse response.APIResponseInterface, cacheRepo cache.RedisRepository) *AuthMiddleware {
+	return &AuthMiddleware{APIResponseInterface: apiResponse, cacheRepo: cacheRepo}
 }
 
 func (m *AuthMiddleware) ValidateAuth() beego.FilterChain {
@@ -47,6 +51,28 @@ func (m *AuthMiddleware) ValidateAuth() beego.FilterChain {
 				return
 			}
 
+			//tokens of deleted accounts are revoked, the request is rejected when the
+			//revocation cannot be looked up
+			_, err = m.cacheRepo.Fetch(ctx.Request.Context(), fmt.Sprintf("%s:%d", domain.RevokedTokenKeyCache, userID))
+			if err == nil {
+				m.APIResponseInterface.ResponseError(
+					ctx,
+					http.StatusUnauthorized,
+					domain.InvalidTokenErrorCode,
+					domain.ErrorCodeText(domain.InvalidTokenErrorCode, pkg.GetLangVersion(ctx)),
+					errors.New("token is revoked"))
+				return
+			}
+			if !errors.Is(err, redis.Nil) {
+				m.APIResponseInterface.ResponseError(
+					ctx,
+					http.StatusInternalServerError,
+					domain.ServerErrorCode,
+					domain.ErrorCodeText(domain.ServerErrorCode, pkg.GetLangVersion(ctx)),
+					err)
+				return
+			}
+
 			ctx.Input.SetData("userID", userID)
 			next(ctx)
 		}
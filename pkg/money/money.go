@@ -0,0 +1,478 @@
+// Package money holds amounts as whole minor units of a currency, cents for USD,
+// so sums and totals never drift the way floating point amounts do.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("money: currencies don't match")
+	ErrOverflow         = errors.New("money: amount out of range")
+	ErrInvalidAmount    = errors.New("money: invalid amount")
+)
+
+// exponents lists the currencies whose minor unit is not the hundredth of the
+// major unit, per ISO 4217.
+var exponents = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+var defaultCurrency = "IDR"
+
+// SetDefaultCurrency sets the currency of the amounts read from the database and
+// of the bare numbers read from JSON.
+func SetDefaultCurrency(currency string) {
+	defaultCurrency = strings.ToUpper(currency)
+}
+
+// DefaultCurrency returns the currency set by SetDefaultCurrency, IDR when not set.
+func DefaultCurrency() string {
+	return defaultCurrency
+}
+
+// Exponent returns the number of decimals of the currency.
+func Exponent(currency string) int {
+	if v, ok := exponents[currency]; ok {
+		return v
+	}
+	return 2
+}
+
+// Money is an amount of a currency. Amount is in minor units and Currency is an
+// ISO 4217 code. The zero value has no currency, it is taken as zero of any
+// currency by the arithmetic.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New returns amount minor units of currency.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Zero returns no money of currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// FromMajor converts an amount in major units, rounding half away from zero to
+// the minor unit.
+func FromMajor(amount float64, currency string) Money {
+	currency = strings.ToUpper(currency)
+	return Money{Amount: int64(math.Round(amount * math.Pow10(Exponent(currency)))), Currency: currency}
+}
+
+// Parse reads a decimal amount in major units, like "12.50". The amount can't have
+// more decimals than the currency.
+func Parse(value string, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	exponent := Exponent(currency)
+
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return Money{}, ErrInvalidAmount
+	}
+	if len(fraction) > exponent {
+		if strings.Trim(fraction[exponent:], "0") != "" {
+			return Money{}, ErrInvalidAmount
+		}
+		fraction = fraction[:exponent]
+	}
+	digits := whole + fraction + strings.Repeat("0", exponent-len(fraction))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Money{}, ErrInvalidAmount
+		}
+	}
+
+	// the sign is parsed with the digits, the smallest amount has no positive twin
+	if negative {
+		digits = "-" + digits
+	}
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Sum adds up the amounts, which must all be of currency.
+func Sum(currency string, values ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, v := range values {
+		var err error
+		if total, err = total.Add(v); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func (m Money) currency(o Money) (string, error) {
+	switch {
+	case m.Currency == o.Currency || o.Currency == "":
+		return m.Currency, nil
+	case m.Currency == "":
+		return o.Currency, nil
+	}
+	return "", ErrCurrencyMismatch
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := m.currency(o)
+	if err != nil {
+		return Money{}, err
+	}
+	if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) || (o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: currency}, nil
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+// Mul returns m times n.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+	amount := m.Amount * n
+	if amount/n != m.Amount || (m.Amount == -1 && n == math.MinInt64) || (n == -1 && m.Amount == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// Div returns m divided by n, rounded half away from zero to the minor unit.
+func (m Money) Div(n int64) (Money, error) {
+	return m.MulDiv(1, n)
+}
+
+// MulDiv returns m times num divided by den, rounded half away from zero to the
+// minor unit. It is exact, the intermediate product can't overflow.
+func (m Money) MulDiv(num, den int64) (Money, error) {
+	return m.mulDiv(big.NewInt(num), big.NewInt(den))
+}
+
+func (m Money) mulDiv(num, den *big.Int) (Money, error) {
+	if den.Sign() == 0 {
+		return Money{}, ErrInvalidAmount
+	}
+	product := new(big.Int).Mul(big.NewInt(m.Amount), num)
+	quotient, remainder := new(big.Int).QuoRem(product, den, new(big.Int))
+
+	// the quotient is truncated toward zero, round it half away from zero
+	twice := new(big.Int).Lsh(new(big.Int).Abs(remainder), 1)
+	if twice.Cmp(new(big.Int).Abs(den)) >= 0 {
+		if product.Sign()*den.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	if !quotient.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: quotient.Int64(), Currency: m.Currency}, nil
+}
+
+// decimal returns value as digits over 10^decimals, with the fewest decimals that
+// read back as value: 12.5 is 125 over 10^1. Rates and percentages are stored as
+// floating point numbers but applied as these exact integers.
+func decimal(value float64) (*big.Int, int, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, 0, ErrInvalidAmount
+	}
+	whole, fraction, _ := strings.Cut(strconv.FormatFloat(value, 'f', -1, 64), ".")
+	digits, _ := new(big.Int).SetString(whole+fraction, 10)
+	return digits, len(fraction), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// Allocate splits m in parts proportional to the weights, which must not be
+// negative. The parts add up to m exactly, the minor units left over by rounding
+// down go to the largest remainders, the first parts on ties.
+func (m Money) Allocate(weights []int64) ([]Money, error) {
+	total := new(big.Int)
+	for _, v := range weights {
+		if v < 0 {
+			return nil, ErrInvalidAmount
+		}
+		total.Add(total, big.NewInt(v))
+	}
+
+	parts := make([]Money, len(weights))
+	for i := range parts {
+		parts[i] = Money{Currency: m.Currency}
+	}
+	if total.Sign() == 0 {
+		if m.Amount != 0 {
+			return nil, ErrInvalidAmount
+		}
+		return parts, nil
+	}
+
+	remainders := make([]*big.Int, len(weights))
+	left := m.Amount
+	for i, v := range weights {
+		quotient, remainder := new(big.Int).QuoRem(new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(v)), total, new(big.Int))
+		parts[i].Amount = quotient.Int64()
+		remainders[i] = remainder.Abs(remainder)
+		left -= parts[i].Amount
+	}
+
+	unit := int64(1)
+	if left < 0 {
+		unit = -1
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]].Cmp(remainders[order[j]]) > 0
+	})
+	for i := 0; left != 0; i++ {
+		parts[order[i%len(order)]].Amount += unit
+		left -= unit
+	}
+	return parts, nil
+}
+
+// Percent returns percent % of m, rounded half away from zero to the minor unit.
+func (m Money) Percent(percent float64) (Money, error) {
+	digits, decimals, err := decimal(percent)
+	if err != nil {
+		return Money{}, err
+	}
+	return m.mulDiv(digits, pow10(decimals+2))
+}
+
+// Convert converts m to currency at rate, the amount of currency one major unit of
+// m is worth, rounding half away from zero to the minor unit.
+func (m Money) Convert(currency string, rate float64) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if currency == m.Currency {
+		return m, nil
+	}
+	digits, decimals, err := decimal(rate)
+	if err != nil {
+		return Money{}, err
+	}
+
+	converted, err := m.mulDiv(digits.Mul(digits, pow10(Exponent(currency))), pow10(decimals+Exponent(m.Currency)))
+	if err != nil {
+		return Money{}, err
+	}
+	converted.Currency = currency
+	return converted, nil
+}
+
+// ConvertInverse converts m to currency at rate, the amount of the currency of m
+// one major unit of currency is worth, rounding half away from zero to the minor
+// unit. It undoes Convert without taking the inverse of rate.
+func (m Money) ConvertInverse(currency string, rate float64) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if currency == m.Currency {
+		return m, nil
+	}
+	digits, decimals, err := decimal(rate)
+	if err != nil {
+		return Money{}, err
+	}
+
+	converted, err := m.mulDiv(pow10(decimals+Exponent(currency)), digits.Mul(digits, pow10(Exponent(m.Currency))))
+	if err != nil {
+		return Money{}, err
+	}
+	converted.Currency = currency
+	return converted, nil
+}
+
+// Cmp compares m and o, it returns -1, 0 or +1.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.currency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Min returns the smaller of m and o, both must be of the same currency.
+func Min(m, o Money) (Money, error) {
+	cmp, err := m.Cmp(o)
+	if err != nil {
+		return Money{}, err
+	}
+	if cmp > 0 {
+		return o, nil
+	}
+	return m, nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Major returns the amount in major units, for display and rates only.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(Exponent(m.Currency))
+}
+
+// Decimal formats the amount in major units with the decimals of the currency,
+// like "12.50".
+func (m Money) Decimal() string {
+	exponent := Exponent(m.Currency)
+
+	sign, digits := "", strconv.FormatUint(uint64(m.Amount), 10)
+	if m.Amount < 0 {
+		sign, digits = "-", strconv.FormatUint(uint64(-m.Amount), 10)
+	}
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type jsonMoney struct {
+	Amount   jsoniter.RawMessage `json:"amount"`
+	Currency string              `json:"currency"`
+}
+
+// MarshalJSON writes {"amount":"12.50","currency":"USD"}, the amount is a decimal
+// string so it reads back exactly.
+func (m Money) MarshalJSON() ([]byte, error) {
+	currency := m.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	return []byte(fmt.Sprintf(`{"amount":"%s","currency":"%s"}`, Money{Amount: m.Amount, Currency: currency}.Decimal(), currency)), nil
+}
+
+// UnmarshalJSON reads the object written by MarshalJSON, its amount may be a
+// number too. A bare number or string is an amount of the default currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		return nil
+	}
+
+	currency := defaultCurrency
+	if strings.HasPrefix(raw, "{") {
+		var v jsonMoney
+		if err := jsoniter.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		if v.Currency != "" {
+			currency = v.Currency
+		}
+		raw = strings.TrimSpace(string(v.Amount))
+	}
+
+	parsed, err := Parse(strings.Trim(raw, `"`), currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount in minor units, the currency is not stored.
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+// Scan reads an amount in minor units of the default currency.
+func (m *Money) Scan(src interface{}) error {
+	var amount int64
+	switch v := src.(type) {
+	case int64:
+		amount = v
+	case int32:
+		amount = int64(v)
+	case float64:
+		amount = int64(math.Round(v))
+	case []byte:
+		parsed, err := parseMinor(string(v))
+		if err != nil {
+			return err
+		}
+		amount = parsed
+	case string:
+		parsed, err := parseMinor(v)
+		if err != nil {
+			return err
+		}
+		amount = parsed
+	case nil:
+		amount = 0
+	default:
+		return fmt.Errorf("money: can't scan %T", src)
+	}
+
+	*m = Money{Amount: amount, Currency: defaultCurrency}
+	return nil
+}
+
+// parseMinor reads an amount in minor units written as text, numeric columns
+// included. Whole amounts are read exactly, others are rounded half away from zero.
+func parseMinor(value string) (int64, error) {
+	if amount, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return amount, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return 0, ErrInvalidAmount
+	}
+	if parsed >= math.MaxInt64 || parsed < math.MinInt64 {
+		return 0, ErrOverflow
+	}
+	return int64(math.Round(parsed)), nil
+}
+
+func (Money) GormDataType() string {
+	return "bigint"
+}
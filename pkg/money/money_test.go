@@ -0,0 +1,396 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		num    int64
+		den    int64
+		want   int64
+		err    error
+	}{
+		{name: "exact", amount: 300, num: 2, den: 3, want: 200},
+		{name: "rounds down below half", amount: 4, num: 1, den: 3, want: 1},
+		{name: "rounds up above half", amount: 10, num: 2, den: 3, want: 7},
+		{name: "rounds half away from zero", amount: 5, num: 1, den: 2, want: 3},
+		{name: "rounds negative half away from zero", amount: -5, num: 1, den: 2, want: -3},
+		{name: "rounds half away from zero with a negative divisor", amount: 5, num: 1, den: -2, want: -3},
+		{name: "rounds negative below half toward zero", amount: -4, num: 1, den: 3, want: -1},
+		{name: "intermediate product beyond int64", amount: math.MaxInt64, num: 3, den: 3, want: math.MaxInt64},
+		{name: "zero divisor", amount: 5, num: 1, den: 0, err: ErrInvalidAmount},
+		{name: "result beyond int64", amount: math.MaxInt64, num: 2, den: 1, err: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.amount, "USD").MulDiv(tt.num, tt.den)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("MulDiv() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if got.Amount != tt.want || got.Currency != "USD" {
+				t.Errorf("MulDiv() = %v, want %d USD", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		weights []int64
+		want    []int64
+		err     error
+	}{
+		{name: "even split", amount: 90, weights: []int64{1, 1, 1}, want: []int64{30, 30, 30}},
+		{name: "remainder goes to the first part on ties", amount: 100, weights: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "remainder goes to the largest remainder", amount: 100, weights: []int64{1, 2}, want: []int64{33, 67}},
+		{name: "several remainders go to the first parts on ties", amount: 5, weights: []int64{3, 3, 3, 1}, want: []int64{2, 2, 1, 0}},
+		{name: "negative amount", amount: -100, weights: []int64{1, 1, 1}, want: []int64{-34, -33, -33}},
+		{name: "zero weight gets nothing", amount: 10, weights: []int64{0, 1}, want: []int64{0, 10}},
+		{name: "zero amount over zero weights", amount: 0, weights: []int64{0, 0}, want: []int64{0, 0}},
+		{name: "amount over zero weights", amount: 5, weights: []int64{0, 0}, err: ErrInvalidAmount},
+		{name: "amount over no weight", amount: 5, weights: nil, err: ErrInvalidAmount},
+		{name: "negative weight", amount: 10, weights: []int64{2, -1}, err: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.amount, "USD").Allocate(tt.weights)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Allocate() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+
+			amounts := make([]int64, 0, len(got))
+			var sum int64
+			for _, v := range got {
+				if v.Currency != "USD" {
+					t.Errorf("Allocate() part currency = %s, want USD", v.Currency)
+				}
+				amounts = append(amounts, v.Amount)
+				sum += v.Amount
+			}
+			if !reflect.DeepEqual(amounts, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", amounts, tt.want)
+			}
+			if sum != tt.amount {
+				t.Errorf("Allocate() parts add up to %d, want %d", sum, tt.amount)
+			}
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		percent float64
+		want    int64
+		err     error
+	}{
+		{name: "whole percent", amount: 1000, percent: 10, want: 100},
+		{name: "decimal percent", amount: 1000, percent: 12.5, want: 125},
+		{name: "rounds half away from zero", amount: 1000, percent: 0.15, want: 2},
+		{name: "rounds negative half away from zero", amount: -1000, percent: 0.15, want: -2},
+		{name: "rounds down below half", amount: 100, percent: 33.3333, want: 33},
+		{name: "exact beyond float precision", amount: 1<<53 + 1, percent: 100, want: 1<<53 + 1},
+		{name: "result beyond int64", amount: math.MaxInt64, percent: 300, err: ErrOverflow},
+		{name: "not a number", amount: 1000, percent: math.NaN(), err: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.amount, "USD").Percent(tt.percent)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Percent() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if got.Amount != tt.want || got.Currency != "USD" {
+				t.Errorf("Percent() = %v, want %d USD", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     Money
+		currency string
+		rate     float64
+		inverse  bool
+		want     Money
+		err      error
+	}{
+		{name: "to a smaller currency", from: New(155000000000, "IDR"), currency: "USD", rate: 0.0000645161, want: New(9999996, "USD")},
+		{name: "to a currency without decimals", from: New(1234, "USD"), currency: "JPY", rate: 150.5, want: New(1857, "JPY")},
+		{name: "to a currency with three decimals", from: New(1000, "JPY"), currency: "KWD", rate: 0.00205, want: New(2050, "KWD")},
+		{name: "same currency is kept", from: New(1234, "USD"), currency: "usd", rate: 2, want: New(1234, "USD")},
+		{name: "exact beyond float precision", from: New(1<<53+1, "USD"), currency: "EUR", rate: 1, want: New(1<<53+1, "EUR")},
+		{name: "inverse of a small rate", from: New(100000, "USD"), currency: "IDR", rate: 0.0000645161, inverse: true, want: New(1550000698, "IDR")},
+		{name: "inverse reads back the amount", from: New(1857, "JPY"), currency: "USD", rate: 150.5, inverse: true, want: New(1234, "USD")},
+		{name: "result beyond int64", from: New(math.MaxInt64, "USD"), currency: "EUR", rate: 2, err: ErrOverflow},
+		{name: "not a number", from: New(1234, "USD"), currency: "EUR", rate: math.Inf(1), err: ErrInvalidAmount},
+		{name: "inverse of a zero rate", from: New(1234, "USD"), currency: "EUR", rate: 0, inverse: true, err: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convert := tt.from.Convert
+			if tt.inverse {
+				convert = tt.from.ConvertInverse
+			}
+			got, err := convert(tt.currency, tt.rate)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Convert() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		want     Money
+		err      error
+	}{
+		{name: "decimals of the currency", value: "12.50", currency: "USD", want: New(1250, "USD")},
+		{name: "fewer decimals", value: "12.5", currency: "usd", want: New(1250, "USD")},
+		{name: "no decimal", value: "12", currency: "USD", want: New(1200, "USD")},
+		{name: "no whole part", value: ".5", currency: "USD", want: New(50, "USD")},
+		{name: "no fraction", value: "5.", currency: "USD", want: New(500, "USD")},
+		{name: "spaces around", value: " 7.5 ", currency: "USD", want: New(750, "USD")},
+		{name: "plus sign", value: "+12.5", currency: "USD", want: New(1250, "USD")},
+		{name: "minus sign", value: "-12.5", currency: "USD", want: New(-1250, "USD")},
+		{name: "currency without decimals", value: "1234", currency: "JPY", want: New(1234, "JPY")},
+		{name: "currency with three decimals", value: "1.234", currency: "KWD", want: New(1234, "KWD")},
+		{name: "excess zero decimals", value: "12.500", currency: "USD", want: New(1250, "USD")},
+		{name: "excess decimals", value: "12.505", currency: "USD", err: ErrInvalidAmount},
+		{name: "decimals of a currency without any", value: "12.5", currency: "JPY", err: ErrInvalidAmount},
+		{name: "largest amount", value: "92233720368547758.07", currency: "USD", want: New(math.MaxInt64, "USD")},
+		{name: "smallest amount", value: "-92233720368547758.08", currency: "USD", want: New(math.MinInt64, "USD")},
+		{name: "beyond the largest amount", value: "92233720368547758.08", currency: "USD", err: ErrOverflow},
+		{name: "beyond the smallest amount", value: "-92233720368547758.09", currency: "USD", err: ErrOverflow},
+		{name: "empty", value: "", currency: "USD", err: ErrInvalidAmount},
+		{name: "sign only", value: "-", currency: "USD", err: ErrInvalidAmount},
+		{name: "point only", value: ".", currency: "USD", err: ErrInvalidAmount},
+		{name: "two signs", value: "--1", currency: "USD", err: ErrInvalidAmount},
+		{name: "two points", value: "1.2.3", currency: "USD", err: ErrInvalidAmount},
+		{name: "exponent", value: "1e3", currency: "USD", err: ErrInvalidAmount},
+		{name: "not a number", value: "abc", currency: "USD", err: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value, tt.currency)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.value, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value Money
+		json  string
+		want  Money
+	}{
+		{name: "decimals of the currency", value: New(1250, "USD"), json: `{"amount":"12.50","currency":"USD"}`, want: New(1250, "USD")},
+		{name: "negative below one unit", value: New(-5, "USD"), json: `{"amount":"-0.05","currency":"USD"}`, want: New(-5, "USD")},
+		{name: "currency without decimals", value: New(1234, "JPY"), json: `{"amount":"1234","currency":"JPY"}`, want: New(1234, "JPY")},
+		{name: "currency with three decimals", value: New(1, "KWD"), json: `{"amount":"0.001","currency":"KWD"}`, want: New(1, "KWD")},
+		{name: "largest amount", value: New(math.MaxInt64, "USD"), json: `{"amount":"92233720368547758.07","currency":"USD"}`, want: New(math.MaxInt64, "USD")},
+		{name: "smallest amount", value: New(math.MinInt64, "USD"), json: `{"amount":"-92233720368547758.08","currency":"USD"}`, want: New(math.MinInt64, "USD")},
+		{name: "no currency is the default one", value: Money{Amount: 1250}, json: `{"amount":"12.50","currency":"` + DefaultCurrency() + `"}`, want: New(1250, DefaultCurrency())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.value.MarshalJSON()
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+			if string(data) != tt.json {
+				t.Errorf("MarshalJSON() = %s, want %s", data, tt.json)
+			}
+
+			var got Money
+			if err := got.UnmarshalJSON(data); err != nil {
+				t.Fatalf("UnmarshalJSON(%s) error = %v", data, err)
+			}
+			if got != tt.want {
+				t.Errorf("UnmarshalJSON(%s) = %v, want %v", data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want Money
+		err  error
+	}{
+		{name: "amount as a number", json: `{"amount":12.5,"currency":"USD"}`, want: New(1250, "USD")},
+		{name: "object without currency", json: `{"amount":"12.50"}`, want: New(1250, DefaultCurrency())},
+		{name: "bare number", json: `12.5`, want: New(1250, DefaultCurrency())},
+		{name: "bare string", json: `"12.50"`, want: New(1250, DefaultCurrency())},
+		{name: "null keeps the value", json: `null`, want: New(7, "USD")},
+		{name: "excess decimals", json: `{"amount":"12.505","currency":"USD"}`, err: ErrInvalidAmount},
+		{name: "beyond the largest amount", json: `"92233720368547758.08"`, err: ErrOverflow},
+		{name: "not an amount", json: `{"amount":true,"currency":"USD"}`, err: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(7, "USD")
+			err := got.UnmarshalJSON([]byte(tt.json))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("UnmarshalJSON(%s) error = %v, want %v", tt.json, err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("UnmarshalJSON(%s) = %v, want %v", tt.json, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name string
+		src  interface{}
+		want int64
+		err  error
+	}{
+		{name: "int8 column", src: int64(1250), want: 1250},
+		{name: "int4 column", src: int32(1250), want: 1250},
+		{name: "float column rounds", src: 1249.5, want: 1250},
+		{name: "numeric column", src: []byte("1250"), want: 1250},
+		{name: "numeric column beyond float precision", src: []byte("9007199254740993"), want: 9007199254740993},
+		{name: "numeric column with decimals rounds", src: []byte("1249.5"), want: 1250},
+		{name: "text", src: "-1250", want: -1250},
+		{name: "null is zero", src: nil, want: 0},
+		{name: "not a number", src: []byte("abc"), err: ErrInvalidAmount},
+		{name: "beyond the largest amount", src: "1e30", err: ErrOverflow},
+		{name: "unsupported type", src: true, err: errors.New("money: can't scan bool")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := got.Scan(tt.src)
+			if (err == nil) != (tt.err == nil) || (err != nil && err.Error() != tt.err.Error()) {
+				t.Fatalf("Scan(%v) error = %v, want %v", tt.src, err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if want := New(tt.want, DefaultCurrency()); got != want {
+				t.Errorf("Scan(%v) = %v, want %v", tt.src, got, want)
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Money
+		b    Money
+		sub  bool
+		want Money
+		err  error
+	}{
+		{name: "same currency", a: New(150, "USD"), b: New(250, "USD"), want: New(400, "USD")},
+		{name: "zero value takes the currency", a: Money{}, b: New(250, "USD"), want: New(250, "USD")},
+		{name: "currencies mismatch", a: New(150, "USD"), b: New(250, "EUR"), err: ErrCurrencyMismatch},
+		{name: "largest amount", a: New(math.MaxInt64-1, "USD"), b: New(1, "USD"), want: New(math.MaxInt64, "USD")},
+		{name: "beyond the largest amount", a: New(math.MaxInt64, "USD"), b: New(1, "USD"), err: ErrOverflow},
+		{name: "beyond the smallest amount", a: New(math.MinInt64, "USD"), b: New(-1, "USD"), err: ErrOverflow},
+		{name: "sub down to the smallest amount", a: New(-1, "USD"), b: New(math.MaxInt64, "USD"), sub: true, want: New(math.MinInt64, "USD")},
+		{name: "sub of the smallest amount", a: New(0, "USD"), b: New(math.MinInt64, "USD"), sub: true, err: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			add := tt.a.Add
+			if tt.sub {
+				add = tt.a.Sub
+			}
+			got, err := add(tt.b)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Add() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Add() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		n      int64
+		want   int64
+		err    error
+	}{
+		{name: "quantity", amount: 1250, n: 3, want: 3750},
+		{name: "negative", amount: 1250, n: -3, want: -3750},
+		{name: "zero", amount: math.MaxInt64, n: 0, want: 0},
+		{name: "largest amount", amount: math.MaxInt64, n: 1, want: math.MaxInt64},
+		{name: "beyond the largest amount", amount: math.MaxInt64/2 + 1, n: 2, err: ErrOverflow},
+		{name: "beyond the smallest amount", amount: math.MinInt64/2 - 1, n: 2, err: ErrOverflow},
+		{name: "smallest amount negated", amount: math.MinInt64, n: -1, err: ErrOverflow},
+		{name: "negated by the smallest amount", amount: -1, n: math.MinInt64, err: ErrOverflow},
+		{name: "wraps back to a multiple", amount: 1 << 62, n: 4, err: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.amount, "USD").Mul(tt.n)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Mul() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if want := New(tt.want, "USD"); got != want {
+				t.Errorf("Mul() = %v, want %v", got, want)
+			}
+		})
+	}
+}
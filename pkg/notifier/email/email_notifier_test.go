@@ -0,0 +1,81 @@
+package email
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/online-store/pkg/notifier"
+)
+
+// silentServer accepts connections and never greets, like a stuck SMTP server.
+func silentServer(t *testing.T) (string, int) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return host, portNumber
+}
+
+func TestNotifyGivesUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		ctx     func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name:    "after the timeout",
+			timeout: 100 * time.Millisecond,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.Background(), func() {}
+			},
+		},
+		{
+			name: "once the context is done",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 100*time.Millisecond)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := silentServer(t)
+			n := NewEmailNotifier(host, port, "", "", "store@example.com", []string{"staff@example.com"}, tt.timeout)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+			if err := n.Notify(ctx, notifier.Message{Subject: "subject", Body: "body"}); err == nil {
+				t.Fatal("Notify() error = nil, want an error")
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Notify() took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestNotifyWithoutRecipient(t *testing.T) {
+	n := NewEmailNotifier("127.0.0.1", 25, "", "", "store@example.com", nil, time.Second)
+	if err := n.Notify(context.Background(), notifier.Message{Subject: "subject"}); err != notifier.ErrNoRecipient {
+		t.Fatalf("Notify() error = %v, want %v", err, notifier.ErrNoRecipient)
+	}
+}
@@ -0,0 +1,23 @@
+package logger
+
+import (
+	"context"
+	"strings"
+
+	"github.com/online-store/pkg/notifier"
+	"github.com/online-store/pkg/zaplogger"
+)
+
+type logNotifier struct {
+	zapLogger zaplogger.Logger
+}
+
+// NewLogNotifier writes the notifications to the application log, as warnings.
+func NewLogNotifier(zapLogger zaplogger.Logger) notifier.Notifier {
+	return &logNotifier{zapLogger: zapLogger}
+}
+
+func (n logNotifier) Notify(ctx context.Context, message notifier.Message) error {
+	n.zapLogger.WithFields(zaplogger.Fields{"to": strings.Join(message.To, ",")}).Warnf("%s: %s", message.Subject, message.Body)
+	return nil
+}
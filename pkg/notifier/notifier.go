@@ -0,0 +1,39 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoRecipient = errors.New("notification has no recipient")
+
+// Message is a notification. To lists the recipients of channels addressing
+// people, channels posting to a shared destination ignore it.
+type Message struct {
+	Subject string
+	Body    string
+	To      []string
+}
+
+// Notifier delivers notifications through one channel: the log, email or Slack.
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+type multiNotifier []Notifier
+
+// NewMultiNotifier delivers every message through each of the notifiers, a
+// failing channel doesn't stop the others.
+func NewMultiNotifier(notifiers ...Notifier) Notifier {
+	return multiNotifier(notifiers)
+}
+
+func (m multiNotifier) Notify(ctx context.Context, message Message) error {
+	var errs []error
+	for _, v := range m {
+		if err := v.Notify(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,31 @@
+package slack
+
+import (
+	"context"
+	"time"
+
+	"github.com/bluele/zapslack"
+	"github.com/online-store/pkg/notifier"
+	"go.uber.org/zap/zapcore"
+)
+
+type slackNotifier struct {
+	hook func(zapcore.Entry) error
+}
+
+// NewSlackNotifier posts the notifications to a Slack incoming webhook, through the
+// same hook the logger uses to report errors.
+func NewSlackNotifier(webhookURL string, timeout time.Duration) notifier.Notifier {
+	hook := zapslack.NewSlackHook(webhookURL, zapcore.WarnLevel)
+	hook.Timeout = timeout
+
+	return &slackNotifier{hook: hook.GetHook()}
+}
+
+func (n slackNotifier) Notify(ctx context.Context, message notifier.Message) error {
+	return n.hook(zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    time.Now(),
+		Message: "*" + message.Subject + "*\n" + message.Body,
+	})
+}
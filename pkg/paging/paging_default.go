@@ -9,6 +9,9 @@ const (
 	DEFAULT_PAGESIZE = 10
 	MAX_PAGESIZE     = 100
 	DEFAULT_PAGE     = 1
+
+	OFFSET_PAGINATION = "offset"
+	CURSOR_PAGINATION = "cursor"
 )
 
 func PageAndPageSizeValidation(pageSizeQuery string, pageQuery string) (pageSize int, page int, err error) {
@@ -46,3 +49,18 @@ func PageAndPageSizeValidation(pageSizeQuery string, pageQuery string) (pageSize
 	err = nil
 	return
 }
+
+// CursorValidation resolves the pagination mode of an endpoint accepting both modes.
+// Cursor mode is used when paginationQuery is "cursor" or a cursor is given.
+func CursorValidation(paginationQuery string, cursorQuery string) (cursorMode bool, err error) {
+	switch paginationQuery {
+	case "":
+		return cursorQuery != "", nil
+	case OFFSET_PAGINATION:
+		return false, nil
+	case CURSOR_PAGINATION:
+		return true, nil
+	default:
+		return false, domain.ErrInvalidUrlQueryParam
+	}
+}
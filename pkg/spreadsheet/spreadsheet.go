@@ -0,0 +1,37 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("spreadsheet format is not supported")
+
+// zipMagic starts every XLSX file, which is a zip archive.
+var zipMagic = []byte("PK\x03\x04")
+
+// Read returns the rows of a CSV or XLSX file. The format is taken from the
+// content, fileName is only used to reject formats that are neither.
+func Read(fileName string, content []byte) ([][]string, error) {
+	if bytes.HasPrefix(content, zipMagic) {
+		return ReadXLSX(content)
+	}
+
+	if ext := strings.ToLower(fileName); strings.HasSuffix(ext, ".xlsx") || strings.HasSuffix(ext, ".xls") {
+		return nil, ErrUnsupportedFormat
+	}
+	return ReadCSV(content)
+}
+
+// ReadCSV reads comma separated rows, a UTF-8 byte order mark written by
+// spreadsheet applications is skipped.
+func ReadCSV(content []byte) ([][]string, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
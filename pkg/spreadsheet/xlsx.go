@@ -0,0 +1,192 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxXLSXPartSize bounds the uncompressed size of a single part of the archive.
+	maxXLSXPartSize = 100 << 20
+
+	// the sheet size limits of Excel
+	maxXLSXRows    = 1 << 20
+	maxXLSXColumns = 1 << 14
+)
+
+type (
+	xlsxWorkbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+
+	xlsxRelationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	xlsxText struct {
+		T    string `xml:"t"`
+		Runs []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	}
+
+	xlsxSharedStrings struct {
+		Items []xlsxText `xml:"si"`
+	}
+
+	xlsxSheet struct {
+		Rows []struct {
+			Ref   int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+)
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, v := range t.Runs {
+		b.WriteString(v.T)
+	}
+	return b.String()
+}
+
+// ReadXLSX returns the rows of the first worksheet as text. Only cell values are
+// read, formulas come back as their last computed value.
+func ReadXLSX(content []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodePart(files, "xl/workbook.xml", &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return nil, ErrUnsupportedFormat
+	}
+
+	var relationships xlsxRelationships
+	if err := decodePart(files, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	sheetPath := ""
+	for _, v := range relationships.Relationships {
+		if v.ID == workbook.Sheets[0].ID {
+			sheetPath = path.Join("xl", v.Target)
+			if strings.HasPrefix(v.Target, "/") {
+				sheetPath = strings.TrimPrefix(v.Target, "/")
+			}
+		}
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(files, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, ErrUnsupportedFormat
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := decodePart(files, sheetPath, &sheet); err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		if row.Ref > maxXLSXRows {
+			return nil, ErrUnsupportedFormat
+		}
+
+		// empty rows are left out of the sheet, keep the row numbers of the file
+		for row.Ref > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+
+		var record []string
+		for i, cell := range row.Cells {
+			column := columnIndex(cell.Ref)
+			if column < 0 {
+				column = i
+			}
+			if column >= maxXLSXColumns {
+				return nil, ErrUnsupportedFormat
+			}
+			for len(record) <= column {
+				record = append(record, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return nil, ErrUnsupportedFormat
+				}
+				record[column] = sharedStrings.Items[index].String()
+			case "inlineStr":
+				record[column] = cell.Inline.String()
+			default:
+				record[column] = cell.Value
+			}
+		}
+		rows = append(rows, record)
+	}
+
+	return rows, nil
+}
+
+func decodePart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return ErrUnsupportedFormat
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return xml.NewDecoder(io.LimitReader(rc, maxXLSXPartSize)).Decode(v)
+}
+
+// columnIndex converts the letters of a cell reference ("C12") to a zero based column.
+func columnIndex(ref string) int {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+		if letters > 3 {
+			return maxXLSXColumns
+		}
+	}
+	if letters == 0 {
+		return -1
+	}
+	return column - 1
+}
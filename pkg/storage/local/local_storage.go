@@ -0,0 +1,77 @@
+package local
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/online-store/pkg/storage"
+)
+
+type localStorage struct {
+	rootDir string
+	baseURL string
+}
+
+// NewLocalStorage stores objects as files under rootDir, served to clients from baseURL.
+func NewLocalStorage(rootDir, baseURL string) storage.Storage {
+	return &localStorage{rootDir: rootDir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s localStorage) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if key == "" || cleaned == "/" || cleaned != "/"+key {
+		return "", storage.ErrInvalidKey
+	}
+	return filepath.Join(s.rootDir, filepath.FromSlash(cleaned)), nil
+}
+
+func (s localStorage) Put(ctx context.Context, key string, content io.Reader, contentType string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (s localStorage) Delete(ctx context.Context, key string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s localStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
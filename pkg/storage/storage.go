@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("storage key is invalid")
+
+// Storage stores objects under slash separated keys. The local filesystem is the
+// only backend for now, S3-compatible backends can implement the same interface.
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
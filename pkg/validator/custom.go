@@ -2,6 +2,8 @@ package validator
 
 import (
 	validatorGo "github.com/go-playground/validator/v10"
+	"github.com/online-store/pkg/money"
+	"reflect"
 	"regexp"
 )
 
@@ -15,6 +17,16 @@ func registerCustomValidator(v *validatorGo.Validate) {
 	if err := v.RegisterValidation("address", ValidateAddress); err != nil {
 		panic(err)
 	}
+
+	// money is validated on its amount in minor units
+	v.RegisterCustomTypeFunc(ValidateMoneyAmount, money.Money{})
+}
+
+func ValidateMoneyAmount(field reflect.Value) interface{} {
+	if value, ok := field.Interface().(money.Money); ok {
+		return value.Amount
+	}
+	return nil
 }
 
 func ValidateName(fl validatorGo.FieldLevel) bool {